@@ -0,0 +1,53 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"fmt"
+)
+
+// ChangeEvents 查询同步时记录的变更, 最新的在前
+func (s *Service) ChangeEvents(filter rancher.ChangeEventFilter) ([]rancher.ChangeEvent, error) {
+	return s.db.GetChangeEvents(filter)
+}
+
+// ChangeTypeLabel 返回变更类型在界面上显示的名称
+func ChangeTypeLabel(changeType string) string {
+	switch changeType {
+	case rancher.ChangeWorkloadAdded:
+		return "新增工作负载"
+	case rancher.ChangeWorkloadRemoved:
+		return "删除工作负载"
+	case rancher.ChangeImage:
+		return "镜像变化"
+	case rancher.ChangeEnvironment:
+		return "环境变量变化"
+	case rancher.ChangeReplicas:
+		return "副本数变化"
+	case rancher.ChangePodState:
+		return "Pod状态变化"
+	}
+	return changeType
+}
+
+// ChangeDetail 变更的对象和前后的值, 如 api: registry/api:1.0 -> registry/api:1.1
+func ChangeDetail(event rancher.ChangeEvent) string {
+	switch event.Type {
+	case rancher.ChangeWorkloadAdded:
+		return event.NewValue
+	case rancher.ChangeWorkloadRemoved:
+		return event.OldValue
+	case rancher.ChangePodState:
+		return fmt.Sprintf("%s: %s -> %s", event.Name, event.OldValue, event.NewValue)
+	}
+	oldValue, newValue := event.OldValue, event.NewValue
+	if oldValue == "" {
+		oldValue = "(无)"
+	}
+	if newValue == "" {
+		newValue = "(无)"
+	}
+	if event.Field == "" {
+		return fmt.Sprintf("%s -> %s", oldValue, newValue)
+	}
+	return fmt.Sprintf("%s: %s -> %s", event.Field, oldValue, newValue)
+}
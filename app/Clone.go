@@ -0,0 +1,200 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExportWorkloads 获取工作负载的YAML, 按options改写后返回每个资源的内容
+func (s *Service) ExportWorkloads(ctx context.Context, workloads []rancher.Workload, options rancher.CloneOptions, progress Progress) ([]ExportedResource, []Result, error) {
+	var resources []ExportedResource
+	results, err := s.eachWorkloadYaml(ctx, ActionExport, workloads, rancher.Namespace{}, options, progress,
+		func(ctx context.Context, workload rancher.Workload, yamlData []byte) (string, error) {
+			resources = append(resources, ExportedResource{Kind: rancher.NormalizeKind(workload.Kind), Name: workload.Name, Yaml: yamlData})
+			return "", nil
+		})
+	return resources, results, err
+}
+
+// CloneWorkloads 将工作负载按options改写后克隆到目标命名空间, 目标命名空间可以属于其他环境
+func (s *Service) CloneWorkloads(ctx context.Context, workloads []rancher.Workload, destNamespace rancher.Namespace, options rancher.CloneOptions, progress Progress) ([]Result, error) {
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("没有要克隆的工作负载")
+	}
+	if destNamespace.Name == "" {
+		return nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	return s.eachWorkloadYaml(ctx, ActionClone, workloads, destNamespace, options, progress,
+		func(ctx context.Context, workload rancher.Workload, yamlData []byte) (string, error) {
+			return importYaml(ctx, destClient, destNamespace.Name, yamlData)
+		})
+}
+
+func (s *Service) eachWorkloadYaml(ctx context.Context, action Action, workloads []rancher.Workload, destNamespace rancher.Namespace, options rancher.CloneOptions, progress Progress,
+	handle func(ctx context.Context, workload rancher.Workload, yamlData []byte) (string, error)) ([]Result, error) {
+	// 目标命名空间和不更新标签的工作负载由服务决定
+	options.DestNamespace = destNamespace.Name
+	options.IgnoreTagNames = s.cloneIgnoreTagWorkload
+	clients := s.newClients()
+	var results []Result
+	for _, workload := range workloads {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		result := workloadResult(action, workload)
+		progress.started(result)
+		client, err := clients.get(workload.Environment, workload.ProjectId)
+		if err == nil {
+			var yamlData []byte
+			if yamlData, err = rancher.BuildWorkloadYaml(ctx, client, workload, options); err == nil {
+				result.Message, err = handle(ctx, workload, yamlData)
+			}
+		}
+		result.Err = err
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return results, ctx.Err()
+}
+
+// ExportConfigMaps 导出命名空间下的所有configMap
+func (s *Service) ExportConfigMaps(ctx context.Context, namespace rancher.Namespace, progress Progress) ([]ExportedResource, []Result, error) {
+	var resources []ExportedResource
+	results, err := s.eachConfigMapYaml(ctx, ActionExport, namespace, rancher.Namespace{}, progress,
+		func(ctx context.Context, name string, yamlData []byte) (string, error) {
+			resources = append(resources, ExportedResource{Kind: "configMap", Name: name, Yaml: yamlData})
+			return "", nil
+		})
+	return resources, results, err
+}
+
+// CloneConfigMaps 将命名空间下的所有configMap克隆到目标命名空间
+func (s *Service) CloneConfigMaps(ctx context.Context, namespace rancher.Namespace, destNamespace rancher.Namespace, progress Progress) ([]Result, error) {
+	if namespace.Name == "" {
+		return nil, fmt.Errorf("未选择命名空间")
+	}
+	if destNamespace.Name == "" {
+		return nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	return s.eachConfigMapYaml(ctx, ActionClone, namespace, destNamespace, progress,
+		func(ctx context.Context, name string, yamlData []byte) (string, error) {
+			return importYaml(ctx, destClient, destNamespace.Name, yamlData)
+		})
+}
+
+func (s *Service) eachConfigMapYaml(ctx context.Context, action Action, namespace rancher.Namespace, destNamespace rancher.Namespace, progress Progress,
+	handle func(ctx context.Context, name string, yamlData []byte) (string, error)) ([]Result, error) {
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.GetConfigMapList(ctx, namespace.Name)
+	if err != nil {
+		return nil, fmt.Errorf("获取配置时出错: %w", err)
+	}
+	var results []Result
+	for _, configMap := range list {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		result := Result{
+			Action:      action,
+			Environment: namespace.Environment,
+			Namespace:   namespace.Name,
+			Kind:        "configMap",
+			Name:        configMap.Name,
+		}
+		progress.started(result)
+		yamlData, err := rancher.BuildConfigMapYaml(configMap, destNamespace.Name)
+		if err == nil {
+			result.Message, err = handle(ctx, configMap.Name, yamlData)
+		}
+		result.Err = err
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return results, ctx.Err()
+}
+
+// importYaml 导入YAML到目标命名空间, 返回每个资源的导入状态
+func importYaml(ctx context.Context, client *rancher.Client, namespace string, yamlData []byte) (string, error) {
+	resources, err := client.ImportYaml(ctx, namespace, yamlData)
+	if err != nil {
+		return "", err
+	}
+	var statuses []string
+	for _, resource := range resources {
+		statuses = append(statuses, resource.String())
+	}
+	return strings.Join(statuses, ", "), nil
+}
+
+// NamespaceExists 检查目标命名空间在Rancher中是否存在, 本地缓存可能已过期, 因此直接查询
+func (s *Service) NamespaceExists(ctx context.Context, namespace rancher.Namespace) (bool, error) {
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.GetNamespace(ctx, namespace.Name)
+	if err != nil {
+		return false, fmt.Errorf("查询命名空间%s失败: %w", namespace.Name, err)
+	}
+	return resp != nil, nil
+}
+
+// CreateNamespace 在目标项目中创建命名空间, 复制源命名空间的标签和描述。source为空时只创建命名空间
+func (s *Service) CreateNamespace(ctx context.Context, namespace rancher.Namespace, source rancher.Namespace) error {
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return err
+	}
+	create := rancher.NamespaceResp{Name: namespace.Name, Description: namespace.Description}
+	if source.Name != "" {
+		sourceClient, err := s.Client(source.Environment, source.Project)
+		if err != nil {
+			return err
+		}
+		sourceResp, err := sourceClient.GetNamespace(ctx, source.Name)
+		if err != nil {
+			return fmt.Errorf("查询源命名空间%s失败: %w", source.Name, err)
+		}
+		if sourceResp != nil {
+			create.Labels = namespaceLabels(sourceResp.Labels)
+			create.Description = sourceResp.Description
+		}
+	}
+	if err := client.CreateNamespace(ctx, create); err != nil {
+		return fmt.Errorf("创建命名空间%s失败: %w", namespace.Name, err)
+	}
+	return nil
+}
+
+// namespaceLabels 复制命名空间标签, 去掉由Rancher和Kubernetes维护的标签
+func namespaceLabels(labels map[string]string) map[string]string {
+	copied := map[string]string{}
+	for key, value := range labels {
+		if strings.HasPrefix(key, "field.cattle.io/") || strings.HasPrefix(key, "cattle.io/") || key == "kubernetes.io/metadata.name" {
+			continue
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+// appendYamlDocument 以YAML文档分隔符追加一个资源
+func appendYamlDocument(allYaml *strings.Builder, kind, name string, yamlData []byte) {
+	allYaml.WriteString(fmt.Sprintf("# %s %s\n", kind, name))
+	allYaml.WriteString("---\n")
+	allYaml.Write(yamlData)
+	allYaml.WriteString("\n")
+}
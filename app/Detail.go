@@ -0,0 +1,284 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NamespaceDetail 命名空间的概要信息
+type NamespaceDetail struct {
+	Namespace       rancher.Namespace
+	EnvironmentName string
+	Pods            []rancher.Pod
+	WorkloadStates  []WorkloadPodStates
+}
+
+// WorkloadPodStates 一个工作负载下所有Pod的状态
+type WorkloadPodStates struct {
+	Name   string
+	States []string
+}
+
+// WorkloadDetail 工作负载的详细信息
+type WorkloadDetail struct {
+	Workload      rancher.Workload
+	Pods          []rancher.Pod
+	NodeIp        string            // 环境的节点IP, 用于拼接NodePort访问地址
+	Services      []rancher.Service // 端口映射
+	Credentials   []Credential      // 从容器环境变量中识别出的数据库账号
+	AccessPaths   []string          // 通过nginx反向代理的访问路径
+	DeployScripts []DeployScript    // 跳板机上的部署配置, 按相关性排序
+}
+
+// Credential 数据库服务的账号信息
+type Credential struct {
+	Label string
+	Value string
+}
+
+// DeployScript 跳板机上与工作负载镜像匹配的部署配置
+type DeployScript struct {
+	Dir    string
+	Script string // 已拼接好参数的脚本命令, 没有脚本时为空
+	Jar    string
+	Image  string
+}
+
+// NamespaceDetail 获取命名空间概要信息
+func (s *Service) NamespaceDetail(namespace rancher.Namespace) (*NamespaceDetail, error) {
+	detail := &NamespaceDetail{Namespace: namespace, EnvironmentName: namespace.Environment}
+	if environment, err := s.Environment(namespace.Environment); err == nil {
+		detail.EnvironmentName = environment.Name
+	}
+	var pods []rancher.Pod
+	var err error
+	if namespace.Project != "" {
+		pods, err = s.db.GetPodsByProjectNamespace(namespace.Environment, namespace.Project, namespace.Name)
+	} else {
+		pods, err = s.db.GetPodsByEnvNamespace(namespace.Environment, namespace.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail.Pods = pods
+	// 按workloadId的最后一部分汇总pod状态
+	index := map[string]int{}
+	for _, pod := range pods {
+		parts := strings.Split(pod.WorkloadId, ":")
+		workloadName := parts[len(parts)-1]
+		i, ok := index[workloadName]
+		if !ok {
+			i = len(detail.WorkloadStates)
+			index[workloadName] = i
+			detail.WorkloadStates = append(detail.WorkloadStates, WorkloadPodStates{Name: workloadName})
+		}
+		detail.WorkloadStates[i].States = append(detail.WorkloadStates[i].States, pod.State)
+	}
+	sort.Slice(detail.WorkloadStates, func(i, j int) bool {
+		return detail.WorkloadStates[i].Name < detail.WorkloadStates[j].Name
+	})
+	return detail, nil
+}
+
+// WorkloadDetail 获取工作负载详细信息
+func (s *Service) WorkloadDetail(workload rancher.Workload) (*WorkloadDetail, error) {
+	detail := &WorkloadDetail{Workload: workload}
+	var pods []rancher.Pod
+	var err error
+	if workload.ProjectId != "" {
+		pods, err = s.db.GetPodsByProjectNamespaceWorkload(workload.Environment, workload.ProjectId, workload.Namespace, workload.Name)
+	} else {
+		pods, err = s.db.GetPodsByEnvNamespaceWorkload(workload.Environment, workload.Namespace, workload.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail.Pods = pods
+	detail.Credentials = findCredentials(workload)
+	if environment, err := s.Environment(workload.Environment); err == nil {
+		detail.NodeIp = environment.Ip
+	}
+	if services, err := s.db.GetServicesByWorkload(workload.Environment, workload.ProjectId, workload.Namespace, workload.Name); err == nil {
+		detail.Services = services
+	}
+	// AccessPath按逗号分隔成多行
+	if workload.AccessPath != "" {
+		for _, path := range strings.Split(workload.AccessPath, ",") {
+			detail.AccessPaths = append(detail.AccessPaths, strings.TrimSpace(path))
+		}
+	}
+	detail.DeployScripts = s.FindDeployScripts(workload)
+	return detail, nil
+}
+
+// findCredentials 检查数据库相关的环境变量, 依次查找所有容器, 数据库不一定运行在主容器中
+func findCredentials(workload rancher.Workload) []Credential {
+	name := strings.ToLower(workload.Name)
+	if !(strings.Contains(name, "mysql") || strings.Contains(name, "mongo")) {
+		return nil
+	}
+	// 更新数据之前保存的工作负载没有容器数据, 使用主容器的环境变量
+	environments := []string{workload.ContainerEnvironment}
+	if len(workload.Containers) > 0 {
+		environments = nil
+		for _, container := range workload.Containers {
+			environments = append(environments, container.Environment)
+		}
+	}
+	var credentials []Credential
+	found := map[string]bool{}
+	for _, environment := range environments {
+		var envVars map[string]string
+		if environment == "" || json.Unmarshal([]byte(environment), &envVars) != nil {
+			continue
+		}
+		for _, item := range []struct{ key, label string }{
+			{"MYSQL_ROOT_PASSWORD", "MySQL Root密码"},
+			{"MONGO_INITDB_ROOT_USERNAME", "MongoDB初始化Root用户名"},
+			{"MONGO_INITDB_ROOT_PASSWORD", "MongoDB初始化Root密码"},
+		} {
+			if value, exists := envVars[item.key]; exists && !found[item.key] {
+				found[item.key] = true
+				credentials = append(credentials, Credential{Label: item.label, Value: value})
+			}
+		}
+	}
+	return credentials
+}
+
+// FindDeployScripts 在跳板机扫描结果中查找与工作负载任一容器镜像匹配的部署配置, 按容器顺序排列。
+// 依次按完整镜像、不带标签的镜像、镜像最后一段匹配, 镜像中的$表示脚本参数:
+// 一个$时传入标签, 两个$时传入标签和镜像所在目录。
+func (s *Service) FindDeployScripts(workload rancher.Workload) []DeployScript {
+	var scripts []DeployScript
+	for _, image := range workload.Images() {
+		scripts = append(scripts, s.findDeployScriptsByImage(image, workload.Namespace)...)
+	}
+	return scripts
+}
+
+// findDeployScriptsByImage 查找与单个镜像匹配的部署配置
+func (s *Service) findDeployScriptsByImage(fullImage string, namespace string) []DeployScript {
+	var uploadConfigList []rancher.UploadConfig
+	// 获取完整镜像名称的配置
+	configs, _ := s.db.GetUploadConfigsByImage(fullImage)
+	uploadConfigList = append(uploadConfigList, configs...)
+
+	// 获取不带标签的镜像名称的配置
+	image := fullImage
+	tag := ""
+	if colonIndex := strings.LastIndex(fullImage, ":"); colonIndex > 0 {
+		image = fullImage[:colonIndex]
+		tag = fullImage[colonIndex+1:]
+	}
+	configs1, _ := s.db.GetUploadConfigsByImageLikeSpecial1(image)
+	uploadConfigList = append(uploadConfigList, configs1...)
+	// 获取最后两个/之间的部分
+	imageDir := ""
+	if strings.Count(image, "/") >= 2 {
+		lastSlashIndex := strings.LastIndex(image, "/")
+		lastTwoSlashIndex := strings.LastIndex(image[:lastSlashIndex], "/")
+		if lastTwoSlashIndex > 0 {
+			imageDir = image[lastTwoSlashIndex+1 : lastSlashIndex]
+		}
+	}
+	if lastSlashIndex := strings.LastIndex(image, "/"); lastSlashIndex >= 0 {
+		image = image[lastSlashIndex+1:]
+	}
+	configs2, _ := s.db.GetUploadConfigsByImageLikeSpecial2(image)
+	uploadConfigList = append(uploadConfigList, configs2...)
+
+	sortUploadConfigs(uploadConfigList, namespace)
+
+	scripts := make([]DeployScript, 0, len(uploadConfigList))
+	for _, config := range uploadConfigList {
+		script := DeployScript{
+			Dir:   strings.ReplaceAll(config.Dir, "\\", "/"),
+			Jar:   config.Jar,
+			Image: config.Image,
+		}
+		if config.Script != "" {
+			script.Script = config.Script
+			if strings.Count(config.Image, "$") == 1 {
+				script.Script = script.Script + " " + tag
+			} else if strings.Count(config.Image, "$") == 2 {
+				script.Script = script.Script + " " + tag + " " + imageDir
+			}
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts
+}
+
+// sortUploadConfigs 参数越少越精确, 排在前面; 参数数量相同时目录包含命名空间中间部分的排在前面
+func sortUploadConfigs(uploadConfigList []rancher.UploadConfig, namespace string) {
+	middlePart := ""
+	if parts := strings.Split(namespace, "-"); len(parts) >= 3 {
+		// 取两个-号之间的部分
+		middlePart = parts[1]
+	}
+	sort.SliceStable(uploadConfigList, func(i, j int) bool {
+		// 获取$符号数量
+		dollarCountI := strings.Count(uploadConfigList[i].Image, "$")
+		dollarCountJ := strings.Count(uploadConfigList[j].Image, "$")
+		if dollarCountI != dollarCountJ {
+			return dollarCountI < dollarCountJ
+		}
+		if middlePart != "" {
+			containsI := strings.Contains(uploadConfigList[i].Dir, middlePart)
+			containsJ := strings.Contains(uploadConfigList[j].Dir, middlePart)
+			if containsI != containsJ {
+				return containsI
+			}
+		}
+		return false
+	})
+}
+
+// UpdateUploadConfigs 重新扫描跳板机上的部署配置并保存, onProgress可以为nil
+func (s *Service) UpdateUploadConfigs(onProgress func(currentFolder string, current, total int)) error {
+	jumpHost, err := rancher.GetJumpHostFromConfig(s.config)
+	if err != nil {
+		return err
+	}
+	if jumpHost == nil {
+		return fmt.Errorf("未配置跳板机信息")
+	}
+	if err := s.db.DeleteAllUploadConfigs(); err != nil {
+		return err
+	}
+	rancher.ListUploadConfig(jumpHost, 50, &uploadConfigListener{db: s.db, onProgress: onProgress})
+	return nil
+}
+
+// uploadConfigListener 将跳板机扫描结果写入数据库
+type uploadConfigListener struct {
+	db         *rancher.DatabaseManager
+	onProgress func(currentFolder string, current, total int)
+}
+
+func (l *uploadConfigListener) OnProgress(currentFolder string, current, total int) {
+	if l.onProgress != nil {
+		l.onProgress(currentFolder, current, total)
+	}
+}
+
+func (l *uploadConfigListener) OnComplete() {
+}
+
+func (l *uploadConfigListener) OnBatchResult(configs []rancher.SSHUploadConfig) {
+	// 将 SSHUploadConfig 转换为 UploadConfig
+	var uploadConfigs []rancher.UploadConfig
+	for _, config := range configs {
+		uploadConfigs = append(uploadConfigs, rancher.UploadConfig{
+			Dir:    config.Dir,
+			Script: config.Script,
+			Jar:    config.Jar,
+			Image:  config.Image,
+		})
+	}
+	l.db.InsertUploadConfigs(uploadConfigs)
+}
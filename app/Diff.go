@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp 差异中一行的类型
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // 两边相同
+	DiffDelete               // 只在当前内容中
+	DiffInsert               // 只在新内容中
+)
+
+// DiffLine 差异中的一行
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffRow 并排显示时的一行, 左边为当前内容, 右边为新内容; 某一边没有对应行时为nil
+type DiffRow struct {
+	Left  *string
+	Right *string
+}
+
+// Changed 这一行两边是否不同
+func (r DiffRow) Changed() bool {
+	return r.Left == nil || r.Right == nil || *r.Left != *r.Right
+}
+
+// Diff 按行比较两段文本, 基于最长公共子序列
+func Diff(current, next string) []DiffLine {
+	a := splitLines(current)
+	b := splitLines(next)
+	// lcs[i][j] 为 a[i:] 和 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{DiffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{DiffDelete, a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{DiffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{DiffDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{DiffInsert, b[j]})
+	}
+	return lines
+}
+
+// SideBySide 将差异转换为并排显示的行, 相邻的删除和新增行配对显示
+func SideBySide(lines []DiffLine) []DiffRow {
+	var rows []DiffRow
+	for i := 0; i < len(lines); {
+		if lines[i].Op == DiffEqual {
+			text := lines[i].Text
+			rows = append(rows, DiffRow{Left: &text, Right: &text})
+			i++
+			continue
+		}
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Op != DiffEqual; i++ {
+			if lines[i].Op == DiffDelete {
+				deleted = append(deleted, lines[i].Text)
+			} else {
+				inserted = append(inserted, lines[i].Text)
+			}
+		}
+		for k := 0; k < len(deleted) || k < len(inserted); k++ {
+			var row DiffRow
+			if k < len(deleted) {
+				row.Left = &deleted[k]
+			}
+			if k < len(inserted) {
+				row.Right = &inserted[k]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// UnifiedDiff 以 -/+ 前缀输出差异, 只保留变化行前后context行
+func UnifiedDiff(lines []DiffLine, context int) string {
+	var sb strings.Builder
+	lastPrinted := -1
+	for i, line := range lines {
+		if !nearChange(lines, i, context) {
+			continue
+		}
+		if lastPrinted >= 0 && i > lastPrinted+1 {
+			sb.WriteString("@@\n")
+		}
+		switch line.Op {
+		case DiffDelete:
+			sb.WriteString(fmt.Sprintf("-%s\n", line.Text))
+		case DiffInsert:
+			sb.WriteString(fmt.Sprintf("+%s\n", line.Text))
+		default:
+			sb.WriteString(fmt.Sprintf(" %s\n", line.Text))
+		}
+		lastPrinted = i
+	}
+	return sb.String()
+}
+
+// nearChange 第i行前后context行内是否有变化
+func nearChange(lines []DiffLine, i, context int) bool {
+	for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+		if lines[k].Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
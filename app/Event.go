@@ -0,0 +1,115 @@
+package app
+
+import (
+	"RancherMan/rancher"
+)
+
+// Action 服务层支持的操作
+type Action string
+
+const (
+	ActionSync         Action = "sync"
+	ActionSyncPods     Action = "pods"
+	ActionSyncServices Action = "services"
+	ActionStart        Action = "start"
+	ActionStop         Action = "stop"
+	ActionRedeploy     Action = "redeploy"
+	ActionScale        Action = "scale"
+	ActionTrigger      Action = "trigger"
+	ActionExport       Action = "export"
+	ActionClone        Action = "clone"
+	ActionPreview      Action = "preview"
+)
+
+// Label 返回操作在界面上显示的名称
+func (a Action) Label() string {
+	switch a {
+	case ActionSync:
+		return "更新数据"
+	case ActionSyncPods:
+		return "更新Pod"
+	case ActionSyncServices:
+		return "更新端口映射"
+	case ActionStart:
+		return "打开"
+	case ActionStop:
+		return "关闭"
+	case ActionRedeploy:
+		return "重新部署"
+	case ActionScale:
+		return "伸缩"
+	case ActionTrigger:
+		return "立即执行"
+	case ActionExport:
+		return "导出"
+	case ActionClone:
+		return "克隆"
+	case ActionPreview:
+		return "预览"
+	}
+	return string(a)
+}
+
+// Result 对单个对象(环境、工作负载或configMap)执行操作的结果
+type Result struct {
+	Action      Action
+	Environment string
+	Namespace   string
+	Kind        string // 对象类型, 如deployment、configMap, 环境级操作为空
+	Name        string
+	Message     string // 成功时的补充信息, 如手动触发的Job名称
+	Err         error
+}
+
+// Success 操作是否成功
+func (r Result) Success() bool {
+	return r.Err == nil
+}
+
+// Reason 返回失败原因, 成功时为空
+func (r Result) Reason() string {
+	if r.Err == nil {
+		return ""
+	}
+	return rancher.ErrorReason(r.Err)
+}
+
+// EventType 进度事件类型
+type EventType int
+
+const (
+	EventStarted  EventType = iota // 开始处理一个对象
+	EventFinished                  // 一个对象处理完成, Result中包含结果
+)
+
+// Event 批量操作的进度事件
+type Event struct {
+	Type   EventType
+	Result Result
+}
+
+// Progress 进度回调, 在执行操作的goroutine中同步调用, 可以为nil
+type Progress func(Event)
+
+func (p Progress) started(result Result) {
+	if p != nil {
+		p(Event{Type: EventStarted, Result: result})
+	}
+}
+
+func (p Progress) finished(result Result) {
+	if p != nil {
+		p(Event{Type: EventFinished, Result: result})
+	}
+}
+
+// Failed 统计失败的结果数量
+func Failed(results []Result) int {
+	failed := 0
+	for _, result := range results {
+		if !result.Success() {
+			failed++
+		}
+	}
+	return failed
+}
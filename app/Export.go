@@ -0,0 +1,207 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportLayout 导出文件的组织方式
+type ExportLayout string
+
+const (
+	LayoutSingleFile  ExportLayout = "single" // 所有资源合并为一个多文档YAML文件
+	LayoutPerResource ExportLayout = "files"  // 每个资源一个文件, 路径为 类型/名称.yaml
+	LayoutArchive     ExportLayout = "tar.gz" // 每个资源一个文件, 打包为tar.gz
+)
+
+// ExportLayouts 所有导出方式, 按界面上的显示顺序排列
+var ExportLayouts = []ExportLayout{LayoutSingleFile, LayoutPerResource, LayoutArchive}
+
+// Label 返回导出方式在界面上显示的名称
+func (l ExportLayout) Label() string {
+	switch l {
+	case LayoutSingleFile:
+		return "单个文件"
+	case LayoutPerResource:
+		return "每个资源一个文件"
+	case LayoutArchive:
+		return "tar.gz压缩包"
+	}
+	return string(l)
+}
+
+// Extension 返回该导出方式的文件扩展名, 导出到目录时为空
+func (l ExportLayout) Extension() string {
+	switch l {
+	case LayoutSingleFile:
+		return ".yaml"
+	case LayoutArchive:
+		return ".tar.gz"
+	}
+	return ""
+}
+
+// ParseExportLayout 解析命令行中的导出方式, 同时接受界面上显示的名称
+func ParseExportLayout(name string) (ExportLayout, error) {
+	for _, layout := range ExportLayouts {
+		if name == string(layout) || name == layout.Label() {
+			return layout, nil
+		}
+	}
+	return "", fmt.Errorf("未知的导出方式: %s", name)
+}
+
+// ExportOptions 导出文件的参数
+type ExportOptions struct {
+	Layout    ExportLayout
+	Helm      bool   // 生成Helm chart, 命名空间、镜像标签和副本数提取到values.yaml
+	ChartName string // chart名称, 为空时为export
+}
+
+// ExportedResource 导出的一个资源
+type ExportedResource struct {
+	Kind string
+	Name string
+	Yaml []byte
+}
+
+// ExportFile 导出结果中的一个文件, Path为相对于导出目录的路径
+type ExportFile struct {
+	Path string
+	Data []byte
+}
+
+// JoinYaml 将导出的资源合并为一个多文档YAML
+func JoinYaml(resources []ExportedResource) []byte {
+	var allYaml strings.Builder
+	for _, resource := range resources {
+		appendYamlDocument(&allYaml, resource.Kind, resource.Name, resource.Yaml)
+	}
+	return []byte(allYaml.String())
+}
+
+// ExportFiles 按导出方式生成文件列表。单个文件的导出方式只有一个文件, 路径为空
+func ExportFiles(resources []ExportedResource, options ExportOptions) ([]ExportFile, error) {
+	if options.Helm {
+		if options.Layout == LayoutSingleFile {
+			return nil, fmt.Errorf("Helm chart不能导出为单个文件, 请选择目录或压缩包")
+		}
+		return helmChartFiles(resources, options.ChartName)
+	}
+	if options.Layout == LayoutSingleFile {
+		return []ExportFile{{Data: JoinYaml(resources)}}, nil
+	}
+	files := make([]ExportFile, 0, len(resources))
+	for _, resource := range resources {
+		files = append(files, ExportFile{
+			Path: filepath.ToSlash(filepath.Join(strings.ToLower(resource.Kind), resource.Name+".yaml")),
+			Data: resource.Yaml,
+		})
+	}
+	return files, nil
+}
+
+// helmChartFiles 生成chart目录下的所有文件, 文件放在以chart名称命名的目录中
+func helmChartFiles(resources []ExportedResource, chartName string) ([]ExportFile, error) {
+	if chartName == "" {
+		chartName = "export"
+	}
+	chart := rancher.NewHelmChart(chartName)
+	for _, resource := range resources {
+		if err := chart.AddYaml(resource.Kind, resource.Name, resource.Yaml); err != nil {
+			return nil, err
+		}
+	}
+	chartYaml, err := chart.ChartYaml()
+	if err != nil {
+		return nil, err
+	}
+	valuesYaml, err := chart.ValuesYaml()
+	if err != nil {
+		return nil, err
+	}
+	files := []ExportFile{
+		{Path: chartName + "/Chart.yaml", Data: chartYaml},
+		{Path: chartName + "/values.yaml", Data: valuesYaml},
+	}
+	for _, template := range chart.Templates() {
+		files = append(files, ExportFile{Path: chartName + "/templates/" + template.Name, Data: template.Content})
+	}
+	return files, nil
+}
+
+// WriteExport 将导出的资源写入target: 单个文件和压缩包写入target文件, 其他方式写入target目录
+func WriteExport(target string, resources []ExportedResource, options ExportOptions) error {
+	if len(resources) == 0 {
+		return fmt.Errorf("没有可导出的资源")
+	}
+	files, err := ExportFiles(resources, options)
+	if err != nil {
+		return err
+	}
+	if options.Layout == LayoutSingleFile {
+		return writeFile(target, files[0].Data)
+	}
+	return WriteFiles(target, files, options.Layout == LayoutArchive)
+}
+
+// WriteFiles 将文件写入target目录, archive为true时打包为target压缩包
+func WriteFiles(target string, files []ExportFile, archive bool) error {
+	if archive {
+		return writeArchive(target, files)
+	}
+	for _, file := range files {
+		if err := writeFile(filepath.Join(target, filepath.FromSlash(file.Path)), file.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入%s失败: %w", path, err)
+	}
+	return nil
+}
+
+// writeArchive 将文件打包为tar.gz
+func writeArchive(path string, files []ExportFile) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建%s失败: %w", path, err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	gzipWriter := gzip.NewWriter(out)
+	tarWriter := tar.NewWriter(gzipWriter)
+	modTime := time.Now()
+	for _, file := range files {
+		header := &tar.Header{Name: file.Path, Mode: 0644, Size: int64(len(file.Data)), ModTime: modTime}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("写入压缩包失败: %w", err)
+		}
+		if _, err := tarWriter.Write(file.Data); err != nil {
+			return fmt.Errorf("写入压缩包失败: %w", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportDocument 从本地文件读取的一个资源
+type ImportDocument struct {
+	Source    string // 来源文件, 多文档文件中附带序号
+	Kind      string
+	Name      string
+	Namespace string // 文件中的命名空间, 可能为空
+	Resource  rancher.NamespacedResource
+	Yaml      []byte
+	Err       error // 解析或校验失败的原因
+}
+
+// 导入目录或压缩包时跳过的文件: kustomize和Helm chart的描述文件不是Kubernetes资源
+var skippedImportFiles = map[string]bool{
+	"kustomization.yaml": true,
+	"Chart.yaml":         true,
+	"values.yaml":        true,
+}
+
+// LoadImportDocuments 读取本地的YAML文件、目录或tar.gz压缩包(包括本工具导出的各种格式), 拆分多文档后逐个校验。
+// 文件无法读取时返回错误, 单个资源的问题记录在ImportDocument.Err中
+func LoadImportDocuments(paths []string) ([]ImportDocument, error) {
+	var documents []ImportDocument
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取%s失败: %w", path, err)
+		}
+		switch {
+		case info.IsDir():
+			err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if entry.IsDir() || !isImportFile(file) {
+					return nil
+				}
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return fmt.Errorf("读取%s失败: %w", file, err)
+				}
+				documents = append(documents, splitYamlDocuments(file, content)...)
+				return nil
+			})
+		case isArchive(path):
+			var archived []ImportDocument
+			archived, err = loadArchive(path)
+			documents = append(documents, archived...)
+		default:
+			var content []byte
+			if content, err = os.ReadFile(path); err == nil {
+				documents = append(documents, splitYamlDocuments(path, content)...)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
+
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+func isImportFile(path string) bool {
+	extension := filepath.Ext(path)
+	return (extension == ".yaml" || extension == ".yml") && !skippedImportFiles[filepath.Base(path)]
+}
+
+// loadArchive 读取tar.gz压缩包中的所有YAML文件
+func loadArchive(path string) ([]ImportDocument, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取%s失败: %w", path, err)
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("解压%s失败: %w", path, err)
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	var documents []ImportDocument
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解压%s失败: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg || !isImportFile(header.Name) {
+			continue
+		}
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("解压%s失败: %w", path, err)
+		}
+		documents = append(documents, splitYamlDocuments(path+"/"+header.Name, content)...)
+	}
+}
+
+// splitYamlDocuments 拆分多文档YAML并校验每个资源, 空文档被跳过
+func splitYamlDocuments(source string, content []byte) []ImportDocument {
+	if bytes.Contains(content, []byte("{{")) {
+		return []ImportDocument{{Source: source, Err: fmt.Errorf("包含模板表达式, 请先渲染(如 helm template)后再导入")}}
+	}
+	var documents []ImportDocument
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for index := 1; ; index++ {
+		document := ImportDocument{Source: source}
+		if index > 1 {
+			document.Source = fmt.Sprintf("%s#%d", source, index)
+		}
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			return documents
+		}
+		if err != nil {
+			// 解析失败后无法定位下一个文档, 整个文件的剩余部分都跳过
+			document.Err = fmt.Errorf("解析YAML失败: %w", err)
+			return append(documents, document)
+		}
+		if object == nil {
+			continue
+		}
+		document.Kind, _ = object["kind"].(string)
+		document.Name = rancher.ObjectName(object)
+		if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+			document.Namespace, _ = metadata["namespace"].(string)
+		}
+		document.Yaml, err = yaml.Marshal(object)
+		if err == nil {
+			document.Resource, err = rancher.ValidateResource(document.Yaml)
+		}
+		document.Err = err
+		documents = append(documents, document)
+	}
+}
+
+// PreviewImport 将本地资源按options改写到目标命名空间, 生成与现有内容对比的预览, 不修改目标命名空间。
+// 预览按导入顺序排列, 校验失败的资源只出现在结果中
+func (s *Service) PreviewImport(ctx context.Context, documents []ImportDocument, destNamespace rancher.Namespace, options rancher.CloneOptions, progress Progress) ([]ClonePreview, []Result, error) {
+	if destNamespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	options.DestNamespace = destNamespace.Name
+	options.IgnoreTagNames = s.cloneIgnoreTagWorkload
+
+	order := map[string]int{}
+	for i, resource := range rancher.ImportableResources {
+		order[resource.Kind] = i
+	}
+	sorted := append([]ImportDocument(nil), documents...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order[sorted[i].Resource.Kind] < order[sorted[j].Resource.Kind]
+	})
+
+	currentByKind := map[string]map[string]string{}
+	var previews []ClonePreview
+	var results []Result
+	for _, document := range sorted {
+		if ctx.Err() != nil {
+			return previews, results, ctx.Err()
+		}
+		result := Result{
+			Action:      ActionPreview,
+			Environment: destNamespace.Environment,
+			Namespace:   destNamespace.Name,
+			Kind:        document.Kind,
+			Name:        document.Name,
+		}
+		if result.Name == "" {
+			result.Name = document.Source
+		}
+		progress.started(result)
+		result.Message, result.Err = func() (string, error) {
+			if document.Err != nil {
+				return "", fmt.Errorf("%s: %w", document.Source, document.Err)
+			}
+			current, ok := currentByKind[document.Kind]
+			if !ok {
+				var err error
+				if current, err = currentResources(ctx, destClient, document.Resource, destNamespace.Name, options.RedactSecrets); err != nil {
+					return "", err
+				}
+				currentByKind[document.Kind] = current
+			}
+			pipeline := options.ResourcePipeline(document.Kind, document.Namespace, document.Name)
+			yamlData, err := pipeline.ApplyYaml(document.Yaml)
+			if err != nil {
+				return "", fmt.Errorf("改写%s失败: %w", document.Name, err)
+			}
+			preview := newClonePreview(document.Kind, document.Name, current[document.Name], yamlData)
+			if document.Kind == rancher.ResourceSecret.Kind {
+				preview = newSecretPreview(document.Kind, document.Name, current[document.Name], yamlData, options.RedactSecrets)
+			}
+			previews = append(previews, preview)
+			return string(preview.Status), nil
+		}()
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return previews, results, ctx.Err()
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kustomization kustomization.yaml的内容
+type kustomization struct {
+	APIVersion string           `yaml:"apiVersion"`
+	Kind       string           `yaml:"kind"`
+	Namespace  string           `yaml:"namespace,omitempty"`
+	Resources  []string         `yaml:"resources"`
+	Patches    []kustomizePatch `yaml:"patches,omitempty"`
+}
+
+type kustomizePatch struct {
+	Path string `yaml:"path"`
+}
+
+func newKustomization(namespace string, resources []string) kustomization {
+	return kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Namespace:  namespace,
+		Resources:  resources,
+	}
+}
+
+// baseWorkload 写入base的工作负载及其完整对象
+type baseWorkload struct {
+	workload rancher.Workload
+	object   map[string]interface{}
+	file     string
+}
+
+// ExportKustomize 将源命名空间中的工作负载导出为kustomize的base, 并为每个目标环境生成overlay。
+// overlay中只包含与源环境不同的字段(镜像、环境变量、副本数、节点亲和性), 由本地缓存中各环境的工作负载比较得出;
+// destNamespace为目标环境中的命名空间, 为空时与源命名空间同名
+func (s *Service) ExportKustomize(ctx context.Context, source rancher.Namespace, workloads []rancher.Workload, environments []string, destNamespace string, progress Progress) ([]ExportFile, []Result, error) {
+	if len(environments) == 0 {
+		return nil, nil, fmt.Errorf("未选择目标环境")
+	}
+	if destNamespace == "" {
+		destNamespace = source.Name
+	}
+
+	// base保持源环境中的原样, 不补充默认的节点亲和性
+	var bases []baseWorkload
+	var files []ExportFile
+	results, err := s.eachWorkloadYaml(ctx, ActionExport, workloads, rancher.Namespace{}, rancher.CloneOptions{KeepAffinity: true}, progress,
+		func(ctx context.Context, workload rancher.Workload, yamlData []byte) (string, error) {
+			var object map[string]interface{}
+			if err := yaml.Unmarshal(yamlData, &object); err != nil {
+				return "", fmt.Errorf("解析%s的YAML失败: %w", workload.Name, err)
+			}
+			file := fmt.Sprintf("%s-%s.yaml", strings.ToLower(rancher.NormalizeKind(workload.Kind)), workload.Name)
+			bases = append(bases, baseWorkload{workload: workload, object: object, file: file})
+			files = append(files, ExportFile{Path: "base/" + file, Data: yamlData})
+			return "", nil
+		})
+	if err != nil {
+		return nil, results, err
+	}
+	if len(bases) == 0 {
+		return nil, results, fmt.Errorf("没有可导出的工作负载")
+	}
+	var resources []string
+	for _, base := range bases {
+		resources = append(resources, base.file)
+	}
+	baseData, err := yaml.Marshal(newKustomization("", resources))
+	if err != nil {
+		return nil, results, err
+	}
+	files = append(files, ExportFile{Path: "base/kustomization.yaml", Data: baseData})
+
+	for _, envName := range environments {
+		if ctx.Err() != nil {
+			return nil, results, ctx.Err()
+		}
+		overlayFiles, overlayResults, err := s.kustomizeOverlay(envName, destNamespace, source.Name, bases, progress)
+		results = append(results, overlayResults...)
+		if err != nil {
+			return nil, results, err
+		}
+		files = append(files, overlayFiles...)
+	}
+	return files, results, ctx.Err()
+}
+
+// kustomizeOverlay 生成一个目标环境的overlay, 每个有差异的工作负载一个补丁文件
+func (s *Service) kustomizeOverlay(envName string, destNamespace string, sourceNamespace string, bases []baseWorkload, progress Progress) ([]ExportFile, []Result, error) {
+	targets, err := s.db.GetWorkloadDetailsByEnvNamespace(envName, destNamespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取%s的工作负载失败: %w", envName, err)
+	}
+	targetByName := map[string]rancher.Workload{}
+	for _, target := range targets {
+		targetByName[rancher.NormalizeKind(target.Kind)+"/"+target.Name] = target
+	}
+
+	dir := "overlays/" + envName + "/"
+	var files []ExportFile
+	var results []Result
+	overlay := newKustomization("", []string{"../../base"})
+	if destNamespace != sourceNamespace {
+		overlay.Namespace = destNamespace
+	}
+	for _, base := range bases {
+		result := Result{
+			Action:      ActionExport,
+			Environment: envName,
+			Namespace:   destNamespace,
+			Kind:        rancher.NormalizeKind(base.workload.Kind),
+			Name:        base.workload.Name,
+		}
+		progress.started(result)
+		target, ok := targetByName[result.Kind+"/"+result.Name]
+		if !ok {
+			result.Message = "目标环境中不存在, 未生成补丁"
+		} else {
+			result.Message, result.Err = func() (string, error) {
+				diff, err := rancher.DiffWorkload(base.workload, target)
+				if err != nil {
+					return "", err
+				}
+				changes := diff.Changes()
+				if len(changes) == 0 {
+					return "无差异", nil
+				}
+				patch, err := diff.Patch(base.object)
+				if err != nil {
+					return "", err
+				}
+				patchData, err := yaml.Marshal(patch)
+				if err != nil {
+					return "", err
+				}
+				files = append(files, ExportFile{Path: dir + base.file, Data: patchData})
+				overlay.Patches = append(overlay.Patches, kustomizePatch{Path: base.file})
+				return strings.Join(changes, "、"), nil
+			}()
+		}
+		results = append(results, result)
+		progress.finished(result)
+	}
+	overlayData, err := yaml.Marshal(overlay)
+	if err != nil {
+		return nil, results, err
+	}
+	files = append(files, ExportFile{Path: dir + "kustomization.yaml", Data: overlayData})
+	return files, results, nil
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// namespaceResources 克隆整个命名空间时收集的资源类型, 按导入顺序排列: 被引用的资源在前,
+// 工作负载在其使用的配置、密钥和存储之后, Ingress在其指向的Service之后。
+// CronJob创建的Job带有ownerReferences, 会作为自动生成的资源跳过, 只克隆单独创建的Job
+var namespaceResources = []rancher.NamespacedResource{
+	rancher.ResourceSecret,
+	rancher.ResourceConfigMap,
+	rancher.ResourcePersistentVolumeClaim,
+	rancher.ResourceService,
+	rancher.ResourceDeployment,
+	rancher.ResourceStatefulSet,
+	rancher.ResourceDaemonSet,
+	rancher.ResourceCronJob,
+	rancher.ResourceJob,
+	rancher.ResourceIngress,
+}
+
+// NamespaceCloneOptions 克隆整个命名空间的参数
+type NamespaceCloneOptions struct {
+	rancher.CloneOptions
+	IncludeSecrets bool // 是否克隆Secret, 默认不克隆; 同时设置RedactSecrets时只克隆键名
+}
+
+// PreviewCloneNamespace 收集源命名空间中的所有资源, 改写后与目标命名空间的现有内容对比。
+// 返回的预览按导入顺序排列, 不修改目标命名空间
+func (s *Service) PreviewCloneNamespace(ctx context.Context, source rancher.Namespace, destNamespace rancher.Namespace, options NamespaceCloneOptions, progress Progress) ([]ClonePreview, []Result, error) {
+	if destNamespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择目标命名空间")
+	}
+	sourceClient, err := s.Client(source.Environment, source.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	cloneOptions := options.CloneOptions
+	cloneOptions.DestNamespace = destNamespace.Name
+	cloneOptions.IgnoreTagNames = s.cloneIgnoreTagWorkload
+
+	var previews []ClonePreview
+	var results []Result
+	for _, resource := range namespaceResources {
+		if resource == rancher.ResourceSecret && !options.IncludeSecrets {
+			continue
+		}
+		if ctx.Err() != nil {
+			return previews, results, ctx.Err()
+		}
+		objects, err := sourceClient.ListResources(ctx, resource, source.Name)
+		if err != nil {
+			return previews, results, fmt.Errorf("获取%s列表失败: %w", resource.Kind, err)
+		}
+		current, err := currentResources(ctx, destClient, resource, destNamespace.Name, options.RedactSecrets)
+		if err != nil {
+			return previews, results, err
+		}
+		for _, object := range objects {
+			if rancher.IsGeneratedResource(object) {
+				continue
+			}
+			name := rancher.ObjectName(object)
+			result := Result{
+				Action:      ActionPreview,
+				Environment: source.Environment,
+				Namespace:   source.Name,
+				Kind:        resource.Kind,
+				Name:        name,
+			}
+			progress.started(result)
+			var yamlData []byte
+			pipeline := cloneOptions.ResourcePipeline(resource.Kind, source.Name, name)
+			if err = pipeline.Apply(object); err == nil {
+				yamlData, err = yaml.Marshal(object)
+			}
+			if err == nil {
+				preview := newClonePreview(resource.Kind, name, current[name], yamlData)
+				if resource == rancher.ResourceSecret {
+					preview = newSecretPreview(resource.Kind, name, current[name], yamlData, options.RedactSecrets)
+				}
+				previews = append(previews, preview)
+				result.Message = string(preview.Status)
+			}
+			result.Err = err
+			results = append(results, result)
+			progress.finished(result)
+		}
+	}
+	return previews, results, ctx.Err()
+}
+
+// currentResources 返回目标命名空间中某类资源的现有内容, 按名称索引。redactSecrets为true时Secret的值同样替换为占位符,
+// 预览中不显示目标命名空间中的真实值
+func currentResources(ctx context.Context, client *rancher.Client, resource rancher.NamespacedResource, namespace string, redactSecrets bool) (map[string]string, error) {
+	objects, err := client.ListResources(ctx, resource, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标命名空间的%s列表失败: %w", resource.Kind, err)
+	}
+	// 只去掉服务端维护和分配的字段, 避免产生无意义的差异
+	normalize := rancher.Pipeline{rancher.StripStatus()}
+	switch resource {
+	case rancher.ResourceService:
+		normalize = append(normalize, rancher.ResetServiceAddresses())
+	case rancher.ResourcePersistentVolumeClaim:
+		normalize = append(normalize, rancher.UnbindVolumeClaim())
+	case rancher.ResourceJob:
+		normalize = append(normalize, rancher.ResetJobSelector())
+	case rancher.ResourceSecret:
+		if redactSecrets {
+			normalize = append(normalize, rancher.RedactSecret())
+		}
+	}
+	current := map[string]string{}
+	for _, object := range objects {
+		if err := normalize.Apply(object); err != nil {
+			return nil, err
+		}
+		yamlData, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		current[rancher.ObjectName(object)] = string(yamlData)
+	}
+	return current, nil
+}
+
+// CloneNamespace 克隆整个命名空间: 目标命名空间不存在时在目标项目中创建, 然后按依赖顺序导入所有资源
+func (s *Service) CloneNamespace(ctx context.Context, source rancher.Namespace, destNamespace rancher.Namespace, options NamespaceCloneOptions, progress Progress) ([]Result, error) {
+	exists, err := s.NamespaceExists(ctx, destNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.CreateNamespace(ctx, destNamespace, source); err != nil {
+			return nil, err
+		}
+	}
+	previews, results, err := s.PreviewCloneNamespace(ctx, source, destNamespace, options, progress)
+	if err != nil {
+		return results, err
+	}
+	if failed := Failed(results); failed > 0 {
+		return results, fmt.Errorf("%d 个资源改写失败, 未导入", failed)
+	}
+	applied, err := s.ApplyClonePreviews(ctx, destNamespace, previews, progress)
+	return append(results, applied...), err
+}
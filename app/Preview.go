@@ -0,0 +1,146 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PreviewStatus 预览中资源相对目标命名空间的状态
+type PreviewStatus string
+
+const (
+	PreviewNew       PreviewStatus = "新建"
+	PreviewChanged   PreviewStatus = "有变化"
+	PreviewUnchanged PreviewStatus = "无变化"
+)
+
+// ClonePreview 克隆前一个资源将要导入的内容与目标命名空间中现有内容的对比
+type ClonePreview struct {
+	Kind    string
+	Name    string
+	Current string // 目标命名空间中的现有内容, 不存在时为空
+	Next    string // 将要导入的内容
+	Status  PreviewStatus
+	Accept  bool // 是否导入, 默认导入新建和有变化的资源
+}
+
+// Diff 返回现有内容与将要导入内容的逐行差异
+func (p ClonePreview) Diff() []DiffLine {
+	return Diff(p.Current, p.Next)
+}
+
+func newClonePreview(kind, name, current string, next []byte) ClonePreview {
+	preview := ClonePreview{Kind: kind, Name: name, Current: current, Next: string(next)}
+	switch {
+	case current == "":
+		preview.Status = PreviewNew
+	case strings.TrimSpace(current) == strings.TrimSpace(preview.Next):
+		preview.Status = PreviewUnchanged
+	default:
+		preview.Status = PreviewChanged
+	}
+	preview.Accept = preview.Status != PreviewUnchanged
+	return preview
+}
+
+// PreviewCloneWorkloads 生成克隆工作负载的预览, 不修改目标命名空间
+func (s *Service) PreviewCloneWorkloads(ctx context.Context, workloads []rancher.Workload, destNamespace rancher.Namespace, options rancher.CloneOptions, progress Progress) ([]ClonePreview, []Result, error) {
+	if len(workloads) == 0 {
+		return nil, nil, fmt.Errorf("没有要克隆的工作负载")
+	}
+	if destNamespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 现有内容做同样的清理, 避免status等字段产生无意义的差异
+	normalize := rancher.Pipeline{rancher.StripStatus()}
+	var previews []ClonePreview
+	results, err := s.eachWorkloadYaml(ctx, ActionPreview, workloads, destNamespace, options, progress,
+		func(ctx context.Context, workload rancher.Workload, yamlData []byte) (string, error) {
+			kind := rancher.NormalizeKind(workload.Kind)
+			var current string
+			content, err := destClient.GetWorkloadYaml(ctx, kind, destNamespace.Name, workload.Name)
+			if err != nil && !rancher.IsNotFound(err) {
+				return "", fmt.Errorf("获取目标命名空间中的%s失败: %w", workload.Name, err)
+			}
+			if err == nil && strings.TrimSpace(content) != "" {
+				normalized, err := normalize.ApplyYaml([]byte(content))
+				if err != nil {
+					return "", err
+				}
+				current = string(normalized)
+			}
+			preview := newClonePreview(kind, workload.Name, current, yamlData)
+			previews = append(previews, preview)
+			return string(preview.Status), nil
+		})
+	return previews, results, err
+}
+
+// PreviewCloneConfigMaps 生成克隆configMap的预览, 不修改目标命名空间
+func (s *Service) PreviewCloneConfigMaps(ctx context.Context, namespace rancher.Namespace, destNamespace rancher.Namespace, progress Progress) ([]ClonePreview, []Result, error) {
+	if namespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择命名空间")
+	}
+	if destNamespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := destClient.GetConfigMapList(ctx, destNamespace.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取目标命名空间的配置时出错: %w", err)
+	}
+	currentByName := map[string]string{}
+	for _, configMap := range existing {
+		yamlData, err := rancher.BuildConfigMapYaml(configMap, destNamespace.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		currentByName[configMap.Name] = string(yamlData)
+	}
+	var previews []ClonePreview
+	results, err := s.eachConfigMapYaml(ctx, ActionPreview, namespace, destNamespace, progress,
+		func(ctx context.Context, name string, yamlData []byte) (string, error) {
+			preview := newClonePreview("configMap", name, currentByName[name], yamlData)
+			previews = append(previews, preview)
+			return string(preview.Status), nil
+		})
+	return previews, results, err
+}
+
+// ApplyClonePreviews 将预览中选择导入的资源导入目标命名空间, 未选择的资源跳过
+func (s *Service) ApplyClonePreviews(ctx context.Context, destNamespace rancher.Namespace, previews []ClonePreview, progress Progress) ([]Result, error) {
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	for _, preview := range previews {
+		if !preview.Accept {
+			continue
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		result := Result{
+			Action:      ActionClone,
+			Environment: destNamespace.Environment,
+			Namespace:   destNamespace.Name,
+			Kind:        preview.Kind,
+			Name:        preview.Name,
+		}
+		progress.started(result)
+		result.Message, result.Err = importYaml(ctx, destClient, destNamespace.Name, []byte(preview.Next))
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return results, ctx.Err()
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"RancherMan/rancher/types/secrets"
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secrets 实时获取命名空间中的Secret, 不包括服务账号令牌和Helm发布记录。值保持base64编码, 只在界面上明确要求时解码
+func (s *Service) Secrets(ctx context.Context, namespace rancher.Namespace) ([]secrets.Secret, error) {
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.GetSecretList(ctx, namespace.Name)
+	if err != nil {
+		return nil, fmt.Errorf("获取Secret列表失败: %w", err)
+	}
+	return list, nil
+}
+
+// SaveSecret 在命名空间中创建(create为true)或更新Secret
+func (s *Service) SaveSecret(ctx context.Context, namespace rancher.Namespace, secret secrets.Secret, create bool) error {
+	if secret.Metadata.Name == "" {
+		return fmt.Errorf("Secret名称不能为空")
+	}
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return err
+	}
+	secret.Metadata.Namespace = namespace.Name
+	if create {
+		if secret.Type == "" {
+			secret.Type = "Opaque"
+		}
+		err = client.CreateSecret(ctx, secret)
+	} else {
+		err = client.UpdateSecret(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("保存Secret %s失败: %w", secret.Metadata.Name, err)
+	}
+	return nil
+}
+
+// ExportSecrets 导出命名空间下的所有Secret, options.RedactSecrets为true时只保留键名
+func (s *Service) ExportSecrets(ctx context.Context, namespace rancher.Namespace, options rancher.CloneOptions, progress Progress) ([]ExportedResource, []Result, error) {
+	var resources []ExportedResource
+	options.DestNamespace = ""
+	results, err := s.eachSecretYaml(ctx, ActionExport, namespace, options, progress,
+		func(ctx context.Context, name string, yamlData []byte) (string, error) {
+			resources = append(resources, ExportedResource{Kind: "secret", Name: name, Yaml: yamlData})
+			return "", nil
+		})
+	return resources, results, err
+}
+
+// PreviewCloneSecrets 生成克隆Secret的预览, 不修改目标命名空间
+func (s *Service) PreviewCloneSecrets(ctx context.Context, namespace rancher.Namespace, destNamespace rancher.Namespace, options rancher.CloneOptions, progress Progress) ([]ClonePreview, []Result, error) {
+	if destNamespace.Name == "" {
+		return nil, nil, fmt.Errorf("未选择目标命名空间")
+	}
+	destClient, err := s.Client(destNamespace.Environment, destNamespace.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := currentResources(ctx, destClient, rancher.ResourceSecret, destNamespace.Name, options.RedactSecrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	options.DestNamespace = destNamespace.Name
+	var previews []ClonePreview
+	results, err := s.eachSecretYaml(ctx, ActionPreview, namespace, options, progress,
+		func(ctx context.Context, name string, yamlData []byte) (string, error) {
+			preview := newSecretPreview("secret", name, current[name], yamlData, options.RedactSecrets)
+			previews = append(previews, preview)
+			return string(preview.Status), nil
+		})
+	return previews, results, err
+}
+
+func (s *Service) eachSecretYaml(ctx context.Context, action Action, namespace rancher.Namespace, options rancher.CloneOptions, progress Progress,
+	handle func(ctx context.Context, name string, yamlData []byte) (string, error)) ([]Result, error) {
+	client, err := s.Client(namespace.Environment, namespace.Project)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.ListResources(ctx, rancher.ResourceSecret, namespace.Name)
+	if err != nil {
+		return nil, fmt.Errorf("获取Secret列表失败: %w", err)
+	}
+	var results []Result
+	for _, object := range objects {
+		if rancher.IsGeneratedResource(object) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		name := rancher.ObjectName(object)
+		result := Result{
+			Action:      action,
+			Environment: namespace.Environment,
+			Namespace:   namespace.Name,
+			Kind:        "secret",
+			Name:        name,
+		}
+		progress.started(result)
+		var yamlData []byte
+		err := options.ResourcePipeline(rancher.ResourceSecret.Kind, namespace.Name, name).Apply(object)
+		if err == nil {
+			yamlData, err = yaml.Marshal(object)
+		}
+		if err == nil {
+			result.Message, err = handle(ctx, name, yamlData)
+		}
+		result.Err = err
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return results, ctx.Err()
+}
+
+// newSecretPreview 生成Secret的预览。脱敏后的Secret只用于在目标命名空间中新建, 不覆盖已有Secret的值
+func newSecretPreview(kind, name, current string, next []byte, redacted bool) ClonePreview {
+	preview := newClonePreview(kind, name, current, next)
+	if redacted && current != "" {
+		preview.Accept = false
+	}
+	return preview
+}
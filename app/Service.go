@@ -0,0 +1,241 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"RancherMan/rancher/config"
+	"fmt"
+)
+
+// Service 应用服务层, 封装配置、数据同步、工作负载操作和克隆导出, 供图形界面和命令行共用。
+// 所有操作都返回结构化的结果, 不直接操作界面。
+type Service struct {
+	db                     *rancher.DatabaseManager
+	config                 *config.Config
+	cloneIgnoreTagWorkload []string
+}
+
+// NewService 创建服务, 创建后需要调用LoadConfig加载配置
+func NewService(db *rancher.DatabaseManager) *Service {
+	return &Service{db: db, config: &config.Config{}}
+}
+
+// Database 返回服务使用的数据库
+func (s *Service) Database() *rancher.DatabaseManager {
+	return s.db
+}
+
+// LoadConfig 从数据库重新加载配置。配置有问题时仍会加载其中可用的部分, 并返回包含所有问题的错误
+func (s *Service) LoadConfig() error {
+	cfg, err := rancher.LoadConfigFromDb(s.db)
+	s.setConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("从数据库读取配置时出错: %w", err)
+	}
+	return nil
+}
+
+// SaveConfig 校验配置内容, 通过后保存并重新加载; 校验失败时不保存, 返回*config.ValidationError
+func (s *Service) SaveConfig(content string) error {
+	// 显示配置时敏感字段被替换为占位符, 未修改的字段沿用当前配置中的值
+	plain, err := config.RestoreMasked([]byte(content), s.config)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Parse(plain)
+	if err != nil {
+		return err
+	}
+	if err := rancher.SaveConfigToDb(s.db, string(plain)); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	s.setConfig(cfg)
+	return nil
+}
+
+// NeedsSecretKey 是否还没有主密码或密钥文件, 此时无法加密保存配置中的密钥
+func (s *Service) NeedsSecretKey() bool {
+	return !config.HasKey(s.db.DataDir())
+}
+
+// UsesPassphrase 之前是否使用主密码加密过配置, 此时需要输入相同的主密码
+func (s *Service) UsesPassphrase() bool {
+	return config.UsesPassphrase(s.db.DataDir())
+}
+
+// SetPassphrase 设置本次运行使用的主密码, 之后需要调用LoadConfig重新加载配置
+func (s *Service) SetPassphrase(passphrase string) {
+	config.SetPassphrase(passphrase)
+}
+
+// CreateKeyFile 在数据库所在目录生成密钥文件, 返回其路径
+func (s *Service) CreateKeyFile() (string, error) {
+	return config.CreateKeyFile(s.db.DataDir())
+}
+
+// KeyFile 返回密钥文件的路径
+func (s *Service) KeyFile() string {
+	return config.KeyFile(s.db.DataDir())
+}
+
+func (s *Service) setConfig(cfg *config.Config) {
+	s.config = cfg
+	// 解析 clone_ignore_tag_workload
+	s.cloneIgnoreTagWorkload = rancher.GetCloneIgnoreTagWorkload(cfg)
+}
+
+// ConfigContent 返回用于显示的配置内容, 敏感字段被替换为占位符
+func (s *Service) ConfigContent() (string, error) {
+	content, err := s.db.GetConfigContent(1)
+	if err != nil || content == "" {
+		return content, err
+	}
+	masked, err := config.MaskSecrets([]byte(content))
+	if err != nil {
+		return "", err
+	}
+	return string(masked), nil
+}
+
+// EnvironmentNames 返回配置中所有环境的标识
+func (s *Service) EnvironmentNames() []string {
+	return rancher.GetEnvironmentNames(s.config)
+}
+
+// Environment 根据环境标识获取环境配置
+func (s *Service) Environment(envName string) (*rancher.Environment, error) {
+	return rancher.GetEnvironmentFromConfig(s.config, envName)
+}
+
+// Client 根据环境标识和项目ID创建Rancher客户端, projectID为空时使用环境的第一个项目
+func (s *Service) Client(envName string, projectID string) (*rancher.Client, error) {
+	environment, err := s.Environment(envName)
+	if err != nil {
+		return nil, err
+	}
+	return rancher.NewClient(environment.WithProject(projectID)), nil
+}
+
+// Projects 返回环境中配置的所有集群/项目
+func (s *Service) Projects(envName string) ([]rancher.Project, error) {
+	environment, err := s.Environment(envName)
+	if err != nil {
+		return nil, err
+	}
+	return environment.Projects, nil
+}
+
+// ProjectOption 集群/项目选择的一个选项
+type ProjectOption struct {
+	Label       string // 显示名称, 如 "环境名/项目名"
+	Environment string
+	Project     string // 项目ID
+	Multiple    bool   // 所在环境是否配置了多个项目
+}
+
+// ProjectOptions 返回所有环境中的集群/项目, 按环境标识排序
+func (s *Service) ProjectOptions() []ProjectOption {
+	var options []ProjectOption
+	for _, envName := range s.EnvironmentNames() {
+		environment, err := s.Environment(envName)
+		if err != nil {
+			continue
+		}
+		for _, project := range environment.Projects {
+			options = append(options, ProjectOption{
+				Label:       environment.Name + "/" + project.Name,
+				Environment: envName,
+				Project:     project.ID,
+				Multiple:    len(environment.Projects) > 1,
+			})
+		}
+	}
+	return options
+}
+
+// HasJumpHost 是否配置了跳板机
+func (s *Service) HasJumpHost() bool {
+	return s.config.JumpHost != nil
+}
+
+// ClearData 清空本地缓存的所有数据
+func (s *Service) ClearData() error {
+	return s.db.ClearAllData()
+}
+
+// Namespaces 返回本地缓存的命名空间, envName为空时返回全部环境
+func (s *Service) Namespaces(envName string) ([]rancher.Namespace, error) {
+	if envName == "" {
+		return s.db.GetAllNamespacesDetail()
+	}
+	return s.db.GetNamespacesByEnvironment(envName)
+}
+
+// FindNamespace 在本地缓存中查找命名空间。project可以是项目ID或显示名称, 为空时命名空间名称需要在环境中唯一;
+// 缓存中没有时返回只包含环境、名称和项目的命名空间, 用于克隆到尚未同步的命名空间
+func (s *Service) FindNamespace(envName string, name string, project string) (rancher.Namespace, error) {
+	projectID := ""
+	if project != "" {
+		environment, err := s.Environment(envName)
+		if err != nil {
+			return rancher.Namespace{}, err
+		}
+		p, ok := environment.FindProject(project)
+		if !ok {
+			return rancher.Namespace{}, fmt.Errorf("环境%s中找不到项目: %s", envName, project)
+		}
+		projectID = p.ID
+	}
+	namespaces, err := s.db.GetNamespacesByEnvironment(envName)
+	if err != nil {
+		return rancher.Namespace{}, err
+	}
+	var matched []rancher.Namespace
+	for _, namespace := range namespaces {
+		if namespace.Name == name && (projectID == "" || namespace.Project == projectID) {
+			matched = append(matched, namespace)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return rancher.Namespace{Environment: envName, Name: name, Project: projectID}, nil
+	case 1:
+		return matched[0], nil
+	default:
+		return rancher.Namespace{}, fmt.Errorf("环境%s的多个项目中都有命名空间%s, 请指定项目", envName, name)
+	}
+}
+
+// Workloads 返回本地缓存的命名空间下的工作负载
+func (s *Service) Workloads(namespace rancher.Namespace) ([]rancher.Workload, error) {
+	if namespace.Environment == "" {
+		return s.db.GetWorkloadsByNamespace(namespace.Name)
+	}
+	// 不同集群中可能有同名的命名空间
+	if namespace.Project != "" {
+		return s.db.GetWorkloadsByProjectNamespace(namespace.Environment, namespace.Project, namespace.Name)
+	}
+	return s.db.GetWorkloadDetailsByEnvNamespace(namespace.Environment, namespace.Name)
+}
+
+// clients 按环境和项目缓存客户端, 用于一次批量操作中处理多个环境、多个项目的工作负载
+type clients struct {
+	service *Service
+	cache   map[string]*rancher.Client
+}
+
+func (s *Service) newClients() *clients {
+	return &clients{service: s, cache: map[string]*rancher.Client{}}
+}
+
+func (c *clients) get(envName string, projectID string) (*rancher.Client, error) {
+	key := envName + "/" + projectID
+	if client, ok := c.cache[key]; ok {
+		return client, nil
+	}
+	client, err := c.service.Client(envName, projectID)
+	if err != nil {
+		return nil, err
+	}
+	c.cache[key] = client
+	return client, nil
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// syncParallelism 同时更新的环境数量上限, 避免同时向多个Rancher发出过多请求
+const syncParallelism = 4
+
+// syncUpdate 更新一个环境的一类数据, 返回本地数据的变化, 作为结果的补充信息
+type syncUpdate func(ctx context.Context, envName string, environment *rancher.Environment) (string, error)
+
+// syncUpdater 返回操作对应的更新函数。ActionSync更新命名空间和工作负载(包括nginx访问路径),
+// ActionSyncPods更新Pod状态, ActionSyncServices更新端口映射
+func (s *Service) syncUpdater(action Action) (syncUpdate, error) {
+	switch action {
+	case ActionSync:
+		return func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+			diff, err := rancher.UpdateEnvironment(ctx, s.db, envName, environment, true)
+			if err != nil || diff == nil {
+				return "", err
+			}
+			return fmt.Sprintf("命名空间: %s; 工作负载: %s", diff.Namespaces.Summary(), diff.Workloads.Summary()), nil
+		}, nil
+	case ActionSyncPods:
+		return func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+			diff, err := rancher.UpdatePod(ctx, s.db, envName, environment)
+			if err != nil {
+				return "", err
+			}
+			return "Pod: " + diff.Summary(), nil
+		}, nil
+	case ActionSyncServices:
+		return func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+			diff, err := rancher.UpdateService(ctx, s.db, envName, environment)
+			if err != nil {
+				return "", err
+			}
+			return "端口: " + diff.Summary(), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的更新操作: %s", action)
+}
+
+// SyncEvents 并发更新多个环境, 最多同时更新syncParallelism个环境, 每个环境按顺序执行actions中的更新。
+// envNames为空时更新全部环境。每个环境、每个操作的开始和完成通过返回的channel发送,
+// 全部完成或被取消后关闭channel, 调用方需要一直读取到channel关闭
+func (s *Service) SyncEvents(ctx context.Context, actions []Action, envNames []string) (<-chan Event, error) {
+	updates := make([]syncUpdate, len(actions))
+	for i, action := range actions {
+		update, err := s.syncUpdater(action)
+		if err != nil {
+			return nil, err
+		}
+		updates[i] = update
+	}
+	if len(envNames) == 0 {
+		envNames = s.EnvironmentNames()
+	}
+	return s.syncEvents(ctx, envNames, actions, updates), nil
+}
+
+// syncEvents 见SyncEvents, updates[i]是actions[i]的更新函数
+func (s *Service) syncEvents(ctx context.Context, envNames []string, actions []Action, updates []syncUpdate) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, syncParallelism)
+		for _, envName := range envNames {
+			// 等待空闲的位置, 取消后不再开始新的环境
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func(envName string) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				s.syncEnvironment(ctx, envName, actions, updates, events)
+			}(envName)
+		}
+		wg.Wait()
+	}()
+	return events
+}
+
+// syncEnvironment 依次执行一个环境的更新。环境配置有问题时只报告一次错误
+func (s *Service) syncEnvironment(ctx context.Context, envName string, actions []Action, updates []syncUpdate, events chan<- Event) {
+	environment, envErr := s.Environment(envName)
+	for i, action := range actions {
+		if ctx.Err() != nil {
+			return
+		}
+		result := Result{Action: action, Environment: envName, Name: envName}
+		if envErr == nil {
+			result.Name = environment.Name
+		}
+		events <- Event{Type: EventStarted, Result: result}
+		if envErr != nil {
+			result.Err = envErr
+		} else {
+			result.Message, result.Err = updates[i](ctx, envName, environment)
+		}
+		events <- Event{Type: EventFinished, Result: result}
+		if envErr != nil {
+			return
+		}
+	}
+}
+
+// Sync 从Rancher更新本地数据, 各环境并发执行, 见SyncEvents。progress在调用Sync的goroutine中依次调用,
+// 返回的结果按envNames和actions的顺序排列。被取消时返回ctx.Err()以及已完成部分的结果。
+func (s *Service) Sync(ctx context.Context, actions []Action, envNames []string, progress Progress) ([]Result, error) {
+	if len(envNames) == 0 {
+		envNames = s.EnvironmentNames()
+	}
+	events, err := s.SyncEvents(ctx, actions, envNames)
+	if err != nil {
+		return nil, err
+	}
+	return collectSyncResults(events, envNames, actions, progress), ctx.Err()
+}
+
+// collectSyncResults 读取事件直到channel关闭, 返回按envNames和actions的顺序排列的结果
+func collectSyncResults(events <-chan Event, envNames []string, actions []Action, progress Progress) []Result {
+	var results []Result
+	for event := range events {
+		if event.Type == EventStarted {
+			progress.started(event.Result)
+			continue
+		}
+		results = append(results, event.Result)
+		progress.finished(event.Result)
+	}
+
+	envOrder := make(map[string]int, len(envNames))
+	for i, envName := range envNames {
+		envOrder[envName] = i
+	}
+	actionOrder := make(map[Action]int, len(actions))
+	for i, action := range actions {
+		actionOrder[action] = i
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Environment != results[j].Environment {
+			return envOrder[results[i].Environment] < envOrder[results[j].Environment]
+		}
+		return actionOrder[results[i].Action] < actionOrder[results[j].Action]
+	})
+	return results
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"RancherMan/rancher/config"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSyncService 创建只包含环境配置的服务, 环境名称为 环境<标识>
+func newSyncService(envNames ...string) *Service {
+	cfg := &config.Config{Environment: map[string]config.EnvironmentConfig{}}
+	for _, envName := range envNames {
+		cfg.Environment[envName] = config.EnvironmentConfig{Name: "环境" + envName, BaseURL: "https://" + envName + ".example.com", Project: "c-1:p-1"}
+	}
+	return &Service{config: cfg}
+}
+
+// concurrency 记录同时执行的更新数量
+type concurrency struct {
+	mu      sync.Mutex
+	running int
+	max     int
+	started []string
+}
+
+func (c *concurrency) enter(envName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+	c.started = append(c.started, envName)
+}
+
+func (c *concurrency) leave() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running--
+}
+
+// collectWithin 在超时时间内读取所有事件, channel没有关闭时测试失败
+func collectWithin(t *testing.T, events <-chan Event, envNames []string, actions []Action) []Result {
+	t.Helper()
+	done := make(chan []Result)
+	go func() { done <- collectSyncResults(events, envNames, actions, nil) }()
+	select {
+	case results := <-done:
+		return results
+	case <-time.After(5 * time.Second):
+		t.Fatal("事件channel没有关闭")
+		return nil
+	}
+}
+
+func resultKeys(results []Result) []string {
+	var keys []string
+	for _, result := range results {
+		keys = append(keys, result.Environment+"/"+string(result.Action))
+	}
+	return keys
+}
+
+// TestSyncResultOrder 结果按环境和操作的顺序排列, 与完成的先后无关, 同时更新的环境不超过syncParallelism个
+func TestSyncResultOrder(t *testing.T) {
+	envNames := []string{"e0", "e1", "e2", "e3", "e4", "e5", "e6", "e7"}
+	actions := []Action{ActionSync, ActionSyncPods}
+	var c concurrency
+	// 排在前面的环境更新得更慢, 先完成的是后面的环境
+	update := func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+		c.enter(envName)
+		defer c.leave()
+		var index int
+		fmt.Sscanf(envName, "e%d", &index)
+		time.Sleep(time.Duration(len(envNames)-index) * 5 * time.Millisecond)
+		return environment.Name, nil
+	}
+	s := newSyncService(envNames...)
+	results := collectWithin(t, s.syncEvents(context.Background(), envNames, actions, []syncUpdate{update, update}), envNames, actions)
+
+	var want []string
+	for _, envName := range envNames {
+		for _, action := range actions {
+			want = append(want, envName+"/"+string(action))
+		}
+	}
+	if got := resultKeys(results); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("结果顺序 %v, 期望 %v", got, want)
+	}
+	for _, result := range results {
+		if result.Err != nil || result.Name != "环境"+result.Environment || result.Message != result.Name {
+			t.Errorf("结果 %+v", result)
+		}
+	}
+	if c.max > syncParallelism {
+		t.Errorf("同时更新了%d个环境, 上限%d", c.max, syncParallelism)
+	}
+}
+
+// TestSyncParallelism 同时更新的环境数量可以达到syncParallelism
+func TestSyncParallelism(t *testing.T) {
+	envNames := []string{"e0", "e1", "e2", "e3", "e4", "e5"}
+	var c concurrency
+	full := make(chan struct{})
+	var once sync.Once
+	update := func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+		c.enter(envName)
+		defer c.leave()
+		c.mu.Lock()
+		if c.running == syncParallelism {
+			once.Do(func() { close(full) })
+		}
+		c.mu.Unlock()
+		select {
+		case <-full:
+			return "", nil
+		case <-time.After(5 * time.Second):
+			return "", errors.New("没有同时更新")
+		}
+	}
+	s := newSyncService(envNames...)
+	results := collectWithin(t, s.syncEvents(context.Background(), envNames, []Action{ActionSync}, []syncUpdate{update}), envNames, []Action{ActionSync})
+	if len(results) != len(envNames) || Failed(results) != 0 {
+		t.Errorf("结果 %+v", results)
+	}
+	if c.max != syncParallelism {
+		t.Errorf("最多同时更新了%d个环境, 期望%d", c.max, syncParallelism)
+	}
+}
+
+// TestSyncCancel 所有位置都被占用时取消: 不再开始新的环境, 正在更新的环境结束后关闭channel
+func TestSyncCancel(t *testing.T) {
+	envNames := []string{"e0", "e1", "e2", "e3", "e4", "e5", "e6", "e7"}
+	actions := []Action{ActionSync, ActionSyncPods}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var c concurrency
+	update := func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+		c.enter(envName)
+		defer c.leave()
+		c.mu.Lock()
+		if len(c.started) == syncParallelism {
+			cancel()
+		}
+		c.mu.Unlock()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	s := newSyncService(envNames...)
+	results := collectWithin(t, s.syncEvents(ctx, envNames, actions, []syncUpdate{update, update}), envNames, actions)
+
+	if len(c.started) != syncParallelism || c.max != syncParallelism {
+		t.Errorf("开始更新的环境 %v, 最多同时更新%d个", c.started, c.max)
+	}
+	if len(results) != syncParallelism {
+		t.Errorf("结果 %v, 期望每个开始的环境只有第一个操作的结果", resultKeys(results))
+	}
+	for _, result := range results {
+		if result.Action != ActionSync || !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("结果 %+v", result)
+		}
+	}
+}
+
+// TestSyncEnvironmentFailed 一个环境失败时其他环境照常更新, 全部结束后关闭channel
+func TestSyncEnvironmentFailed(t *testing.T) {
+	envNames := []string{"e0", "missing", "e1", "e2"}
+	actions := []Action{ActionSync, ActionSyncPods}
+	update := func(ctx context.Context, envName string, environment *rancher.Environment) (string, error) {
+		if envName == "e1" {
+			return "", errors.New("连接失败")
+		}
+		return "", nil
+	}
+	s := newSyncService("e0", "e1", "e2")
+	results := collectWithin(t, s.syncEvents(context.Background(), envNames, actions, []syncUpdate{update, update}), envNames, actions)
+
+	want := []string{"e0/sync", "e0/pods", "missing/sync", "e1/sync", "e1/pods", "e2/sync", "e2/pods"}
+	if got := resultKeys(results); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("结果 %v, 期望 %v", got, want)
+	}
+	failed := map[string]bool{}
+	for _, result := range results {
+		if result.Err != nil {
+			failed[result.Environment+"/"+string(result.Action)] = true
+		}
+	}
+	if len(failed) != 3 || !failed["missing/sync"] || !failed["e1/sync"] || !failed["e1/pods"] {
+		t.Errorf("失败的结果 %v", failed)
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WorkloadAction 对工作负载逐个执行打开、关闭、重新部署或立即执行。
+// 工作负载可以来自不同环境, 每个工作负载使用其所属环境的客户端。
+func (s *Service) WorkloadAction(ctx context.Context, action Action, workloads []rancher.Workload, progress Progress) ([]Result, error) {
+	var run func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error)
+	switch action {
+	case ActionStart:
+		run = func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error) {
+			return "", client.Start(ctx, workload.Kind, workload.Namespace, workload.Name)
+		}
+	case ActionStop:
+		run = func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error) {
+			return "", client.Stop(ctx, workload.Kind, workload.Namespace, workload.Name)
+		}
+	case ActionRedeploy:
+		run = func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error) {
+			return "", client.Redeploy(ctx, workload.Kind, workload.Namespace, workload.Name)
+		}
+	case ActionTrigger:
+		run = func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error) {
+			return client.TriggerJob(ctx, workload.Kind, workload.Namespace, workload.Name)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的工作负载操作: %s", action)
+	}
+	return s.eachWorkload(ctx, action, workloads, progress, run)
+}
+
+// Scale 将工作负载伸缩到指定副本数
+func (s *Service) Scale(ctx context.Context, workloads []rancher.Workload, replicas int, progress Progress) ([]Result, error) {
+	if replicas < 0 {
+		return nil, fmt.Errorf("无效的副本数: %d", replicas)
+	}
+	return s.eachWorkload(ctx, ActionScale, workloads, progress, func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error) {
+		return "", client.Scale(ctx, workload.Kind, workload.Namespace, workload.Name, replicas)
+	})
+}
+
+func (s *Service) eachWorkload(ctx context.Context, action Action, workloads []rancher.Workload, progress Progress,
+	run func(ctx context.Context, client *rancher.Client, workload rancher.Workload) (string, error)) ([]Result, error) {
+	clients := s.newClients()
+	var results []Result
+	for _, workload := range workloads {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		result := workloadResult(action, workload)
+		progress.started(result)
+		client, err := clients.get(workload.Environment, workload.ProjectId)
+		if err == nil {
+			result.Message, err = run(ctx, client, workload)
+		}
+		result.Err = err
+		results = append(results, result)
+		progress.finished(result)
+	}
+	return results, ctx.Err()
+}
+
+func workloadResult(action Action, workload rancher.Workload) Result {
+	return Result{
+		Action:      action,
+		Environment: workload.Environment,
+		Namespace:   workload.Namespace,
+		Kind:        rancher.NormalizeKind(workload.Kind),
+		Name:        workload.Name,
+	}
+}
+
+// SelectWorkloads 按名称从列表中选取工作负载, 名称不存在时返回错误
+func SelectWorkloads(workloads []rancher.Workload, names []string) ([]rancher.Workload, error) {
+	var selected []rancher.Workload
+	for _, name := range names {
+		found := false
+		for _, workload := range workloads {
+			if workload.Name == name {
+				selected = append(selected, workload)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("找不到工作负载 %s", name)
+		}
+	}
+	return selected, nil
+}
+
+// FilterNamespaces 按名称或描述过滤命名空间, 不区分大小写
+func FilterNamespaces(items []rancher.Namespace, filter string) []rancher.Namespace {
+	if filter == "" {
+		return items
+	}
+	var filtered []rancher.Namespace
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(filter)) || strings.Contains(strings.ToLower(item.Description), strings.ToLower(filter)) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// FilterNamespacesByProject 过滤出指定环境和项目的命名空间, projectID为空时不按项目过滤
+func FilterNamespacesByProject(items []rancher.Namespace, envName string, projectID string) []rancher.Namespace {
+	var filtered []rancher.Namespace
+	for _, item := range items {
+		if item.Environment == envName && (projectID == "" || item.Project == projectID) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// FilterWorkloads 按名称或任一容器的镜像过滤工作负载, 不区分大小写
+func FilterWorkloads(items []rancher.Workload, filter string) []rancher.Workload {
+	if filter == "" {
+		return items
+	}
+	filter = strings.ToLower(filter)
+	var filtered []rancher.Workload
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), filter) || matchImage(item, filter) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// matchImage 检查工作负载是否有容器的镜像包含filter, filter需要已转换为小写
+func matchImage(workload rancher.Workload, filter string) bool {
+	for _, image := range workload.Images() {
+		if strings.Contains(strings.ToLower(image), filter) {
+			return true
+		}
+	}
+	return false
+}
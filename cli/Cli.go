@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"RancherMan/app"
+	"RancherMan/rancher"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+const usage = `用法: RancherMan <命令> [参数]
+
+命令:
+  env list                                          列出配置中的环境
+  ns list      [--env 环境] [--project 项目]           列出本地缓存的命名空间
+  ns clone     --env 环境 --ns 命名空间 --to-env 环境 --to-ns 命名空间 [--to-project 项目] [--secrets [--redact-secrets]] [--diff] [--tag 标签] [--registry 旧=新] [--replicas 副本数]
+  wl list      --env 环境 --ns 命名空间               列出本地缓存的工作负载
+  wl scale     --env 环境 --ns 命名空间 (--name 名称 | --all) <副本数>
+  wl start     --env 环境 --ns 命名空间 (--name 名称 | --all)
+  wl stop      --env 环境 --ns 命名空间 (--name 名称 | --all)
+  wl redeploy  --env 环境 --ns 命名空间 (--name 名称 | --all)
+  secret list  --env 环境 --ns 命名空间               列出Secret及其键名
+  secret get   --env 环境 --ns 命名空间 --name 名称 --key 键  输出解码后的值
+  sync         [--env 环境] [--pods] [--services]     从Rancher更新本地数据, 各环境并发更新
+  changes      [--env 环境] [--ns 命名空间] [--name 工作负载] [--limit 条数]  列出同步时记录的变更
+  export       --env 环境 --ns 命名空间 [--name 名称] [--tag 标签] [--registry 旧=新] [--replicas 副本数] [--configmaps] [--secrets [--redact-secrets]] [--file 文件或目录] [--layout single|files|tar.gz] [--helm] [--chart 名称]
+  clone        --env 环境 --ns 命名空间 --to-env 环境 --to-ns 命名空间 [--to-project 项目] [--create-ns] [--diff] [--name 名称] [--tag 标签] [--registry 旧=新] [--replicas 副本数] [--configmaps] [--secrets [--redact-secrets]]
+  kustomize    --env 环境 --ns 命名空间 --to-env 环境[,环境] --file 目录或压缩包 [--to-ns 命名空间] [--name 名称] [--layout files|tar.gz]
+  import       --env 环境 --ns 命名空间 [--project 项目] [--create-ns] [--diff] [--tag 标签] [--registry 旧=新] [--replicas 副本数] <文件或目录>...
+
+通用参数:
+  --output json|yaml|table   输出格式, 默认table
+  --db 文件                  数据库文件, 默认使用应用数据目录中的app.db
+
+--name 可用逗号分隔多个名称。
+环境配置了多个集群/项目且不同项目中有同名命名空间时, 需要用 --project 指定项目ID或名称。
+`
+
+// errUsage 表示命令行参数错误
+var errUsage = errors.New("参数错误")
+
+// command 一次命令执行所需的上下文
+type command struct {
+	ctx     context.Context
+	flags   *flag.FlagSet
+	output  *string
+	dbFile  *string
+	stdout  io.Writer
+	db      *rancher.DatabaseManager
+	service *app.Service
+}
+
+// Run 执行命令行模式, 返回进程退出码
+func Run(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, args, os.Stdout)
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "错误: %s\n", rancher.ErrorReason(err))
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, "\n"+usage)
+		return 2
+	}
+	return 1
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	name := args[0]
+	rest := args[1:]
+	// 两级命令, 如 wl scale
+	if name == "env" || name == "ns" || name == "wl" || name == "secret" {
+		if len(rest) == 0 {
+			return fmt.Errorf("%w: %s 需要子命令", errUsage, name)
+		}
+		name = name + " " + rest[0]
+		rest = rest[1:]
+	}
+	handler, ok := handlers[name]
+	if !ok {
+		return fmt.Errorf("%w: 未知命令 %s", errUsage, name)
+	}
+	return handler(ctx, name, rest, stdout)
+}
+
+// newCommand 创建带有通用参数的命令
+func newCommand(ctx context.Context, name string, stdout io.Writer) *command {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return &command{
+		ctx:    ctx,
+		flags:  flags,
+		output: flags.String("output", outputTable, "输出格式 json|yaml|table"),
+		dbFile: flags.String("db", "", "数据库文件"),
+		stdout: stdout,
+	}
+}
+
+// parse 解析参数并打开数据库、加载配置, 调用方需要调用close
+func (c *command) parse(args []string) error {
+	if err := c.flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if err := validateOutput(*c.output); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	db, err := rancher.NewDatabaseManager(*c.dbFile)
+	if err != nil {
+		return err
+	}
+	c.db = db
+	c.service = app.NewService(db)
+	if err := c.service.LoadConfig(); err != nil {
+		// 配置有问题时仍可使用其中有效的环境
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+	}
+	return nil
+}
+
+func (c *command) close() {
+	if c.db != nil {
+		c.db.Close()
+	}
+}
+
+func (c *command) print(t table) error {
+	return printTable(c.stdout, *c.output, t)
+}
+
+// environment 根据环境标识获取环境配置
+func (c *command) environment(envName string) (*rancher.Environment, error) {
+	if envName == "" {
+		return nil, fmt.Errorf("%w: 缺少 --env", errUsage)
+	}
+	return c.service.Environment(envName)
+}
+
+// selectWorkloads 从本地缓存中选取工作负载, names为空且all为true时返回命名空间下全部
+func (c *command) selectWorkloads(namespace rancher.Namespace, names string, all bool) ([]rancher.Workload, error) {
+	if names == "" && !all {
+		return nil, fmt.Errorf("%w: 需要 --name 或 --all", errUsage)
+	}
+	workloads, err := c.service.Workloads(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if names == "" {
+		if len(workloads) == 0 {
+			return nil, fmt.Errorf("命名空间 %s 中没有工作负载, 请先执行 sync", namespace.Name)
+		}
+		return workloads, nil
+	}
+	selected, err := app.SelectWorkloads(workloads, splitNames(names))
+	if err != nil {
+		return nil, fmt.Errorf("%w, 请先执行 sync", err)
+	}
+	return selected, nil
+}
+
+// namespace 根据 --env、--ns 和可选的 --project 在本地缓存中查找命名空间
+func (c *command) namespace(envName, namespace, project string) (rancher.Namespace, error) {
+	if envName == "" || namespace == "" {
+		return rancher.Namespace{}, fmt.Errorf("%w: 需要 --env 和 --ns", errUsage)
+	}
+	return c.service.FindNamespace(envName, namespace, project)
+}
+
+func splitNames(names string) []string {
+	var result []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
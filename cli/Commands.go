@@ -0,0 +1,746 @@
+package cli
+
+import (
+	"RancherMan/app"
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// namespaceResult 命名空间输出
+type namespaceResult struct {
+	Environment string `json:"environment" yaml:"environment"`
+	Name        string `json:"name" yaml:"name"`
+	Project     string `json:"project" yaml:"project"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// workloadResult 工作负载输出
+type workloadResult struct {
+	Environment string `json:"environment" yaml:"environment"`
+	Namespace   string `json:"namespace" yaml:"namespace"`
+	Name        string `json:"name" yaml:"name"`
+	Kind        string `json:"kind" yaml:"kind"`
+	Image       string `json:"image" yaml:"image"`
+}
+
+// actionResult 对单个对象执行操作的结果
+type actionResult struct {
+	Environment string `json:"environment" yaml:"environment"`
+	Namespace   string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Kind        string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Name        string `json:"name" yaml:"name"`
+	Action      string `json:"action" yaml:"action"`
+	Success     bool   `json:"success" yaml:"success"`
+	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+var handlers = map[string]func(ctx context.Context, name string, args []string, stdout io.Writer) error{
+	"env list":    envList,
+	"ns list":     namespaceList,
+	"ns clone":    namespaceClone,
+	"wl list":     workloadList,
+	"wl scale":    workloadAction,
+	"wl start":    workloadAction,
+	"wl stop":     workloadAction,
+	"wl redeploy": workloadAction,
+	"sync":        syncData,
+	"export":      exportOrClone,
+	"clone":       exportOrClone,
+	"kustomize":   exportKustomize,
+	"import":      importYaml,
+	"secret list": secretList,
+	"secret get":  secretGet,
+	"changes":     changeList,
+}
+
+// resultTable 将服务层的操作结果转换为输出表格
+func resultTable(results []app.Result) table {
+	rows := []actionResult{}
+	t := table{headers: []string{"环境", "类型", "名称", "操作", "结果"}}
+	for _, result := range results {
+		row := actionResult{
+			Environment: result.Environment,
+			Namespace:   result.Namespace,
+			Kind:        result.Kind,
+			Name:        result.Name,
+			Action:      string(result.Action),
+			Success:     result.Success(),
+			Message:     result.Message,
+			Error:       result.Reason(),
+		}
+		status := "成功"
+		if !row.Success {
+			status = "失败: " + row.Error
+		} else if row.Message != "" {
+			status = "成功: " + row.Message
+		}
+		rows = append(rows, row)
+		t.rows = append(t.rows, []string{row.Environment, row.Kind, row.Name, row.Action, status})
+	}
+	t.data = rows
+	return t
+}
+
+// finish 输出结果, 任务被取消或有失败时返回错误
+func (c *command) finish(results []app.Result, err error) error {
+	if printErr := c.print(resultTable(results)); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+	if failed := app.Failed(results); failed > 0 {
+		return fmt.Errorf("%d 项操作失败", failed)
+	}
+	return nil
+}
+
+func envList(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	type envResult struct {
+		ID       string   `json:"id" yaml:"id"`
+		Name     string   `json:"name" yaml:"name"`
+		BaseURL  string   `json:"baseUrl" yaml:"baseUrl"`
+		Projects []string `json:"projects" yaml:"projects"`
+	}
+	results := []envResult{}
+	t := table{headers: []string{"ID", "名称", "地址", "项目"}}
+	for _, envName := range cmd.service.EnvironmentNames() {
+		environment, err := cmd.service.Environment(envName)
+		if err != nil {
+			return err
+		}
+		var projects []string
+		for _, project := range environment.Projects {
+			projects = append(projects, project.ID)
+		}
+		results = append(results, envResult{environment.ID, environment.Name, environment.BaseURL, projects})
+		t.rows = append(t.rows, []string{environment.ID, environment.Name, environment.BaseURL, strings.Join(projects, ",")})
+	}
+	t.data = results
+	return cmd.print(t)
+}
+
+func namespaceList(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境")
+	project := cmd.flags.String("project", "", "项目ID或名称")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	projectID := ""
+	if *project != "" {
+		environment, err := cmd.environment(*envName)
+		if err != nil {
+			return err
+		}
+		p, ok := environment.FindProject(*project)
+		if !ok {
+			return fmt.Errorf("环境%s中找不到项目: %s", *envName, *project)
+		}
+		projectID = p.ID
+	}
+	namespaces, err := cmd.service.Namespaces(*envName)
+	if err != nil {
+		return err
+	}
+	results := []namespaceResult{}
+	t := table{headers: []string{"环境", "命名空间", "项目", "描述"}}
+	for _, namespace := range namespaces {
+		if projectID != "" && namespace.Project != projectID {
+			continue
+		}
+		results = append(results, namespaceResult{namespace.Environment, namespace.Name, namespace.Project, namespace.Description})
+		t.rows = append(t.rows, []string{namespace.Environment, namespace.Name, namespace.Project, namespace.Description})
+	}
+	t.data = results
+	return cmd.print(t)
+}
+
+func workloadList(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境")
+	namespace := cmd.flags.String("ns", "", "命名空间")
+	project := cmd.flags.String("project", "", "项目ID或名称")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	workloads, err := cmd.service.Workloads(source)
+	if err != nil {
+		return err
+	}
+	results := []workloadResult{}
+	t := table{headers: []string{"名称", "类型", "镜像"}}
+	for _, workload := range workloads {
+		kind := rancher.NormalizeKind(workload.Kind)
+		results = append(results, workloadResult{workload.Environment, workload.Namespace, workload.Name, kind, workload.Image})
+		t.rows = append(t.rows, []string{workload.Name, kind, workload.Image})
+	}
+	t.data = results
+	return cmd.print(t)
+}
+
+func workloadAction(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境")
+	namespace := cmd.flags.String("ns", "", "命名空间")
+	names := cmd.flags.String("name", "", "工作负载名称, 多个用逗号分隔")
+	all := cmd.flags.Bool("all", false, "命名空间下的全部工作负载")
+	project := cmd.flags.String("project", "", "项目ID或名称")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	action := app.Action(strings.TrimPrefix(name, "wl "))
+	replicas := 0
+	if action == app.ActionScale {
+		if cmd.flags.NArg() != 1 {
+			return fmt.Errorf("%w: wl scale 需要副本数", errUsage)
+		}
+		value, err := strconv.Atoi(cmd.flags.Arg(0))
+		if err != nil || value < 0 {
+			return fmt.Errorf("%w: 无效的副本数 %s", errUsage, cmd.flags.Arg(0))
+		}
+		replicas = value
+	}
+	if _, err := cmd.environment(*envName); err != nil {
+		return err
+	}
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	workloads, err := cmd.selectWorkloads(source, *names, *all)
+	if err != nil {
+		return err
+	}
+
+	if action == app.ActionScale {
+		return cmd.finish(cmd.service.Scale(ctx, workloads, replicas, nil))
+	}
+	return cmd.finish(cmd.service.WorkloadAction(ctx, action, workloads, nil))
+}
+
+func syncData(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境, 为空时更新全部环境")
+	pods := cmd.flags.Bool("pods", false, "同时更新Pod状态")
+	services := cmd.flags.Bool("services", false, "同时更新端口映射")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	var envNames []string
+	if *envName != "" {
+		envNames = []string{*envName}
+	}
+	actions := []app.Action{app.ActionSync}
+	if *pods {
+		actions = append(actions, app.ActionSyncPods)
+	}
+	if *services {
+		actions = append(actions, app.ActionSyncServices)
+	}
+	// 各环境并发更新, 结果按环境和操作的顺序输出
+	results, err := cmd.service.Sync(ctx, actions, envNames, nil)
+	return cmd.finish(results, err)
+}
+
+func exportOrClone(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	isClone := name == "clone"
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "源环境")
+	namespace := cmd.flags.String("ns", "", "源命名空间")
+	project := cmd.flags.String("project", "", "源项目ID或名称")
+	names := cmd.flags.String("name", "", "工作负载名称, 多个用逗号分隔, 为空时处理全部")
+	withConfigMaps := cmd.flags.Bool("configmaps", false, "同时处理configMap")
+	withSecrets := cmd.flags.Bool("secrets", false, "同时处理Secret")
+	redactSecrets := cmd.flags.Bool("redact-secrets", false, "Secret的值替换为占位符, 只保留键名")
+	file := cmd.flags.String("file", "", "导出的文件或目录, 为空时输出到标准输出")
+	layout := cmd.flags.String("layout", string(app.LayoutSingleFile), "导出方式: single(单个文件)、files(每个资源一个文件)或tar.gz")
+	helm := cmd.flags.Bool("helm", false, "导出为Helm chart, 命名空间、镜像标签和副本数提取到values.yaml")
+	chartName := cmd.flags.String("chart", "", "Helm chart名称, 默认为源命名空间名称")
+	toEnv := cmd.flags.String("to-env", "", "目标环境")
+	toNamespace := cmd.flags.String("to-ns", "", "目标命名空间")
+	toProject := cmd.flags.String("to-project", "", "目标项目ID或名称")
+	createNamespace := cmd.flags.Bool("create-ns", false, "目标命名空间不存在时按源命名空间的标签和描述创建")
+	diff := cmd.flags.Bool("diff", false, "只输出与目标命名空间现有内容的差异, 不导入")
+	tag := cmd.flags.String("tag", "", "新的镜像标签")
+	registry := cmd.flags.String("registry", "", "替换镜像仓库地址, 格式为 旧地址=新地址")
+	replicas := cmd.flags.Int("replicas", -1, "新的副本数, 默认保持不变")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	if _, err := cmd.environment(*envName); err != nil {
+		return err
+	}
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	workloads, err := cmd.selectWorkloads(source, *names, true)
+	if err != nil {
+		return err
+	}
+	options, err := cloneOptions(*tag, *registry, *replicas)
+	if err != nil {
+		return err
+	}
+	options.RedactSecrets = *redactSecrets
+
+	if isClone {
+		if *toEnv == "" || *toNamespace == "" {
+			return fmt.Errorf("%w: clone 需要 --to-env 和 --to-ns", errUsage)
+		}
+		dest, err := cmd.service.FindNamespace(*toEnv, *toNamespace, *toProject)
+		if err != nil {
+			return err
+		}
+		exists, err := cmd.service.NamespaceExists(ctx, dest)
+		if err != nil {
+			return err
+		}
+		if *diff {
+			return cloneDiff(ctx, cmd, workloads, source, dest, options, *withConfigMaps, *withSecrets)
+		}
+		if !exists {
+			if !*createNamespace {
+				return fmt.Errorf("目标命名空间 %s 不存在, 可使用 --create-ns 创建", dest.Name)
+			}
+			if err := cmd.service.CreateNamespace(ctx, dest, source); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已创建命名空间 %s\n", dest.Name)
+		}
+		results, err := cmd.service.CloneWorkloads(ctx, workloads, dest, options, nil)
+		if err == nil && *withConfigMaps {
+			var configMapResults []app.Result
+			configMapResults, err = cmd.service.CloneConfigMaps(ctx, source, dest, nil)
+			results = append(results, configMapResults...)
+		}
+		if err == nil && *withSecrets {
+			var secretResults []app.Result
+			secretResults, err = cloneSecrets(ctx, cmd, source, dest, options)
+			results = append(results, secretResults...)
+		}
+		return cmd.finish(results, err)
+	}
+
+	exportLayout, err := app.ParseExportLayout(*layout)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	exportOptions := app.ExportOptions{Layout: exportLayout, Helm: *helm, ChartName: *chartName}
+	if exportOptions.ChartName == "" {
+		exportOptions.ChartName = source.Name
+	}
+	if *file == "" && (exportLayout != app.LayoutSingleFile || *helm) {
+		return fmt.Errorf("%w: 导出为多个文件、压缩包或Helm chart时需要 --file", errUsage)
+	}
+
+	resources, results, err := cmd.service.ExportWorkloads(ctx, workloads, options, nil)
+	if err == nil && *withConfigMaps {
+		var configMapResources []app.ExportedResource
+		var configMapResults []app.Result
+		configMapResources, configMapResults, err = cmd.service.ExportConfigMaps(ctx, source, nil)
+		resources = append(resources, configMapResources...)
+		results = append(results, configMapResults...)
+	}
+	if err == nil && *withSecrets {
+		var secretResources []app.ExportedResource
+		var secretResults []app.Result
+		secretResources, secretResults, err = cmd.service.ExportSecrets(ctx, source, options, nil)
+		resources = append(resources, secretResources...)
+		results = append(results, secretResults...)
+	}
+	if *file == "" {
+		// 未指定文件时直接输出YAML, 便于管道处理
+		if _, writeErr := stdout.Write(app.JoinYaml(resources)); writeErr != nil {
+			return writeErr
+		}
+		if err != nil {
+			return err
+		}
+		if failed := app.Failed(results); failed > 0 {
+			return fmt.Errorf("%d 项操作失败", failed)
+		}
+		return nil
+	}
+	if len(resources) > 0 {
+		if writeErr := app.WriteExport(*file, resources, exportOptions); writeErr != nil {
+			return fmt.Errorf("导出到文件失败: %w", writeErr)
+		}
+	}
+	return cmd.finish(results, err)
+}
+
+// exportKustomize 将源命名空间导出为kustomize的base, 并为每个目标环境生成只包含差异的overlay
+func exportKustomize(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "源环境")
+	namespace := cmd.flags.String("ns", "", "源命名空间")
+	project := cmd.flags.String("project", "", "源项目ID或名称")
+	names := cmd.flags.String("name", "", "工作负载名称, 多个用逗号分隔, 为空时处理全部")
+	toEnv := cmd.flags.String("to-env", "", "目标环境, 多个用逗号分隔")
+	toNamespace := cmd.flags.String("to-ns", "", "目标环境中的命名空间, 默认与源命名空间同名")
+	file := cmd.flags.String("file", "", "导出目录或压缩包")
+	layout := cmd.flags.String("layout", string(app.LayoutPerResource), "导出方式: files(目录)或tar.gz")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	environments := splitNames(*toEnv)
+	if len(environments) == 0 || *file == "" {
+		return fmt.Errorf("%w: kustomize 需要 --to-env 和 --file", errUsage)
+	}
+	exportLayout, err := app.ParseExportLayout(*layout)
+	if err != nil || exportLayout == app.LayoutSingleFile {
+		return fmt.Errorf("%w: kustomize 的导出方式只能是 files 或 tar.gz", errUsage)
+	}
+	for _, environment := range environments {
+		if _, err := cmd.environment(environment); err != nil {
+			return err
+		}
+	}
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	workloads, err := cmd.selectWorkloads(source, *names, true)
+	if err != nil {
+		return err
+	}
+	files, results, err := cmd.service.ExportKustomize(ctx, source, workloads, environments, *toNamespace, nil)
+	if err == nil {
+		if writeErr := app.WriteFiles(*file, files, exportLayout == app.LayoutArchive); writeErr != nil {
+			return fmt.Errorf("导出到文件失败: %w", writeErr)
+		}
+	}
+	return cmd.finish(results, err)
+}
+
+// importYaml 将本地YAML文件、目录或压缩包导入目标命名空间
+func importYaml(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "目标环境")
+	namespace := cmd.flags.String("ns", "", "目标命名空间")
+	project := cmd.flags.String("project", "", "目标项目ID或名称")
+	createNamespace := cmd.flags.Bool("create-ns", false, "目标命名空间不存在时创建")
+	diff := cmd.flags.Bool("diff", false, "只输出与目标命名空间现有内容的差异, 不导入")
+	tag := cmd.flags.String("tag", "", "新的镜像标签")
+	registry := cmd.flags.String("registry", "", "替换镜像仓库地址, 格式为 旧地址=新地址")
+	replicas := cmd.flags.Int("replicas", -1, "新的副本数, 默认保持不变")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	paths := cmd.flags.Args()
+	if len(paths) == 0 {
+		return fmt.Errorf("%w: import 需要至少一个文件或目录", errUsage)
+	}
+	if _, err := cmd.environment(*envName); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("%w: 缺少 --ns", errUsage)
+	}
+	dest, err := cmd.service.FindNamespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	options, err := cloneOptions(*tag, *registry, *replicas)
+	if err != nil {
+		return err
+	}
+	documents, err := app.LoadImportDocuments(paths)
+	if err != nil {
+		return err
+	}
+	if !*diff {
+		exists, err := cmd.service.NamespaceExists(ctx, dest)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if !*createNamespace {
+				return fmt.Errorf("目标命名空间 %s 不存在, 可使用 --create-ns 创建", dest.Name)
+			}
+			if err := cmd.service.CreateNamespace(ctx, dest, rancher.Namespace{}); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已创建命名空间 %s\n", dest.Name)
+		}
+	}
+	previews, results, err := cmd.service.PreviewImport(ctx, documents, dest, options, nil)
+	if err != nil {
+		return err
+	}
+	if *diff {
+		return printPreviews(cmd, previews, results)
+	}
+	// 校验失败的资源不导入, 其余资源全部导入
+	applied, err := cmd.service.ApplyClonePreviews(ctx, dest, previews, nil)
+	var failed []app.Result
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return cmd.finish(append(failed, applied...), err)
+}
+
+// cloneDiff 输出克隆将要导入的内容与目标命名空间现有内容的差异, 不修改目标命名空间
+func cloneDiff(ctx context.Context, cmd *command, workloads []rancher.Workload, source, dest rancher.Namespace, options rancher.CloneOptions, withConfigMaps bool, withSecrets bool) error {
+	previews, results, err := cmd.service.PreviewCloneWorkloads(ctx, workloads, dest, options, nil)
+	if err != nil {
+		return err
+	}
+	if withConfigMaps {
+		configMapPreviews, configMapResults, err := cmd.service.PreviewCloneConfigMaps(ctx, source, dest, nil)
+		if err != nil {
+			return err
+		}
+		previews = append(previews, configMapPreviews...)
+		results = append(results, configMapResults...)
+	}
+	if withSecrets {
+		secretPreviews, secretResults, err := cmd.service.PreviewCloneSecrets(ctx, source, dest, options, nil)
+		if err != nil {
+			return err
+		}
+		previews = append(previews, secretPreviews...)
+		results = append(results, secretResults...)
+	}
+	return printPreviews(cmd, previews, results)
+}
+
+// cloneSecrets 将源命名空间的Secret导入目标命名空间, 脱敏时不覆盖目标命名空间中已有的Secret
+func cloneSecrets(ctx context.Context, cmd *command, source, dest rancher.Namespace, options rancher.CloneOptions) ([]app.Result, error) {
+	previews, results, err := cmd.service.PreviewCloneSecrets(ctx, source, dest, options, nil)
+	if err != nil {
+		return results, err
+	}
+	var failed []app.Result
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	applied, err := cmd.service.ApplyClonePreviews(ctx, dest, previews, nil)
+	return append(failed, applied...), err
+}
+
+// printPreviews 逐个输出资源的状态和差异
+func printPreviews(cmd *command, previews []app.ClonePreview, results []app.Result) error {
+	for _, preview := range previews {
+		fmt.Fprintf(cmd.stdout, "# %s %s: %s\n", preview.Kind, preview.Name, preview.Status)
+		if preview.Status != app.PreviewUnchanged {
+			fmt.Fprint(cmd.stdout, app.UnifiedDiff(preview.Diff(), 3))
+		}
+	}
+	if failed := app.Failed(results); failed > 0 {
+		return fmt.Errorf("%d 项操作失败", failed)
+	}
+	return nil
+}
+
+// cloneOptions 解析 --tag、--registry 和 --replicas, replicas小于0表示不修改
+func cloneOptions(tag, registry string, replicas int) (rancher.CloneOptions, error) {
+	options := rancher.CloneOptions{Tag: tag}
+	if registry != "" {
+		from, to, found := strings.Cut(registry, "=")
+		if !found || from == "" || to == "" {
+			return options, fmt.Errorf("%w: --registry 格式应为 旧地址=新地址", errUsage)
+		}
+		options.RegistryFrom, options.RegistryTo = from, to
+	}
+	if replicas >= 0 {
+		options.Replicas = &replicas
+	}
+	return options, nil
+}
+
+// namespaceClone 克隆整个命名空间, 目标命名空间不存在时自动创建
+func namespaceClone(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "源环境")
+	namespace := cmd.flags.String("ns", "", "源命名空间")
+	project := cmd.flags.String("project", "", "源项目ID或名称")
+	toEnv := cmd.flags.String("to-env", "", "目标环境")
+	toNamespace := cmd.flags.String("to-ns", "", "目标命名空间")
+	toProject := cmd.flags.String("to-project", "", "目标项目ID或名称, 新建命名空间时使用")
+	secrets := cmd.flags.Bool("secrets", false, "同时克隆Secret")
+	redactSecrets := cmd.flags.Bool("redact-secrets", false, "Secret的值替换为占位符, 只保留键名")
+	diff := cmd.flags.Bool("diff", false, "只输出与目标命名空间现有内容的差异, 不导入")
+	tag := cmd.flags.String("tag", "", "新的镜像标签")
+	registry := cmd.flags.String("registry", "", "替换镜像仓库地址, 格式为 旧地址=新地址")
+	replicas := cmd.flags.Int("replicas", -1, "新的副本数, 默认保持不变")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	if *toEnv == "" || *toNamespace == "" {
+		return fmt.Errorf("%w: ns clone 需要 --to-env 和 --to-ns", errUsage)
+	}
+	dest, err := cmd.service.FindNamespace(*toEnv, *toNamespace, *toProject)
+	if err != nil {
+		return err
+	}
+	cloneOptions, err := cloneOptions(*tag, *registry, *replicas)
+	if err != nil {
+		return err
+	}
+	cloneOptions.RedactSecrets = *redactSecrets
+	options := app.NamespaceCloneOptions{CloneOptions: cloneOptions, IncludeSecrets: *secrets}
+	if *diff {
+		previews, results, err := cmd.service.PreviewCloneNamespace(ctx, source, dest, options, nil)
+		if err != nil {
+			return err
+		}
+		return printPreviews(cmd, previews, results)
+	}
+	results, err := cmd.service.CloneNamespace(ctx, source, dest, options, nil)
+	return cmd.finish(results, err)
+}
+
+// secretList 列出命名空间中的Secret及其键名, 不输出值
+func secretList(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境")
+	namespace := cmd.flags.String("ns", "", "命名空间")
+	project := cmd.flags.String("project", "", "项目ID或名称")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	list, err := cmd.service.Secrets(ctx, source)
+	if err != nil {
+		return err
+	}
+	type secretResult struct {
+		Name string   `json:"name" yaml:"name"`
+		Type string   `json:"type" yaml:"type"`
+		Keys []string `json:"keys" yaml:"keys"`
+	}
+	results := []secretResult{}
+	t := table{headers: []string{"名称", "类型", "键"}}
+	for _, secret := range list {
+		keys := secret.Keys()
+		results = append(results, secretResult{secret.Metadata.Name, secret.Type, keys})
+		t.rows = append(t.rows, []string{secret.Metadata.Name, secret.Type, strings.Join(keys, ",")})
+	}
+	t.data = results
+	return cmd.print(t)
+}
+
+// secretGet 输出Secret中一个键解码后的值, 便于在脚本中使用
+func secretGet(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境")
+	namespace := cmd.flags.String("ns", "", "命名空间")
+	project := cmd.flags.String("project", "", "项目ID或名称")
+	secretName := cmd.flags.String("name", "", "Secret名称")
+	key := cmd.flags.String("key", "", "键")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	if *secretName == "" || *key == "" {
+		return fmt.Errorf("%w: secret get 需要 --name 和 --key", errUsage)
+	}
+	source, err := cmd.namespace(*envName, *namespace, *project)
+	if err != nil {
+		return err
+	}
+	list, err := cmd.service.Secrets(ctx, source)
+	if err != nil {
+		return err
+	}
+	for _, secret := range list {
+		if secret.Metadata.Name == *secretName {
+			value, err := secret.Value(*key)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(stdout, value)
+			return err
+		}
+	}
+	return fmt.Errorf("命名空间 %s 中找不到Secret: %s", source.Name, *secretName)
+}
+
+// changeList 列出同步时记录的变更, 最新的在前
+func changeList(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	cmd := newCommand(ctx, name, stdout)
+	envName := cmd.flags.String("env", "", "环境, 为空时不限制")
+	namespace := cmd.flags.String("ns", "", "命名空间, 为空时不限制")
+	workload := cmd.flags.String("name", "", "工作负载名称, 为空时不限制")
+	limit := cmd.flags.Int("limit", 100, "最多输出的记录数, 0表示不限制")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	defer cmd.close()
+
+	events, err := cmd.service.ChangeEvents(rancher.ChangeEventFilter{Environment: *envName, Namespace: *namespace, Workload: *workload, Limit: *limit})
+	if err != nil {
+		return err
+	}
+	type changeResult struct {
+		Time        string `json:"time" yaml:"time"`
+		Environment string `json:"environment" yaml:"environment"`
+		Namespace   string `json:"namespace" yaml:"namespace"`
+		Workload    string `json:"workload" yaml:"workload"`
+		Kind        string `json:"kind" yaml:"kind"`
+		Name        string `json:"name" yaml:"name"`
+		Type        string `json:"type" yaml:"type"`
+		Field       string `json:"field,omitempty" yaml:"field,omitempty"`
+		Old         string `json:"old,omitempty" yaml:"old,omitempty"`
+		New         string `json:"new,omitempty" yaml:"new,omitempty"`
+	}
+	results := []changeResult{}
+	t := table{headers: []string{"时间", "环境", "命名空间", "工作负载", "变更", "内容"}}
+	for _, event := range events {
+		eventTime := event.CreatedAt.Local().Format("2006-01-02 15:04:05")
+		results = append(results, changeResult{eventTime, event.Environment, event.Namespace, event.Workload, event.Kind, event.Name,
+			event.Type, event.Field, event.OldValue, event.NewValue})
+		t.rows = append(t.rows, []string{eventTime, event.Environment, event.Namespace, event.Workload, app.ChangeTypeLabel(event.Type), app.ChangeDetail(event)})
+	}
+	t.data = results
+	return cmd.print(t)
+}
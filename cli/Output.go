@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// table 一个可以按表格、JSON或YAML输出的结果集
+type table struct {
+	headers []string
+	rows    [][]string
+	data    interface{} // JSON/YAML输出时使用的结构化数据
+}
+
+func validateOutput(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("不支持的输出格式: %s, 可选 json|yaml|table", format)
+}
+
+// printTable 按指定格式输出结果
+func printTable(out io.Writer, format string, t table) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.data)
+	case outputYAML:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(t.data)
+	default:
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
@@ -1,30 +1,32 @@
 package main
 
 import (
+	"RancherMan/app"
+	"RancherMan/cli"
 	"RancherMan/rancher"
-	workload2 "RancherMan/rancher/types/workload"
+	"RancherMan/rancher/config"
 	"RancherMan/ui"
 	"RancherMan/ui/component"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"sort"
-	"strconv"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
+	fyneapp "fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
-	"gopkg.in/yaml.v3"
 )
 
 // 数据
 var gNamespaces []rancher.Namespace
 var gFilteredNamespaces []rancher.Namespace
 var gSelectedNamespace rancher.Namespace
+var gProjectOptions []app.ProjectOption
 var gWorkloads []rancher.Workload
 var gFilteredWorkloads []rancher.Workload
 var gSelectedWorkloads []rancher.Workload
@@ -32,116 +34,142 @@ var gSelectedWorkloads []rancher.Workload
 // UI组件
 var gNamespaceList *widget.List
 var gNamespaceSearch *widget.Entry
+var gProjectSelect *widget.Select
 var gWorkloadList *component.MultiSelectList
 var gWorkloadSearch *widget.Entry
 var gInfoArea *widget.Entry
+var gCancelButton *widget.Button
 var gApp fyne.App
 
-// 数据库和配置
-var gDb *rancher.DatabaseManager
-var gConfig map[string]interface{}
+// 服务和当前环境
+var gService *app.Service
 var gEnvironment *rancher.Environment
-var gJumpHostConfig *rancher.JumpHostConfig
-var gCloneIgnoreTagWorkload []string
+
+// 后台任务
+var gTaskMutex sync.Mutex
+var gCancelFunc context.CancelFunc
 
 func main() {
+	// 带参数启动时进入命令行模式, 不创建窗口
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-psn") {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
 	//// 创建数据库管理器实例
 	database, err := rancher.NewDatabaseManager("")
 	if err != nil {
 		log.Fatal(err)
 	}
-	gDb = database
-	defer gDb.Close()
+	defer database.Close()
+	gService = app.NewService(database)
 	window := initView()
-	loadConfig(false)
-	initData()
+	setupSecretKey(window, func() {
+		loadConfig()
+		initData()
+	})
 	window.ShowAndRun()
 }
+
+// setupSecretKey 还没有主密码或密钥文件时请用户输入主密码, 留空时生成密钥文件并提示其位置;
+// 之前使用过主密码时要求输入相同的主密码, 解密失败时重新输入。完成或取消后调用onReady
+func setupSecretKey(window fyne.Window, onReady func()) {
+	if !gService.NeedsSecretKey() {
+		onReady()
+		return
+	}
+	existing := gService.UsesPassphrase()
+	ui.ShowPassphraseDialog(window, gService.KeyFile(), existing, func(passphrase string) {
+		if passphrase != "" {
+			gService.SetPassphrase(passphrase)
+			if err := gService.LoadConfig(); existing && errors.Is(err, config.ErrDecrypt) {
+				gService.SetPassphrase("")
+				dialog.ShowError(errors.New("主密码不正确"), window)
+				setupSecretKey(window, onReady)
+				return
+			}
+			onReady()
+			return
+		}
+		keyFile, err := gService.CreateKeyFile()
+		onReady()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("生成密钥文件失败: %w", err), window)
+			return
+		}
+		dialog.ShowInformation("已生成密钥文件",
+			fmt.Sprintf("密钥文件保存在:\n%s\n请妥善保管, 丢失后需要重新填写配置中的密钥", keyFile), window)
+	}, onReady)
+}
 func initView() fyne.Window {
 	//// 初始化界面
-	gApp = app.New()
+	gApp = fyneapp.New()
 	myWindow := gApp.NewWindow("Rancher助手")
 
 	// 创建主菜单
 	mainMenu := fyne.NewMainMenu(
 		fyne.NewMenu("配置",
 			fyne.NewMenuItem("保存配置", func() {
-				var content = gInfoArea.Text
-				rancher.SaveConfigToDb(gDb, content)
-				loadConfig(true)
-				initData()
+				content := gInfoArea.Text
+				// 还没有主密码或密钥文件时先设置, 否则无法加密保存配置中的密钥
+				setupSecretKey(myWindow, func() {
+					// 校验失败时保留编辑中的内容, 在对话框中列出所有问题
+					if err := gService.SaveConfig(content); err != nil {
+						dialog.ShowError(err, myWindow)
+						return
+					}
+					gInfoArea.SetText("配置已成功加载")
+					initData()
+				})
 			}),
 			fyne.NewMenuItem("显示配置", func() {
-				configContent, _ := gDb.GetConfigContent(1)
+				configContent, _ := gService.ConfigContent()
 				gInfoArea.SetText(configContent)
 			}),
+			fyne.NewMenuItem("输入主密码", func() {
+				// 启动时取消了输入或输入错误时重新输入
+				ui.ShowPassphraseDialog(myWindow, gService.KeyFile(), true, func(passphrase string) {
+					gService.SetPassphrase(passphrase)
+					loadConfig()
+					initData()
+				}, nil)
+			}),
 		),
 		fyne.NewMenu("数据",
 			fyne.NewMenuItem("更新数据", func() {
-				var info strings.Builder
-				if gEnvironment != nil {
-					// 只更新当前选中的环境
-					info.WriteString(fmt.Sprintf("更新数据: %s ", gEnvironment.Name))
-					gInfoArea.SetText(info.String())
-					rancher.UpdateEnvironment(gDb, gEnvironment.ID, gEnvironment, true)
-					info.WriteString("完成!\n")
-					gInfoArea.SetText(info.String())
-				} else {
-					// 如果没有选中环境，则更新所有环境
-					for envName, _ := range gConfig["environment"].(map[interface{}]interface{}) {
-						environment, _ := rancher.GetEnvironmentFromConfig(gConfig, envName.(string))
-						info.WriteString(fmt.Sprintf("更新数据: %s ", environment.Name))
-						gInfoArea.SetText(info.String())
-						rancher.UpdateEnvironment(gDb, environment.ID, environment, true)
-						info.WriteString("完成!\n")
-						gInfoArea.SetText(info.String())
-					}
-				}
-				initData()
+				runSync(app.ActionSync)
 			}),
 			fyne.NewMenuItem("更新端口映射", func() {
-				var info strings.Builder
-				if gEnvironment != nil {
-					// 只更新当前选中的环境
-					info.WriteString(fmt.Sprintf("更新端口映射: %s ", gEnvironment.Name))
-					gInfoArea.SetText(info.String())
-					rancher.UpdateService(gDb, gEnvironment.ID, gEnvironment)
-					info.WriteString("完成!\n")
-					gInfoArea.SetText(info.String())
-				} else {
-					// 如果没有选中环境，则更新所有环境
-					for envName, _ := range gConfig["environment"].(map[interface{}]interface{}) {
-						environment, _ := rancher.GetEnvironmentFromConfig(gConfig, envName.(string))
-						info.WriteString(fmt.Sprintf("更新端口映射: %s ", environment.Name))
-						gInfoArea.SetText(info.String())
-						rancher.UpdateService(gDb, environment.ID, environment)
-						info.WriteString("完成!\n")
-						gInfoArea.SetText(info.String())
-					}
-				}
+				runSync(app.ActionSyncServices)
 			}),
 			fyne.NewMenuItem("更新跳板机", func() {
-				if gJumpHostConfig == nil {
+				if !gService.HasJumpHost() {
 					gInfoArea.SetText("错误：未配置跳板机信息")
 					return
 				}
-
-				// 创建进度监听器
-				listener := &jumpHostProgressListener{
-					infoArea: gInfoArea,
-				}
-
 				// 清空信息区域并显示初始信息
 				gInfoArea.SetText("开始扫描跳板机配置...\n")
 
 				// 在新的 goroutine 中执行耗时操作
 				go func() {
-					gDb.DeleteAllUploadConfigs()
-					rancher.ListUploadConfig(gJumpHostConfig, 50, listener)
+					err := gService.UpdateUploadConfigs(func(currentFolder string, current, total int) {
+						gInfoArea.SetText(fmt.Sprintf("正在扫描... %d/%d\n当前目录:%s", current, total, currentFolder))
+					})
+					if err != nil {
+						gInfoArea.SetText(fmt.Sprintf("更新跳板机失败: %v", err))
+					} else {
+						gInfoArea.SetText("更新跳板机完成")
+					}
 				}()
 			}),
+			fyne.NewMenuItem("变更记录", func() {
+				// 默认显示当前命名空间或选中工作负载的变更
+				filter := rancher.ChangeEventFilter{Environment: gSelectedNamespace.Environment, Namespace: gSelectedNamespace.Name}
+				if len(gSelectedWorkloads) == 1 {
+					filter.Workload = gSelectedWorkloads[0].Name
+				}
+				ui.ShowChangeWindow(gApp, gService, filter)
+			}),
 			fyne.NewMenuItem("清空数据", func() {
-				err := gDb.ClearAllData()
+				err := gService.ClearData()
 				if err != nil {
 					gInfoArea.SetText(fmt.Sprintf("清空数据失败: %v", err))
 				} else {
@@ -152,24 +180,116 @@ func initView() fyne.Window {
 			}),
 		),
 		fyne.NewMenu("克隆和导出",
+			fyne.NewMenuItem("克隆整个命名空间", func() {
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowSelectNamespaceDialog(myWindow, gService.Database(), gProjectOptions, gSelectedNamespace, true, func(destNamespace rancher.Namespace, tag string) {
+					ui.ShowSecretOptionsDialog(myWindow, true, func(includeSecrets bool, redactSecrets bool) {
+						options := app.NamespaceCloneOptions{CloneOptions: rancher.CloneOptions{Tag: tag, RedactSecrets: redactSecrets}, IncludeSecrets: includeSecrets}
+						runCancellable(func(ctx context.Context) {
+							cloneNamespace(ctx, myWindow, destNamespace, options)
+						})
+					})
+				})
+			}),
+			fyne.NewMenuItemSeparator(),
 			fyne.NewMenuItem("导出configMap", func() {
-				ui.ShowSelectNamespaceDialog(myWindow, gDb, false, func(destNamespace rancher.Namespace, tag string) {
-					cloneOrExportConfigMap(false, destNamespace)
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowExportDialog(myWindow, gSelectedNamespace.Name+"-configMaps", gSelectedNamespace.Name, false, func(target string, options app.ExportOptions, tag string) {
+					runCancellable(func(ctx context.Context) {
+						exportConfigMaps(ctx, target, options)
+					})
 				})
 			}),
 			fyne.NewMenuItem("克隆configMap", func() {
-				ui.ShowSelectNamespaceDialog(myWindow, gDb, false, func(destNamespace rancher.Namespace, tag string) {
-					cloneOrExportConfigMap(false, destNamespace)
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowSelectNamespaceDialog(myWindow, gService.Database(), gProjectOptions, gSelectedNamespace, false, func(destNamespace rancher.Namespace, tag string) {
+					runCancellable(func(ctx context.Context) {
+						cloneConfigMaps(ctx, myWindow, destNamespace)
+					})
+				})
+			}),
+			fyne.NewMenuItem("导出Secret", func() {
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowSecretOptionsDialog(myWindow, false, func(_ bool, redactSecrets bool) {
+					ui.ShowExportDialog(myWindow, gSelectedNamespace.Name+"-secrets", gSelectedNamespace.Name, false, func(target string, options app.ExportOptions, tag string) {
+						runCancellable(func(ctx context.Context) {
+							exportSecrets(ctx, target, options, redactSecrets)
+						})
+					})
+				})
+			}),
+			fyne.NewMenuItem("克隆Secret", func() {
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowSecretOptionsDialog(myWindow, false, func(_ bool, redactSecrets bool) {
+					ui.ShowSelectNamespaceDialog(myWindow, gService.Database(), gProjectOptions, gSelectedNamespace, false, func(destNamespace rancher.Namespace, tag string) {
+						runCancellable(func(ctx context.Context) {
+							cloneSecrets(ctx, myWindow, destNamespace, redactSecrets)
+						})
+					})
 				})
 			}),
 			fyne.NewMenuItem("导出workload", func() {
-				ui.ShowSelectNamespaceDialog(myWindow, gDb, true, func(destNamespace rancher.Namespace, tag string) {
-					cloneOrExportWorkload(false, destNamespace, tag)
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowExportDialog(myWindow, gSelectedNamespace.Name+"-workloads", gSelectedNamespace.Name, true, func(target string, options app.ExportOptions, tag string) {
+					runCancellable(func(ctx context.Context) {
+						exportWorkloads(ctx, target, options, tag)
+					})
 				})
 			}),
 			fyne.NewMenuItem("克隆workload", func() {
-				ui.ShowSelectNamespaceDialog(myWindow, gDb, true, func(destNamespace rancher.Namespace, tag string) {
-					cloneOrExportWorkload(true, destNamespace, tag)
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				ui.ShowSelectNamespaceDialog(myWindow, gService.Database(), gProjectOptions, gSelectedNamespace, true, func(destNamespace rancher.Namespace, tag string) {
+					runCancellable(func(ctx context.Context) {
+						cloneWorkloads(ctx, myWindow, destNamespace, tag)
+					})
+				})
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("导入YAML", func() {
+				ui.ShowImportSourceDialog(myWindow, func(paths []string) {
+					ui.ShowSelectNamespaceDialog(myWindow, gService.Database(), gProjectOptions, gSelectedNamespace, true, func(destNamespace rancher.Namespace, tag string) {
+						runCancellable(func(ctx context.Context) {
+							importYamlFiles(ctx, myWindow, paths, destNamespace, tag)
+						})
+					})
+				})
+			}),
+			fyne.NewMenuItem("导出kustomize", func() {
+				if gSelectedNamespace.Name == "" {
+					gInfoArea.SetText("未选择命名空间")
+					return
+				}
+				var environments []string
+				for _, envName := range gService.EnvironmentNames() {
+					if envName != gSelectedNamespace.Environment {
+						environments = append(environments, envName)
+					}
+				}
+				ui.ShowKustomizeDialog(myWindow, environments, gSelectedNamespace.Name+"-kustomize", func(target string, environments []string, destNamespace string, archive bool) {
+					runCancellable(func(ctx context.Context) {
+						exportKustomize(ctx, target, environments, destNamespace, archive)
+					})
 				})
 			}),
 		),
@@ -186,6 +306,11 @@ func initView() fyne.Window {
 	)
 	myWindow.SetMainMenu(mainMenu)
 
+	// 创建集群/项目选择框
+	gProjectSelect = widget.NewSelect([]string{allProjects}, func(string) {
+		filterNamespaces()
+	})
+
 	// 创建命名空间搜索框
 	gNamespaceSearch = widget.NewEntry()
 	gNamespaceSearch.SetPlaceHolder("搜索命名空间...")
@@ -200,7 +325,7 @@ func initView() fyne.Window {
 			return widget.NewLabel("Template Item")
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
-			item.(*widget.Label).SetText(gFilteredNamespaces[id].Name)
+			item.(*widget.Label).SetText(namespaceLabel(gFilteredNamespaces[id]))
 		},
 	)
 	gNamespaceList.OnSelected = func(id widget.ListItemID) {
@@ -209,15 +334,8 @@ func initView() fyne.Window {
 	}
 
 	// 添加命名空间搜索功能
-	gNamespaceSearch.OnChanged = func(s string) {
-		gFilteredNamespaces = filterNamespaces(gNamespaces, s)
-		gNamespaceList.UnselectAll()
-		gNamespaceList.ScrollToTop()
-		gNamespaceList.Refresh()
-		if len(gFilteredNamespaces) >= 1 {
-			gNamespaceList.Select(0)
-		}
-		updateInfoArea()
+	gNamespaceSearch.OnChanged = func(string) {
+		filterNamespaces()
 	}
 
 	namespaceScroll := container.NewScroll(gNamespaceList)
@@ -243,7 +361,11 @@ func initView() fyne.Window {
 			workload := gFilteredWorkloads[id]
 			check := item.(*fyne.Container).Objects[0].(*widget.Check)
 			label := item.(*fyne.Container).Objects[1].(*widget.Label)
-			label.SetText(workload.Name)
+			if kind := rancher.NormalizeKind(workload.Kind); kind != rancher.KindDeployment {
+				label.SetText(fmt.Sprintf("%s [%s]", workload.Name, rancher.KindLabel(kind)))
+			} else {
+				label.SetText(workload.Name)
+			}
 			check.OnChanged = func(checked bool) {
 				if checked {
 					gWorkloadList.MultiSelectedOne(id)
@@ -278,7 +400,7 @@ func initView() fyne.Window {
 
 	// 添加服务搜索功能
 	gWorkloadSearch.OnChanged = func(s string) {
-		gFilteredWorkloads = filterWorkloads(gWorkloads, s)
+		gFilteredWorkloads = app.FilterWorkloads(gWorkloads, s)
 		gWorkloadList.UnselectMulti()
 		gWorkloadList.RefreshList()
 		if len(gFilteredWorkloads) == 1 {
@@ -300,117 +422,55 @@ func initView() fyne.Window {
 
 	// 添加更pod按钮
 	buttonUpdatePod := widget.NewButton("更新Pod", func() {
-		var info strings.Builder
-		if gEnvironment != nil {
-			// 只更新当前选中的环境
-			info.WriteString(fmt.Sprintf("更新Pod: %s ", gEnvironment.Name))
-			gInfoArea.SetText(info.String())
-			rancher.UpdatePod(gDb, gEnvironment.ID, gEnvironment)
-			info.WriteString("完成!\n")
-			gInfoArea.SetText(info.String())
-		} else {
-			// 如果没有选中环境，则更新所有环境
-			for envName, _ := range gConfig["environment"].(map[interface{}]interface{}) {
-				environment, _ := rancher.GetEnvironmentFromConfig(gConfig, envName.(string))
-				info.WriteString(fmt.Sprintf("更新Pod: %s ", environment.Name))
-				gInfoArea.SetText(info.String())
-				rancher.UpdateEnvironment(gDb, environment.ID, environment, false)
-				info.WriteString("完成!\n")
-				gInfoArea.SetText(info.String())
-			}
-		}
-		updateInfoArea()
+		runSync(app.ActionSyncPods)
 	})
 
 	buttonOpen := widget.NewButton("打开", func() {
-		var info strings.Builder
-		if len(gSelectedWorkloads) > 0 {
-			// 处理多选的情况
-			for _, workload := range gSelectedWorkloads {
-				info.WriteString(fmt.Sprintf("打开: %s", workload.Name))
-				success := rancher.Scale(*gEnvironment, workload.Namespace, workload.Name, 1)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
-		} else if len(gFilteredWorkloads) > 0 {
-			// 处理未选择的情况，使用过滤列表中的所有数据
-			for _, workload := range gFilteredWorkloads {
-				info.WriteString(fmt.Sprintf("打开: %s    ", workload.Name))
-				success := rancher.Scale(*gEnvironment, workload.Namespace, workload.Name, 1)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
-		}
+		batchWorkloadAction(app.ActionStart)
 	})
 	buttonClose := widget.NewButton("关闭", func() {
-		var info strings.Builder
-		if len(gSelectedWorkloads) > 0 {
-			// 处理多选的情况
-			for _, workload := range gSelectedWorkloads {
-				info.WriteString(fmt.Sprintf("关闭: %s    ", workload.Name))
-				success := rancher.Scale(*gEnvironment, workload.Namespace, workload.Name, 0)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
-		} else if len(gFilteredWorkloads) > 0 {
-			// 处理未选择的情况，使用过滤列表中的所有数据
-			for _, workload := range gFilteredWorkloads {
-				info.WriteString(fmt.Sprintf("关闭: %s    ", workload.Name))
-				success := rancher.Scale(*gEnvironment, workload.Namespace, workload.Name, 0)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
-		}
+		batchWorkloadAction(app.ActionStop)
 	})
 	buttonRedeploy := widget.NewButton("重新部署", func() {
-		var info strings.Builder
-		if len(gSelectedWorkloads) > 0 {
-			// 处理多选的情况
-			for _, workload := range gSelectedWorkloads {
-				info.WriteString(fmt.Sprintf("重新部署: %s    ", workload.Name))
-				success := rancher.Redeploy(*gEnvironment, workload.Namespace, workload.Name)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
-		} else if len(gFilteredWorkloads) > 0 {
-			// 处理未选择的情况，使用过滤列表中的所有数据
-			for _, workload := range gFilteredWorkloads {
-				info.WriteString(fmt.Sprintf("重新部署: %s    ", workload.Name))
-				success := rancher.Redeploy(*gEnvironment, workload.Namespace, workload.Name)
-				if success {
-					info.WriteString("成功!\n")
-				} else {
-					info.WriteString("失败!\n")
-				}
-				gInfoArea.SetText(info.String())
-			}
+		batchWorkloadAction(app.ActionRedeploy)
+	})
+	buttonTrigger := widget.NewButton("立即执行", func() {
+		batchWorkloadAction(app.ActionTrigger)
+	})
+	buttonLog := widget.NewButton("日志", func() {
+		if len(gSelectedWorkloads) != 1 || gEnvironment == nil {
+			gInfoArea.SetText("请选择一个服务查看日志")
+			return
 		}
+		workload := gSelectedWorkloads[0]
+		ui.ShowLogWindow(gApp, rancher.NewClient(gEnvironment.WithProject(workload.ProjectId)), workload)
 	})
+	buttonExec := widget.NewButton("进入容器", func() {
+		if len(gSelectedWorkloads) != 1 || gEnvironment == nil {
+			gInfoArea.SetText("请选择一个服务进入容器")
+			return
+		}
+		workload := gSelectedWorkloads[0]
+		ui.ShowExecWindow(gApp, rancher.NewClient(gEnvironment.WithProject(workload.ProjectId)), workload)
+	})
+	buttonSecret := widget.NewButton("Secret", func() {
+		if gSelectedNamespace.Name == "" {
+			gInfoArea.SetText("请先选择命名空间")
+			return
+		}
+		ui.ShowSecretWindow(gApp, gService, gSelectedNamespace)
+	})
+	// 取消按钮, 仅在有后台任务执行时可用
+	gCancelButton = widget.NewButton("取消", func() {
+		cancelRunningTask()
+	})
+	gCancelButton.Disable()
 
 	// 更新布局（移除了buttonUpdateData）
 	content := container.NewHBox(
 		container.NewVBox(
 			widget.NewLabel("命名空间"),
+			gProjectSelect,
 			gNamespaceSearch,
 			namespaceScroll,
 		),
@@ -420,43 +480,20 @@ func initView() fyne.Window {
 			workloadScroll,
 		),
 		container.NewVBox(
-			container.NewHBox(buttonUpdatePod, buttonOpen, buttonClose, buttonRedeploy),
+			container.NewHBox(buttonUpdatePod, buttonOpen, buttonClose, buttonRedeploy, buttonTrigger, buttonLog, buttonExec, buttonSecret, gCancelButton),
 			infoContainer,
 		),
 	)
 	myWindow.SetContent(content)
 	return myWindow
 }
-func loadConfig(showSuccessTip bool) {
-	var err error
-	gConfig, err = rancher.LoadConfigFromDb(gDb)
-	// 解析跳板机配置
-	if jumpHost, exists := gConfig["jump_host"].(map[interface{}]interface{}); exists {
-		gJumpHostConfig = &rancher.JumpHostConfig{
-			Ip:       jumpHost["ip"].(string),
-			Port:     strconv.Itoa(jumpHost["port"].(int)),
-			Username: jumpHost["username"].(string),
-			Password: jumpHost["password"].(string),
-			RootPath: jumpHost["root_path"].(string),
-		}
-	}
-	// 解析 clone_ignore_tag_workload
-	if ignoreList, exists := gConfig["clone_ignore_tag_workload"].([]interface{}); exists {
-		gCloneIgnoreTagWorkload = make([]string, len(ignoreList))
-		for i, item := range ignoreList {
-			gCloneIgnoreTagWorkload[i] = item.(string)
-		}
-	}
-	if err != nil {
-		gInfoArea.SetText(fmt.Sprintf("从数据库读取配置时出错: %v", err))
-	} else {
-		if showSuccessTip {
-			gInfoArea.SetText("配置已成功加载")
-		}
+func loadConfig() {
+	if err := gService.LoadConfig(); err != nil {
+		gInfoArea.SetText(err.Error())
 	}
 }
 func initData() {
-	namespaces, _ := gDb.GetAllNamespacesDetail()
+	namespaces, _ := gService.Namespaces("")
 	gNamespaces = append(namespaces)
 	gFilteredNamespaces = append(gNamespaces)
 	gSelectedNamespace = rancher.Namespace{}
@@ -465,17 +502,59 @@ func initData() {
 	gNamespaceList.Refresh()
 	gNamespaceSearch.SetText("")
 
+	// 配置变化后重新生成集群/项目选项
+	gProjectOptions = gService.ProjectOptions()
+	options := []string{allProjects}
+	for _, option := range gProjectOptions {
+		options = append(options, option.Label)
+	}
+	gProjectSelect.Options = options
+	gProjectSelect.Selected = allProjects
+	gProjectSelect.Refresh()
+
 	gWorkloads = []rancher.Workload{}
 	gFilteredWorkloads = []rancher.Workload{}
 	gWorkloadList.RefreshList()
 	gWorkloadSearch.SetText("")
 }
 
+// allProjects 集群/项目选择框中表示不过滤的选项
+const allProjects = "全部"
+
+// filterNamespaces 按选择的集群/项目和搜索内容过滤命名空间
+func filterNamespaces() {
+	namespaces := gNamespaces
+	for _, option := range gProjectOptions {
+		if option.Label == gProjectSelect.Selected {
+			namespaces = app.FilterNamespacesByProject(namespaces, option.Environment, option.Project)
+			break
+		}
+	}
+	gFilteredNamespaces = app.FilterNamespaces(namespaces, gNamespaceSearch.Text)
+	gNamespaceList.UnselectAll()
+	gNamespaceList.ScrollToTop()
+	gNamespaceList.Refresh()
+	if len(gFilteredNamespaces) >= 1 {
+		gNamespaceList.Select(0)
+	}
+	updateInfoArea()
+}
+
+// namespaceLabel 环境配置了多个项目时, 在命名空间名称后显示所属项目, 以区分同名命名空间
+func namespaceLabel(namespace rancher.Namespace) string {
+	for _, option := range gProjectOptions {
+		if option.Multiple && option.Environment == namespace.Environment && option.Project == namespace.Project {
+			return namespace.Name + " (" + option.Label + ")"
+		}
+	}
+	return namespace.Name
+}
+
 func selectNamespace(namespace rancher.Namespace) {
 	gSelectedNamespace = namespace
-	gEnvironment, _ = rancher.GetEnvironmentFromConfig(gConfig, gSelectedNamespace.Environment)
+	gEnvironment, _ = gService.Environment(gSelectedNamespace.Environment)
 
-	workloads, _ := gDb.GetWorkloadsByNamespace(namespace.Name)
+	workloads, _ := gService.Workloads(namespace)
 	gWorkloads = workloads
 	gWorkloadSearch.SetText("")
 	gFilteredWorkloads = gWorkloads
@@ -497,33 +576,32 @@ func updateInfoArea() {
 }
 
 func updateInfoAreaForSelectNamespace() {
-	podList, _ := gDb.GetPodsByEnvNamespace(gSelectedNamespace.Environment, gSelectedNamespace.Name)
+	detail, err := gService.NamespaceDetail(gSelectedNamespace)
+	if err != nil {
+		gInfoArea.SetText(fmt.Sprintf("读取命名空间信息失败: %v", err))
+		return
+	}
 
 	var info strings.Builder
-	info.WriteString(fmt.Sprintf("环境: %s\n", gEnvironment.Name))
+	info.WriteString(fmt.Sprintf("环境: %s\n", detail.EnvironmentName))
 	info.WriteString(fmt.Sprintf("命名空间: %s\n", gSelectedNamespace.Name))
 	info.WriteString(fmt.Sprintf("项目: %s\n", gSelectedNamespace.Project))
 	info.WriteString(fmt.Sprintf("描述: %s\n", gSelectedNamespace.Description))
-	info.WriteString(fmt.Sprintf("pod数量: %d\n", len(podList)))
-	// 创建一个map来存储相同workloadId的pod状态
-	podStates := make(map[string][]string)
-	for _, pod := range podList {
-		// 获取workloadId的最后一部分
-		parts := strings.Split(pod.WorkloadId, ":")
-		workloadName := parts[len(parts)-1]
-		podStates[workloadName] = append(podStates[workloadName], pod.State)
-	}
-
+	info.WriteString(fmt.Sprintf("pod数量: %d\n", len(detail.Pods)))
 	// 打印每个workload的pod状态
-	for workloadName, states := range podStates {
-		info.WriteString(fmt.Sprintf("%s: %s\n", workloadName, strings.Join(states, ",")))
+	for _, workload := range detail.WorkloadStates {
+		info.WriteString(fmt.Sprintf("%s: %s\n", workload.Name, strings.Join(workload.States, ",")))
 	}
 	gInfoArea.SetText(info.String())
 }
 
 func updateInfoAreaForSingleWorkload() {
 	workload := gSelectedWorkloads[0]
-	podList, _ := gDb.GetPodsByEnvNamespaceWorkload(workload.Environment, workload.Namespace, workload.Name)
+	detail, err := gService.WorkloadDetail(workload)
+	if err != nil {
+		gInfoArea.SetText(fmt.Sprintf("读取服务信息失败: %v", err))
+		return
+	}
 
 	// 构建信息字符串
 	var info strings.Builder
@@ -531,129 +609,66 @@ func updateInfoAreaForSingleWorkload() {
 	info.WriteString(fmt.Sprintf("环境: %s\n", workload.Environment))
 	info.WriteString(fmt.Sprintf("命名空间: %s\n", workload.Namespace))
 	info.WriteString(fmt.Sprintf("名称: %s\n", workload.Name))
-	info.WriteString(fmt.Sprintf("镜像: %s\n", workload.Image))
-	info.WriteString(fmt.Sprintf("镜像拉取策略: %s\n", workload.ImagePullPolicy))
-	info.WriteString(fmt.Sprintf("pod数量: %d\n", len(podList)))
-	if len(podList) > 0 {
+	info.WriteString(fmt.Sprintf("类型: %s\n", rancher.KindLabel(workload.Kind)))
+	if len(workload.Containers) > 1 {
+		info.WriteString("容器:\n")
+		for _, c := range workload.Containers {
+			info.WriteString(fmt.Sprintf("  %s    %s\n", c.Name, containerRoleLabel(c.Role)))
+			info.WriteString(fmt.Sprintf("    镜像: %s\n", c.Image))
+			info.WriteString(fmt.Sprintf("    镜像拉取策略: %s\n", c.ImagePullPolicy))
+			if c.Ports != "" {
+				info.WriteString(fmt.Sprintf("    端口: %s\n", c.Ports))
+			}
+		}
+	} else {
+		info.WriteString(fmt.Sprintf("镜像: %s\n", workload.Image))
+		info.WriteString(fmt.Sprintf("镜像拉取策略: %s\n", workload.ImagePullPolicy))
+	}
+	info.WriteString(fmt.Sprintf("pod数量: %d\n", len(detail.Pods)))
+	if len(detail.Pods) > 0 {
 		var states []string
-		for _, pod := range podList {
+		for _, pod := range detail.Pods {
 			states = append(states, pod.State)
 		}
 		info.WriteString(fmt.Sprintf("Pod状态: %s\n", strings.Join(states, ",")))
-	}
-	// 检查数据库相关的环境变量
-	if (strings.Contains(strings.ToLower(workload.Name), "mysql") ||
-		strings.Contains(strings.ToLower(workload.Name), "mongo")) &&
-		workload.ContainerEnvironment != "" {
-		var envVars map[string]string
-		if err := json.Unmarshal([]byte(workload.ContainerEnvironment), &envVars); err == nil {
-			// 检查并输出每个可能存在的环境变量
-			if password, exists := envVars["MYSQL_ROOT_PASSWORD"]; exists {
-				info.WriteString(fmt.Sprintf("MySQL Root密码: %s\n", password))
-			}
-			if username, exists := envVars["MONGO_INITDB_ROOT_USERNAME"]; exists {
-				info.WriteString(fmt.Sprintf("MongoDB初始化Root用户名: %s\n", username))
-			}
-			if password, exists := envVars["MONGO_INITDB_ROOT_PASSWORD"]; exists {
-				info.WriteString(fmt.Sprintf("MongoDB初始化Root密码: %s\n", password))
+		for _, pod := range detail.Pods {
+			if pod.Name != "" {
+				info.WriteString(fmt.Sprintf("  %s    %s    节点: %s    重启: %d    容器: %s\n", pod.Name, pod.State, pod.NodeId, pod.RestartCount, pod.Containers))
 			}
 		}
 	}
-	services, err := gDb.GetServicesByWorkload(workload.Environment, workload.ProjectId, workload.Namespace, workload.Name)
-	if err == nil && len(services) > 0 {
+	for _, credential := range detail.Credentials {
+		info.WriteString(fmt.Sprintf("%s: %s\n", credential.Label, credential.Value))
+	}
+	if len(detail.Services) > 0 {
 		info.WriteString("端口访问:\n")
-		ip := gEnvironment.Ip
-		for _, port := range services {
+		for _, port := range detail.Services {
 			if port.Kind == "NodePort" {
-				info.WriteString(fmt.Sprintf("  %s    %s    %d->%s:%d\n", port.PortName, port.PortProtocol, port.Port, ip, port.NodePort))
+				info.WriteString(fmt.Sprintf("  %s    %s    %d->%s:%d\n", port.PortName, port.PortProtocol, port.Port, detail.NodeIp, port.NodePort))
 			} else {
 				info.WriteString(fmt.Sprintf("  %s    %s    %d\n", port.PortName, port.PortProtocol, port.Port))
 			}
 		}
 	}
-	// 只有当 AccessPath 不为空时才显示，并按逗号分隔成多行
-	if workload.AccessPath != "" {
+	if len(detail.AccessPaths) > 0 {
 		info.WriteString("访问路径:\n")
-		paths := strings.Split(workload.AccessPath, ",")
-		for _, path := range paths {
-			info.WriteString(fmt.Sprintf("  %s\n", strings.TrimSpace(path)))
+		for _, path := range detail.AccessPaths {
+			info.WriteString(fmt.Sprintf("  %s\n", path))
 		}
 	}
-	var uploadConfigList []rancher.UploadConfig
-	// 获取完整镜像名称的配置
-	configs, _ := gDb.GetUploadConfigsByImage(workload.Image)
-	uploadConfigList = append(uploadConfigList, configs...)
-
-	// 获取不带标签的镜像名称的配置
-	image := workload.Image
-	tag := ""
-	if colonIndex := strings.LastIndex(workload.Image, ":"); colonIndex > 0 {
-		image = workload.Image[:colonIndex]
-		tag = workload.Image[colonIndex+1:]
-	}
-	configs1, _ := gDb.GetUploadConfigsByImageLikeSpecial1(image)
-	uploadConfigList = append(uploadConfigList, configs1...)
-	// 获取最后两个/之间的部分
-	imageDir := ""
-	if strings.Count(image, "/") >= 2 {
-		lastSlashIndex := strings.LastIndex(image, "/")
-		lastTwoSlashIndex := strings.LastIndex(image[:lastSlashIndex], "/")
-		if lastTwoSlashIndex > 0 {
-			imageDir = image[lastTwoSlashIndex+1 : lastSlashIndex]
-		}
-	}
-	if lastSlashIndex := strings.LastIndex(image, "/"); lastSlashIndex >= 0 {
-		image = image[lastSlashIndex+1:]
-	}
-	configs2, _ := gDb.GetUploadConfigsByImageLikeSpecial2(image)
-	uploadConfigList = append(uploadConfigList, configs2...)
-	// 对uploadConfigList进行排序
-	sort.Slice(uploadConfigList, func(i, j int) bool {
-		// 获取$符号数量
-		dollarCountI := strings.Count(uploadConfigList[i].Image, "$")
-		dollarCountJ := strings.Count(uploadConfigList[j].Image, "$")
-
-		// 如果$数量不同,按数量升序排序
-		if dollarCountI != dollarCountJ {
-			return dollarCountI < dollarCountJ
-		}
-
-		// 如果$数量相同,检查namespace中的部分是否包含在Dir中
-		parts := strings.Split(workload.Namespace, "-")
-		if len(parts) >= 3 {
-			// 取两个-号之间的部分
-			middlePart := parts[1]
-			containsI := strings.Contains(uploadConfigList[i].Dir, middlePart)
-			containsJ := strings.Contains(uploadConfigList[j].Dir, middlePart)
-
-			// 包含middlePart的排在前面
-			if containsI != containsJ {
-				return containsI
-			}
-		}
-
-		// 其他情况保持原有顺序
-		return i < j
-	})
 	// 如果有上传配置，则显示
-	if len(uploadConfigList) > 0 {
+	if len(detail.DeployScripts) > 0 {
 		info.WriteString("\n上传配置:\n")
-		for _, config := range uploadConfigList {
-			info.WriteString(fmt.Sprintf("  目录: %s\n", strings.ReplaceAll(config.Dir, "\\", "/")))
-			if config.Script != "" {
-				var script = config.Script
-				if strings.Count(config.Image, "$") == 1 {
-					script = script + " " + tag
-				} else if strings.Count(config.Image, "$") == 2 {
-					script = script + " " + tag + " " + imageDir
-				}
-				info.WriteString(fmt.Sprintf("  脚本: ./%s\n", script))
+		for _, script := range detail.DeployScripts {
+			info.WriteString(fmt.Sprintf("  目录: %s\n", script.Dir))
+			if script.Script != "" {
+				info.WriteString(fmt.Sprintf("  脚本: ./%s\n", script.Script))
 			}
-			if config.Jar != "" {
-				info.WriteString(fmt.Sprintf("  Jar包: %s\n", config.Jar))
+			if script.Jar != "" {
+				info.WriteString(fmt.Sprintf("  Jar包: %s\n", script.Jar))
 			}
-			if config.Image != "" {
-				info.WriteString(fmt.Sprintf("  镜像: %s\n", config.Image))
+			if script.Image != "" {
+				info.WriteString(fmt.Sprintf("  镜像: %s\n", script.Image))
 			}
 			info.WriteString("\n")
 		}
@@ -668,242 +683,358 @@ func updateInfoAreaForSelectMultiWorkload() {
 
 	for _, workload := range gSelectedWorkloads {
 		info.WriteString(fmt.Sprintf("\n服务名称: %s\n", workload.Name))
-		info.WriteString(fmt.Sprintf("镜像: %s\n", workload.Image))
+		info.WriteString(fmt.Sprintf("类型: %s\n", rancher.KindLabel(workload.Kind)))
+		info.WriteString(fmt.Sprintf("镜像: %s\n", strings.Join(workload.Images(), ", ")))
 	}
 
 	gInfoArea.SetText(info.String())
 }
 
-// 添加过过滤函数
-func filterNamespaces(items []rancher.Namespace, filter string) []rancher.Namespace {
-	if filter == "" {
-		return items
-	}
-	var filtered []rancher.Namespace
-	for _, item := range items {
-		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(filter)) || strings.Contains(strings.ToLower(item.Description), strings.ToLower(filter)) {
-			filtered = append(filtered, item)
-		}
+// containerRoleLabel 容器角色的显示名称
+func containerRoleLabel(role string) string {
+	switch role {
+	case rancher.ContainerMain:
+		return "主容器"
+	case rancher.ContainerInit:
+		return "init容器"
+	default:
+		return "sidecar"
 	}
-	return filtered
 }
 
-func filterWorkloads(items []rancher.Workload, filter string) []rancher.Workload {
-	if filter == "" {
-		return items
-	}
-	var filtered []rancher.Workload
-	for _, item := range items {
-		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(filter)) {
-			filtered = append(filtered, item)
-		}
+// runCancellable 在后台执行耗时任务, 执行期间可通过取消按钮中止
+func runCancellable(task func(ctx context.Context)) {
+	gTaskMutex.Lock()
+	if gCancelFunc != nil {
+		gTaskMutex.Unlock()
+		gInfoArea.SetText("已有任务正在执行, 请等待完成或先取消")
+		return
 	}
-	return filtered
+	ctx, cancel := context.WithCancel(context.Background())
+	gCancelFunc = cancel
+	gTaskMutex.Unlock()
+	gCancelButton.Enable()
+
+	go func() {
+		defer func() {
+			gTaskMutex.Lock()
+			gCancelFunc = nil
+			gTaskMutex.Unlock()
+			cancel()
+			gCancelButton.Disable()
+		}()
+		task(ctx)
+	}()
 }
 
-func cloneOrExportWorkload(isClone bool, destNamespace rancher.Namespace, tag string) {
-	if isClone && destNamespace.Name == "" {
-		gInfoArea.SetText("未选择目标命名空间")
-		return
+// cancelRunningTask 取消正在执行的后台任务
+func cancelRunningTask() {
+	gTaskMutex.Lock()
+	defer gTaskMutex.Unlock()
+	if gCancelFunc != nil {
+		gCancelFunc()
 	}
+}
 
-	var info strings.Builder
-	var allYaml strings.Builder // 用于存储所有workload的YAML
-
-	processWorkloads := func(workloads []rancher.Workload) {
-		for _, workload := range workloads {
-			info.WriteString(fmt.Sprintf("获取deployment: %s    ", workload.Name))
-			deployment, err := rancher.GetDeploymentYaml(*gEnvironment, workload.Namespace, workload.Name)
-			if err == nil {
-				info.WriteString("成功!\n")
-				// 替换deployment名称中的namespace
-				if destNamespace.Name != "" {
-					deployment = strings.ReplaceAll(deployment, fmt.Sprintf(":\"%s:", workload.Namespace), fmt.Sprintf(":\"%s:", destNamespace.Name))
-					deployment = strings.ReplaceAll(deployment, fmt.Sprintf("deployment-%s-", workload.Namespace), fmt.Sprintf("deployment-%s-", destNamespace.Name))
-				}
-				if tag != "" {
-					// 检查workload是否在忽略列表中
-					shouldUpdateTag := true
-					for _, ignoreName := range gCloneIgnoreTagWorkload {
-						if strings.Contains(workload.Name, ignoreName) {
-							shouldUpdateTag = false
-							break
-						}
-					}
-					if shouldUpdateTag {
-						// 从原始镜像名称中分离基础名称和标签
-						baseImage := workload.Image
-						if colonIndex := strings.LastIndex(workload.Image, ":"); colonIndex > 0 {
-							baseImage = workload.Image[:colonIndex]
-						}
-						// 使用新标签替换
-						deployment = strings.ReplaceAll(deployment, fmt.Sprintf("image: %s", workload.Image), fmt.Sprintf("image: %s:%s", baseImage, tag))
-					}
-				}
-				// 解析yaml
-				var deploymentStruct workload2.Deployment
-				if err := yaml.Unmarshal([]byte(deployment), &deploymentStruct); err != nil {
-					info.WriteString(fmt.Sprintf("解析deployment失败: %v\n", err))
-					continue
-				}
-				// 如果nodeSelectorTerms为空,添加默认的node selector
-				if len(deploymentStruct.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) == 0 {
-					deploymentStruct.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = []workload2.NodeSelectorTerm{
-						{
-							MatchExpressions: []workload2.MatchExpression{
-								{
-									Key:      "role",
-									Operator: "In",
-									Values:   []string{"node"},
-								},
-							},
-						},
-					}
-				}
-				if destNamespace.Name != "" {
-					deploymentStruct.Metadata.Namespace = destNamespace.Name
-				}
-				// 编码yaml
-				yamlData, err := yaml.Marshal(deploymentStruct)
-				if err != nil {
-					info.WriteString(fmt.Sprintf("写入YAML失败: %v\n", err))
-					continue
-				}
+// targetWorkloads 返回操作的目标工作负载, 未选择时为过滤后的全部
+func targetWorkloads() []rancher.Workload {
+	if len(gSelectedWorkloads) > 0 {
+		return gSelectedWorkloads
+	}
+	return gFilteredWorkloads
+}
 
-				if isClone {
-					// 克隆模式：导入到Rancher
-					destEnvironment, _ := rancher.GetEnvironmentFromConfig(gConfig, destNamespace.Environment)
-					err := rancher.ImportYaml(*destEnvironment, "big-data", yamlData)
-					if err != nil {
-						info.WriteString("克隆失败!\n")
-					} else {
-						info.WriteString("克隆成功!\n")
-					}
-				} else {
-					allYaml.WriteString(fmt.Sprintf("# workload %s\n", workload.Name))
-					// 导出模式：添加到YAML字符串
-					allYaml.WriteString("---\n") // YAML文档分隔符
-					allYaml.Write(yamlData)
-					allYaml.WriteString("\n")
-					info.WriteString("已添加到导出文件\n")
-				}
+// progressWriter 将服务层的进度事件逐行写入信息区域
+func progressWriter(info *strings.Builder) app.Progress {
+	return func(event app.Event) {
+		result := event.Result
+		switch event.Type {
+		case app.EventStarted:
+			if result.Kind != "" {
+				info.WriteString(fmt.Sprintf("%s %s: %s    ", result.Action.Label(), result.Kind, result.Name))
 			} else {
-				info.WriteString("失败!\n")
+				info.WriteString(fmt.Sprintf("%s: %s    ", result.Action.Label(), result.Name))
 			}
-			gInfoArea.SetText(info.String())
+		case app.EventFinished:
+			info.WriteString(resultStatus(result) + "\n")
 		}
+		gInfoArea.SetText(info.String())
 	}
+}
 
-	if len(gSelectedWorkloads) > 0 {
-		processWorkloads(gSelectedWorkloads)
-	} else if len(gFilteredWorkloads) > 0 {
-		processWorkloads(gFilteredWorkloads)
+// resultStatus 单个结果的状态说明
+func resultStatus(result app.Result) string {
+	if !result.Success() {
+		return fmt.Sprintf("失败: %s", result.Reason())
 	}
+	if result.Message != "" {
+		return fmt.Sprintf("成功! %s", result.Message)
+	}
+	return "成功!"
+}
 
-	// 如果是导出模式，将所有YAML写入文件
-	if !isClone && allYaml.Len() > 0 {
-		err := os.WriteFile("workloads.yaml", []byte(allYaml.String()), 0644)
-		if err != nil {
-			info.WriteString(fmt.Sprintf("\n导出到文件失败: %v", err))
+// syncProgressWriter 多个环境并发更新, 每个环境的每项更新显示一行, 完成后替换为结果
+func syncProgressWriter(info *strings.Builder) app.Progress {
+	var lines []string
+	index := map[string]int{}
+	return func(event app.Event) {
+		result := event.Result
+		key := result.Environment + "/" + string(result.Action)
+		line := fmt.Sprintf("%s: %s    ", result.Action.Label(), result.Name)
+		status := "进行中..."
+		if event.Type == app.EventFinished {
+			status = resultStatus(result)
+		}
+		if i, ok := index[key]; ok {
+			lines[i] = line + status
 		} else {
-			info.WriteString("\n已成功导出到 workloads.yaml")
+			index[key] = len(lines)
+			lines = append(lines, line+status)
 		}
+		info.Reset()
+		info.WriteString(strings.Join(lines, "\n") + "\n")
+		gInfoArea.SetText(info.String())
 	}
+}
 
+// writeTaskError 在信息区域末尾追加任务整体的错误, 如取消
+func writeTaskError(info *strings.Builder, err error) {
+	if err != nil {
+		info.WriteString(fmt.Sprintf("%s\n", rancher.ErrorReason(err)))
+	}
 	gInfoArea.SetText(info.String())
 }
 
-func cloneOrExportConfigMap(isClone bool, destNamespace rancher.Namespace) {
-	if isClone && destNamespace.Name == "" {
-		gInfoArea.SetText("未选择目标命名空间")
+// runSync 在后台更新当前环境的数据, 未选择命名空间时并发更新所有环境。更新数据时同时更新Pod状态和端口映射
+func runSync(action app.Action) {
+	var envNames []string
+	if gEnvironment != nil {
+		envNames = []string{gEnvironment.ID}
+	}
+	actions := []app.Action{action}
+	if action == app.ActionSync {
+		actions = append(actions, app.ActionSyncPods, app.ActionSyncServices)
+	}
+	runCancellable(func(ctx context.Context) {
+		var info strings.Builder
+		_, err := gService.Sync(ctx, actions, envNames, syncProgressWriter(&info))
+		if action == app.ActionSync {
+			initData()
+		} else if action == app.ActionSyncPods {
+			updateInfoArea()
+			return
+		}
+		writeTaskError(&info, err)
+	})
+}
+
+// batchWorkloadAction 对选中的工作负载(未选择时为过滤后的全部)逐个执行操作
+func batchWorkloadAction(action app.Action) {
+	if gEnvironment == nil {
+		gInfoArea.SetText("未选择命名空间")
+		return
+	}
+	workloads := targetWorkloads()
+	if len(workloads) == 0 {
 		return
 	}
+	runCancellable(func(ctx context.Context) {
+		var info strings.Builder
+		_, err := gService.WorkloadAction(ctx, action, workloads, progressWriter(&info))
+		writeTaskError(&info, err)
+	})
+}
+
+// exportWorkloads 按导出方式将选中的工作负载(未选择时为过滤后的全部)导出到target
+func exportWorkloads(ctx context.Context, target string, options app.ExportOptions, tag string) {
+	var info strings.Builder
+	resources, _, err := gService.ExportWorkloads(ctx, targetWorkloads(), rancher.CloneOptions{Tag: tag}, progressWriter(&info))
+	writeExport(&info, target, resources, options)
+	writeTaskError(&info, err)
+}
 
+// exportConfigMaps 按导出方式将当前命名空间的configMap导出到target
+func exportConfigMaps(ctx context.Context, target string, options app.ExportOptions) {
 	var info strings.Builder
-	var allYaml strings.Builder // 用于存储所有workload的YAML
-	list, err := rancher.GetConfigMapList(*gEnvironment, gSelectedNamespace.Name)
+	resources, _, err := gService.ExportConfigMaps(ctx, gSelectedNamespace, progressWriter(&info))
+	writeExport(&info, target, resources, options)
+	writeTaskError(&info, err)
+}
+
+// exportSecrets 按导出方式将当前命名空间的Secret导出到target, redactSecrets为true时只导出键名
+func exportSecrets(ctx context.Context, target string, options app.ExportOptions, redactSecrets bool) {
+	var info strings.Builder
+	resources, _, err := gService.ExportSecrets(ctx, gSelectedNamespace, rancher.CloneOptions{RedactSecrets: redactSecrets}, progressWriter(&info))
+	writeExport(&info, target, resources, options)
+	writeTaskError(&info, err)
+}
+
+// importYamlFiles 读取本地YAML文件, 改写到目标命名空间后预览, 确认后导入
+func importYamlFiles(ctx context.Context, window fyne.Window, paths []string, destNamespace rancher.Namespace, tag string) {
+	var info strings.Builder
+	documents, err := app.LoadImportDocuments(paths)
 	if err != nil {
-		gInfoArea.SetText("获取配置时出错")
+		writeTaskError(&info, err)
 		return
 	}
+	destNamespace, ok := ensureDestNamespace(ctx, window, &info, destNamespace)
+	if !ok {
+		return
+	}
+	previews, _, err := gService.PreviewImport(ctx, documents, destNamespace, rancher.CloneOptions{Tag: tag}, progressWriter(&info))
+	if err != nil {
+		writeTaskError(&info, err)
+		return
+	}
+	applyClonePreviews(ctx, window, &info, destNamespace, previews)
+}
 
-	for _, configMap := range list {
-		info.WriteString(fmt.Sprintf("获取configMap: %s    ", configMap.Name))
-		configMap.ApiVersion = "v1"
-		configMap.Kind = "ConfigMap"
-		configMap.Metadata.Name = configMap.Name
-		if destNamespace.Name != "" {
-			configMap.Metadata.Namespace = destNamespace.Name
-		}
-
-		// 编码yaml
-		yamlData, err := yaml.Marshal(configMap)
-		if err != nil {
-			info.WriteString(fmt.Sprintf("写入YAML失败: %v\n", err))
-			continue
-		}
-
-		if isClone {
-			// 克隆模式：导入到Rancher
-			destEnvironment, _ := rancher.GetEnvironmentFromConfig(gConfig, destNamespace.Environment)
-			err := rancher.ImportYaml(*destEnvironment, "big-data", yamlData)
-			if err != nil {
-				info.WriteString("克隆失败!\n")
-			} else {
-				info.WriteString("克隆成功!\n")
-			}
-		} else {
-			allYaml.WriteString(fmt.Sprintf("# configMap %s\n", configMap.Name))
-			// 导出模式：添加到YAML字符串
-			allYaml.WriteString("---\n") // YAML文档分隔符
-			allYaml.Write(yamlData)
-			allYaml.WriteString("\n")
-			info.WriteString("已添加到导出文件\n")
+// exportKustomize 将选中的工作负载(未选择时为过滤后的全部)导出为kustomize的base和各目标环境的overlay
+func exportKustomize(ctx context.Context, target string, environments []string, destNamespace string, archive bool) {
+	var info strings.Builder
+	files, _, err := gService.ExportKustomize(ctx, gSelectedNamespace, targetWorkloads(), environments, destNamespace, progressWriter(&info))
+	if err == nil {
+		if err = app.WriteFiles(target, files, archive); err == nil {
+			info.WriteString(fmt.Sprintf("\n已成功导出到 %s\n", target))
 		}
-		gInfoArea.SetText(info.String())
 	}
+	writeTaskError(&info, err)
+}
 
-	// 如果是导出模式，将所有YAML写入文件
-	if !isClone && allYaml.Len() > 0 {
-		err := os.WriteFile("configMaps.yaml", []byte(allYaml.String()), 0644)
-		if err != nil {
-			info.WriteString(fmt.Sprintf("\n导出到文件失败: %v", err))
-		} else {
-			info.WriteString("\n已成功导出到 configMaps.yaml")
-		}
+func cloneWorkloads(ctx context.Context, window fyne.Window, destNamespace rancher.Namespace, tag string) {
+	var info strings.Builder
+	workloads := targetWorkloads()
+	destNamespace, ok := ensureDestNamespace(ctx, window, &info, destNamespace)
+	if !ok {
+		return
 	}
+	previews, _, err := gService.PreviewCloneWorkloads(ctx, workloads, destNamespace, rancher.CloneOptions{Tag: tag}, progressWriter(&info))
+	if err != nil {
+		writeTaskError(&info, err)
+		return
+	}
+	applyClonePreviews(ctx, window, &info, destNamespace, previews)
+}
 
-	gInfoArea.SetText(info.String())
+func cloneConfigMaps(ctx context.Context, window fyne.Window, destNamespace rancher.Namespace) {
+	var info strings.Builder
+	destNamespace, ok := ensureDestNamespace(ctx, window, &info, destNamespace)
+	if !ok {
+		return
+	}
+	previews, _, err := gService.PreviewCloneConfigMaps(ctx, gSelectedNamespace, destNamespace, progressWriter(&info))
+	if err != nil {
+		writeTaskError(&info, err)
+		return
+	}
+	applyClonePreviews(ctx, window, &info, destNamespace, previews)
 }
 
-// 在 main.go 中添加以下结构体和方法
-type jumpHostProgressListener struct {
-	infoArea *widget.Entry
+func cloneSecrets(ctx context.Context, window fyne.Window, destNamespace rancher.Namespace, redactSecrets bool) {
+	var info strings.Builder
+	destNamespace, ok := ensureDestNamespace(ctx, window, &info, destNamespace)
+	if !ok {
+		return
+	}
+	previews, _, err := gService.PreviewCloneSecrets(ctx, gSelectedNamespace, destNamespace, rancher.CloneOptions{RedactSecrets: redactSecrets}, progressWriter(&info))
+	if err != nil {
+		writeTaskError(&info, err)
+		return
+	}
+	applyClonePreviews(ctx, window, &info, destNamespace, previews)
 }
 
-func (l *jumpHostProgressListener) OnProgress(currentFolder string, current, total int) {
-	// 直接更新 UI
-	l.infoArea.SetText(fmt.Sprintf("正在扫描... %d/%d\n当前目录:%s", current, total, currentFolder))
+// cloneNamespace 将当前命名空间中的所有资源克隆到目标命名空间, 预览确认后按依赖顺序导入
+func cloneNamespace(ctx context.Context, window fyne.Window, destNamespace rancher.Namespace, options app.NamespaceCloneOptions) {
+	var info strings.Builder
+	destNamespace, ok := ensureDestNamespace(ctx, window, &info, destNamespace)
+	if !ok {
+		return
+	}
+	previews, _, err := gService.PreviewCloneNamespace(ctx, gSelectedNamespace, destNamespace, options, progressWriter(&info))
+	if err != nil {
+		writeTaskError(&info, err)
+		return
+	}
+	applyClonePreviews(ctx, window, &info, destNamespace, previews)
 }
 
-func (l *jumpHostProgressListener) OnComplete() {
-	// 直接更新 UI
-	l.infoArea.SetText("更新跳板机完成")
+// applyClonePreviews 显示差异预览, 确认后导入选中的资源
+func applyClonePreviews(ctx context.Context, window fyne.Window, info *strings.Builder, destNamespace rancher.Namespace, previews []app.ClonePreview) {
+	if len(previews) == 0 {
+		writeTaskError(info, fmt.Errorf("没有需要克隆的资源"))
+		return
+	}
+	answer := make(chan []app.ClonePreview, 1)
+	ui.ShowClonePreviewDialog(window, previews,
+		func(accepted []app.ClonePreview) { answer <- accepted },
+		func() { answer <- nil })
+	var accepted []app.ClonePreview
+	select {
+	case <-ctx.Done():
+		writeTaskError(info, ctx.Err())
+		return
+	case accepted = <-answer:
+	}
+	if accepted == nil {
+		writeTaskError(info, fmt.Errorf("已取消克隆"))
+		return
+	}
+	info.WriteString("\n")
+	_, err := gService.ApplyClonePreviews(ctx, destNamespace, accepted, progressWriter(info))
+	writeTaskError(info, err)
 }
 
-func (l *jumpHostProgressListener) OnBatchResult(configs []rancher.SSHUploadConfig) {
-	// 将 SSHUploadConfig 转换为 UploadConfig
-	var uploadConfigs []rancher.UploadConfig
-	for _, config := range configs {
-		uploadConfig := rancher.UploadConfig{
-			Dir:    config.Dir,
-			Script: config.Script,
-			Jar:    config.Jar,
-			Image:  config.Image,
+// ensureDestNamespace 检查克隆的目标命名空间是否存在, 不存在时询问是否按源命名空间的标签和描述创建。
+// 对话框中输入的新命名空间没有环境, 使用当前命名空间所在的环境和项目
+func ensureDestNamespace(ctx context.Context, window fyne.Window, info *strings.Builder, destNamespace rancher.Namespace) (rancher.Namespace, bool) {
+	if destNamespace.Name == "" {
+		writeTaskError(info, fmt.Errorf("未选择目标命名空间"))
+		return destNamespace, false
+	}
+	if destNamespace.Environment == "" {
+		destNamespace.Environment = gSelectedNamespace.Environment
+		destNamespace.Project = gSelectedNamespace.Project
+	}
+	exists, err := gService.NamespaceExists(ctx, destNamespace)
+	if err != nil {
+		writeTaskError(info, err)
+		return destNamespace, false
+	}
+	if exists {
+		return destNamespace, true
+	}
+	answer := make(chan bool, 1)
+	dialog.ShowConfirm("创建命名空间",
+		fmt.Sprintf("目标命名空间 %s 不存在, 是否按 %s 的标签和描述创建?", destNamespace.Name, gSelectedNamespace.Name),
+		func(ok bool) { answer <- ok }, window)
+	select {
+	case <-ctx.Done():
+		writeTaskError(info, ctx.Err())
+		return destNamespace, false
+	case ok := <-answer:
+		if !ok {
+			writeTaskError(info, fmt.Errorf("目标命名空间不存在, 已取消克隆"))
+			return destNamespace, false
 		}
-		uploadConfigs = append(uploadConfigs, uploadConfig)
 	}
+	if err := gService.CreateNamespace(ctx, destNamespace, gSelectedNamespace); err != nil {
+		writeTaskError(info, err)
+		return destNamespace, false
+	}
+	info.WriteString(fmt.Sprintf("已创建命名空间 %s\n", destNamespace.Name))
+	gInfoArea.SetText(info.String())
+	return destNamespace, true
+}
 
-	// 插入数据库
-	gDb.InsertUploadConfigs(uploadConfigs)
+// writeExport 按导出方式将导出的资源写入文件或目录
+func writeExport(info *strings.Builder, target string, resources []app.ExportedResource, options app.ExportOptions) {
+	if len(resources) == 0 {
+		return
+	}
+	if err := app.WriteExport(target, resources, options); err != nil {
+		info.WriteString(fmt.Sprintf("\n导出到文件失败: %v\n", err))
+	} else {
+		info.WriteString(fmt.Sprintf("\n已成功导出到 %s\n", target))
+	}
 }
@@ -0,0 +1,127 @@
+package rancher
+
+import (
+	"RancherMan/rancher/types/configMaps"
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CloneOptions 克隆或导出工作负载时的参数
+type CloneOptions struct {
+	DestNamespace  string   // 目标命名空间, 为空时保持原命名空间
+	Tag            string   // 新的镜像标签, 为空时不修改
+	IgnoreTagNames []string // 名称包含其中任一字符串的工作负载不修改镜像标签
+	RegistryFrom   string   // 需要替换的镜像仓库地址前缀, 为空时不替换
+	RegistryTo     string   // 替换后的镜像仓库地址前缀
+	Replicas       *int     // 新的副本数, 为nil时保持不变
+	KeepAffinity   bool     // 不为Deployment补充默认的节点亲和性, 导出原样的资源时使用
+	RedactSecrets  bool     // 将Secret的值替换为占位符, 只保留键名
+}
+
+// shouldUpdateTag 检查workload是否在忽略列表中
+func (o CloneOptions) shouldUpdateTag(name string) bool {
+	if o.Tag == "" {
+		return false
+	}
+	for _, ignoreName := range o.IgnoreTagNames {
+		if strings.Contains(name, ignoreName) {
+			return false
+		}
+	}
+	return true
+}
+
+// Pipeline 根据克隆参数生成工作负载的改写步骤
+func (o CloneOptions) Pipeline(workload Workload) Pipeline {
+	kind := NormalizeKind(workload.Kind)
+	pipeline := Pipeline{StripStatus()}
+	if o.DestNamespace != "" {
+		pipeline = append(pipeline, RenameNamespace(workload.Namespace, o.DestNamespace))
+	}
+	// 只改写与主容器同一次发布的容器(包括使用相同镜像的初始化容器), 其他边车容器保持原来的版本。
+	// 按原始镜像匹配容器, 需要在替换仓库地址之前执行
+	if o.shouldUpdateTag(workload.Name) {
+		pipeline = append(pipeline, RetagImage(workload.Image, o.Tag))
+	}
+	if o.RegistryFrom != "" {
+		pipeline = append(pipeline, RewriteRegistry(o.RegistryFrom, o.RegistryTo))
+	}
+	if o.Replicas != nil {
+		pipeline = append(pipeline, SetReplicas(*o.Replicas))
+	}
+	// 如果nodeSelectorTerms为空,添加默认的node selector
+	if kind == KindDeployment && !o.KeepAffinity {
+		pipeline = append(pipeline, InjectNodeAffinity(DefaultNodeAffinity))
+	}
+	if kind == KindJob {
+		pipeline = append(pipeline, ResetJobSelector())
+	}
+	return pipeline
+}
+
+// ResourcePipeline 克隆整个命名空间和导入本地文件时单个资源的改写步骤。与Pipeline相同, 只更新与主容器同一次发布的容器的标签
+func (o CloneOptions) ResourcePipeline(kind string, namespace string, name string) Pipeline {
+	pipeline := Pipeline{StripStatus()}
+	if o.DestNamespace != "" {
+		pipeline = append(pipeline, RenameNamespace(namespace, o.DestNamespace))
+	}
+	switch kind {
+	case ResourceService.Kind:
+		pipeline = append(pipeline, ResetServiceAddresses())
+	case ResourcePersistentVolumeClaim.Kind:
+		pipeline = append(pipeline, UnbindVolumeClaim())
+	case ResourceSecret.Kind:
+		if o.RedactSecrets {
+			pipeline = append(pipeline, RedactSecret())
+		}
+	case ResourceDeployment.Kind, ResourceStatefulSet.Kind, ResourceDaemonSet.Kind, ResourceCronJob.Kind, ResourceJob.Kind:
+		if o.shouldUpdateTag(name) {
+			pipeline = append(pipeline, RetagWorkloadRelease(name, o.Tag))
+		}
+		if o.RegistryFrom != "" {
+			pipeline = append(pipeline, RewriteRegistry(o.RegistryFrom, o.RegistryTo))
+		}
+		if o.Replicas != nil {
+			pipeline = append(pipeline, SetReplicas(*o.Replicas))
+		}
+		if kind == ResourceDeployment.Kind && !o.KeepAffinity {
+			pipeline = append(pipeline, InjectNodeAffinity(DefaultNodeAffinity))
+		}
+		if kind == ResourceJob.Kind {
+			pipeline = append(pipeline, ResetJobSelector())
+		}
+	}
+	return pipeline
+}
+
+// BuildWorkloadYaml 获取工作负载的YAML并按克隆参数改写
+func BuildWorkloadYaml(ctx context.Context, client *Client, workload Workload, options CloneOptions) ([]byte, error) {
+	kind := NormalizeKind(workload.Kind)
+	content, err := client.GetWorkloadYaml(ctx, kind, workload.Namespace, workload.Name)
+	if err != nil {
+		return nil, err
+	}
+	yamlData, err := options.Pipeline(workload).ApplyYaml([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("改写%s失败: %w", workload.Name, err)
+	}
+	return yamlData, nil
+}
+
+// BuildConfigMapYaml 将configMap转换为可导入的YAML
+func BuildConfigMapYaml(configMap configMaps.ConfigMap, destNamespace string) ([]byte, error) {
+	configMap.ApiVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Metadata.Name = configMap.Name
+	if destNamespace != "" {
+		configMap.Metadata.Namespace = destNamespace
+	}
+	yamlData, err := yaml.Marshal(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("写入YAML失败: %w", err)
+	}
+	return yamlData, nil
+}
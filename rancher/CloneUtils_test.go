@@ -0,0 +1,144 @@
+package rancher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// containerImages 按初始化容器、容器的顺序返回所有容器的镜像
+func containerImages(object map[string]interface{}) []string {
+	var images []string
+	eachContainer(object, func(container map[string]interface{}) {
+		image, _ := container["image"].(string)
+		images = append(images, image)
+	})
+	return images
+}
+
+// retagYaml 主容器web、同一次发布的初始化容器, 以及各自有版本的边车容器
+const retagYaml = `
+kind: Deployment
+metadata:
+	name: web
+	namespace: dev
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web-migrate:1.0
+				- name: wait
+				  image: busybox:1.0
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:1.0
+				- name: log
+				  image: registry.old.com/team/filebeat:7.0
+				- name: proxy
+				  image: envoyproxy/envoy:v1.27.0
+`
+
+func TestCloneOptionsPipelineRetag(t *testing.T) {
+	workload := Workload{Name: "web", Namespace: "dev", Kind: KindDeployment, Image: "registry.old.com/team/web:1.0"}
+	cases := []struct {
+		name    string
+		options CloneOptions
+		want    []string
+	}{
+		{
+			name:    "改写同一次发布的容器",
+			options: CloneOptions{Tag: "2.0"},
+			want: []string{"registry.old.com/team/web-migrate:2.0", "busybox:1.0",
+				"registry.old.com/team/web:2.0", "registry.old.com/team/filebeat:7.0", "envoyproxy/envoy:v1.27.0"},
+		},
+		{
+			name:    "先改写标签再替换仓库地址",
+			options: CloneOptions{Tag: "2.0", RegistryFrom: "registry.old.com", RegistryTo: "registry.new.com"},
+			want: []string{"registry.new.com/team/web-migrate:2.0", "busybox:1.0",
+				"registry.new.com/team/web:2.0", "registry.new.com/team/filebeat:7.0", "envoyproxy/envoy:v1.27.0"},
+		},
+		{
+			name:    "忽略列表中的工作负载不改写标签",
+			options: CloneOptions{Tag: "2.0", IgnoreTagNames: []string{"we"}},
+			want: []string{"registry.old.com/team/web-migrate:1.0", "busybox:1.0",
+				"registry.old.com/team/web:1.0", "registry.old.com/team/filebeat:7.0", "envoyproxy/envoy:v1.27.0"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			object := parseObject(t, retagYaml)
+			if err := tc.options.Pipeline(workload).Apply(object); err != nil {
+				t.Fatalf("改写失败: %v", err)
+			}
+			if got := containerImages(object); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("镜像\n实际: %s\n期望: %s", strings.Join(got, ", "), strings.Join(tc.want, ", "))
+			}
+		})
+	}
+}
+
+// TestCloneOptionsResourcePipelineRetag 克隆整个命名空间时与克隆工作负载相同, 边车容器保持原来的版本
+func TestCloneOptionsResourcePipelineRetag(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "按名称查找主容器",
+			input: retagYaml,
+			want: []string{"registry.old.com/team/web-migrate:2.0", "busybox:1.0",
+				"registry.old.com/team/web:2.0", "registry.old.com/team/filebeat:7.0", "envoyproxy/envoy:v1.27.0"},
+		},
+		{
+			name: "没有同名容器时第一个容器为主容器",
+			input: `
+kind: Deployment
+metadata:
+	name: web
+spec:
+	template:
+		spec:
+			containers:
+				- name: proxy
+				  image: envoyproxy/envoy:v1.27.0
+				- name: app
+				  image: registry.old.com/team/web:1.0
+`,
+			want: []string{"envoyproxy/envoy:2.0", "registry.old.com/team/web:1.0"},
+		},
+		{
+			name: "CronJob",
+			input: `
+kind: CronJob
+metadata:
+	name: web
+spec:
+	jobTemplate:
+		spec:
+			template:
+				spec:
+					containers:
+						- name: web
+						  image: registry.old.com/team/web:1.0
+						- name: proxy
+						  image: envoyproxy/envoy:v1.27.0
+`,
+			want: []string{"registry.old.com/team/web:2.0", "envoyproxy/envoy:v1.27.0"},
+		},
+	}
+	options := CloneOptions{DestNamespace: "test", Tag: "2.0", KeepAffinity: true}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			object := parseObject(t, tc.input)
+			kind, _ := object["kind"].(string)
+			if err := options.ResourcePipeline(kind, "dev", "web").Apply(object); err != nil {
+				t.Fatalf("改写失败: %v", err)
+			}
+			if got := containerImages(object); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("镜像\n实际: %s\n期望: %s", strings.Join(got, ", "), strings.Join(tc.want, ", "))
+			}
+		})
+	}
+}
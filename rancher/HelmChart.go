@@ -0,0 +1,128 @@
+package rancher
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HelmTemplate chart中的一个模板文件
+type HelmTemplate struct {
+	Name    string // templates目录下的文件名
+	Content []byte
+}
+
+// HelmChart 由导出的资源生成的最小Helm chart。命名空间、镜像标签和副本数提取为values.yaml中的配置项,
+// 其余内容原样写入模板
+type HelmChart struct {
+	Name      string
+	namespace string
+	workloads map[string]map[string]interface{}
+	templates []HelmTemplate
+}
+
+// NewHelmChart 创建一个空的chart, name同时作为Chart.yaml中的名称
+func NewHelmChart(name string) *HelmChart {
+	return &HelmChart{Name: name, workloads: map[string]map[string]interface{}{}}
+}
+
+// AddYaml 将一个资源加入chart。资源中的命名空间、镜像标签和副本数被替换为对values的引用
+func (c *HelmChart) AddYaml(kind string, name string, content []byte) error {
+	var object map[string]interface{}
+	if err := yaml.Unmarshal(content, &object); err != nil {
+		return fmt.Errorf("解析%s的YAML失败: %w", name, err)
+	}
+	if object == nil {
+		return fmt.Errorf("%s的YAML内容为空", name)
+	}
+
+	// 先写入占位符, 编码后再替换为模板表达式, 避免表达式被YAML加上引号
+	expressions := map[string]string{}
+	placeholder := func(expression string) string {
+		key := fmt.Sprintf("__helm_value_%d__", len(expressions))
+		expressions[key] = expression
+		return key
+	}
+
+	if metadata, ok := nestedMap(object, "metadata"); ok {
+		if namespace, ok := metadata["namespace"].(string); ok && namespace != "" {
+			if c.namespace == "" {
+				c.namespace = namespace
+			}
+			metadata["namespace"] = placeholder("{{ .Values.namespace }}")
+		}
+	}
+	if spec, ok := nestedMap(object, "spec"); ok && IsScalable(kind) {
+		if replicas, ok := spec["replicas"]; ok {
+			c.workloadValues(name)["replicas"] = replicas
+			spec["replicas"] = placeholder(fmt.Sprintf("{{ index .Values.workloads %q \"replicas\" }}", name))
+		}
+	}
+	eachContainer(object, func(container map[string]interface{}) {
+		containerName, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		repository := imageRepository(image)
+		// 没有标签或使用摘要的镜像保持不变
+		if containerName == "" || repository == image || strings.Contains(image, "@") {
+			return
+		}
+		tags, _ := c.workloadValues(name)["tags"].(map[string]interface{})
+		if tags == nil {
+			tags = map[string]interface{}{}
+			c.workloadValues(name)["tags"] = tags
+		}
+		tags[containerName] = image[len(repository)+1:]
+		container["image"] = repository + ":" + placeholder(fmt.Sprintf("{{ index .Values.workloads %q \"tags\" %q }}", name, containerName))
+	})
+
+	yamlData, err := yaml.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("写入YAML失败: %w", err)
+	}
+	template := string(yamlData)
+	for key, expression := range expressions {
+		template = strings.ReplaceAll(template, key, expression)
+	}
+	c.templates = append(c.templates, HelmTemplate{
+		Name:    fmt.Sprintf("%s-%s.yaml", strings.ToLower(kind), name),
+		Content: []byte(template),
+	})
+	return nil
+}
+
+func (c *HelmChart) workloadValues(name string) map[string]interface{} {
+	values, ok := c.workloads[name]
+	if !ok {
+		values = map[string]interface{}{}
+		c.workloads[name] = values
+	}
+	return values
+}
+
+// ChartYaml 返回Chart.yaml的内容
+func (c *HelmChart) ChartYaml() ([]byte, error) {
+	chart := map[string]interface{}{
+		"apiVersion": "v2",
+		"name":       c.Name,
+		"type":       "application",
+		"version":    "0.1.0",
+	}
+	if c.namespace != "" {
+		chart["description"] = fmt.Sprintf("从命名空间 %s 导出", c.namespace)
+	}
+	return yaml.Marshal(chart)
+}
+
+// ValuesYaml 返回values.yaml的内容
+func (c *HelmChart) ValuesYaml() ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"namespace": c.namespace,
+		"workloads": c.workloads,
+	})
+}
+
+// Templates 返回按加入顺序排列的模板文件
+func (c *HelmChart) Templates() []HelmTemplate {
+	return c.templates
+}
@@ -0,0 +1,66 @@
+package rancher
+
+import (
+	"strings"
+)
+
+// 导入YAML后资源的状态, 与kubectl apply的输出一致
+const (
+	ImportCreated    = "created"
+	ImportConfigured = "configured"
+	ImportUnchanged  = "unchanged"
+)
+
+// ImportedResource 导入YAML时一个资源的处理结果
+type ImportedResource struct {
+	Kind   string // 资源类型, 如 deployment.apps、configmap
+	Name   string
+	Status string // created、configured、unchanged, 无法识别时为原始输出
+}
+
+// StatusLabel 返回状态的中文描述
+func (r ImportedResource) StatusLabel() string {
+	switch r.Status {
+	case ImportCreated:
+		return "已创建"
+	case ImportConfigured:
+		return "已更新"
+	case ImportUnchanged:
+		return "未变化"
+	}
+	return r.Status
+}
+
+// String 返回便于显示的描述, 如 "configmap/app 已更新"
+func (r ImportedResource) String() string {
+	if r.Kind == "" {
+		return r.Name + " " + r.StatusLabel()
+	}
+	return r.Kind + "/" + r.Name + " " + r.StatusLabel()
+}
+
+// ParseImportOutput 解析importYaml返回的kubectl apply输出, 每行形如 "deployment.apps/app configured"
+func ParseImportOutput(message string) []ImportedResource {
+	var resources []ImportedResource
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		resource, status, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		item := ImportedResource{Name: resource, Status: strings.TrimSpace(status)}
+		if slashIndex := strings.LastIndex(resource, "/"); slashIndex > 0 {
+			item.Kind = resource[:slashIndex]
+			item.Name = resource[slashIndex+1:]
+		}
+		// 去掉 "configured (server dry run)" 之类的附加说明
+		if spaceIndex := strings.Index(item.Status, " "); spaceIndex > 0 {
+			item.Status = item.Status[:spaceIndex]
+		}
+		resources = append(resources, item)
+	}
+	return resources
+}
@@ -0,0 +1,187 @@
+package rancher
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// WorkloadOverlay 工作负载在目标环境中与源环境不同的字段, 用于生成kustomize overlay中的补丁
+type WorkloadOverlay struct {
+	Image           string                    // 目标环境中的镜像, 为空时相同
+	Env             map[string]string         // 目标环境中新增或修改的环境变量
+	RemovedEnv      []string                  // 目标环境中没有的环境变量
+	Replicas        *int                      // 目标环境中的副本数, 为nil时相同
+	AffinityChanged bool                      // 必需的节点条件是否不同
+	NodeAffinity    []NodeSelectorRequirement // 目标环境中必需的节点条件
+}
+
+// DiffWorkload 比较本地缓存中同一工作负载在源环境和目标环境中的镜像、环境变量、副本数和节点条件。
+// 多容器的工作负载没有缓存镜像和环境变量, 只比较副本数和节点条件
+func DiffWorkload(source Workload, target Workload) (WorkloadOverlay, error) {
+	var overlay WorkloadOverlay
+	if source.Image != "" && target.Image != "" && source.Image != target.Image {
+		overlay.Image = target.Image
+	}
+	if source.Image != "" && target.Image != "" {
+		sourceEnv, err := parseContainerEnvironment(source.ContainerEnvironment)
+		if err != nil {
+			return overlay, err
+		}
+		targetEnv, err := parseContainerEnvironment(target.ContainerEnvironment)
+		if err != nil {
+			return overlay, err
+		}
+		for name, value := range targetEnv {
+			if current, ok := sourceEnv[name]; !ok || current != value {
+				if overlay.Env == nil {
+					overlay.Env = map[string]string{}
+				}
+				overlay.Env[name] = value
+			}
+		}
+		for name := range sourceEnv {
+			if _, ok := targetEnv[name]; !ok {
+				overlay.RemovedEnv = append(overlay.RemovedEnv, name)
+			}
+		}
+		sort.Strings(overlay.RemovedEnv)
+	}
+	if source.Replicas != nil && target.Replicas != nil && *source.Replicas != *target.Replicas {
+		replicas := *target.Replicas
+		overlay.Replicas = &replicas
+	}
+	if source.NodeAffinity != target.NodeAffinity {
+		var expressions []string
+		if target.NodeAffinity != "" {
+			if err := json.Unmarshal([]byte(target.NodeAffinity), &expressions); err != nil {
+				return overlay, fmt.Errorf("解析%s的节点条件失败: %w", target.Name, err)
+			}
+		}
+		for _, expression := range expressions {
+			requirement, err := ParseNodeRequirement(expression)
+			if err != nil {
+				return overlay, err
+			}
+			overlay.NodeAffinity = append(overlay.NodeAffinity, requirement)
+		}
+		overlay.AffinityChanged = true
+	}
+	return overlay, nil
+}
+
+func parseContainerEnvironment(content string) (map[string]string, error) {
+	env := map[string]string{}
+	if content == "" || content == "null" {
+		return env, nil
+	}
+	if err := json.Unmarshal([]byte(content), &env); err != nil {
+		return nil, fmt.Errorf("解析环境变量失败: %w", err)
+	}
+	return env, nil
+}
+
+// Changes 返回有差异的字段名称, 没有差异时为空
+func (o WorkloadOverlay) Changes() []string {
+	var changes []string
+	if o.Image != "" {
+		changes = append(changes, "镜像")
+	}
+	if len(o.Env) > 0 || len(o.RemovedEnv) > 0 {
+		changes = append(changes, "环境变量")
+	}
+	if o.Replicas != nil {
+		changes = append(changes, "副本数")
+	}
+	if o.AffinityChanged {
+		changes = append(changes, "节点亲和性")
+	}
+	return changes
+}
+
+// Patch 生成strategic merge补丁。base为源环境中工作负载的完整对象, 用于确定类型、命名空间和容器名称
+func (o WorkloadOverlay) Patch(base map[string]interface{}) (map[string]interface{}, error) {
+	baseMetadata, _ := nestedMap(base, "metadata")
+	metadata := map[string]interface{}{"name": baseMetadata["name"]}
+	if namespace, ok := baseMetadata["namespace"]; ok {
+		metadata["namespace"] = namespace
+	}
+	patch := map[string]interface{}{
+		"apiVersion": base["apiVersion"],
+		"kind":       base["kind"],
+		"metadata":   metadata,
+	}
+	if o.Replicas != nil {
+		ensureMap(patch, "spec")["replicas"] = *o.Replicas
+	}
+
+	// 补丁中Pod模板的位置与源工作负载一致, CronJob在jobTemplate中
+	var templateSpec map[string]interface{}
+	if _, ok := nestedMap(base, "spec", "jobTemplate"); ok {
+		templateSpec = ensureMap(ensureMap(ensureMap(ensureMap(ensureMap(patch, "spec"), "jobTemplate"), "spec"), "template"), "spec")
+	} else {
+		templateSpec = ensureMap(ensureMap(ensureMap(patch, "spec"), "template"), "spec")
+	}
+
+	if o.Image != "" || len(o.Env) > 0 || len(o.RemovedEnv) > 0 {
+		containerName := firstContainerName(base)
+		if containerName == "" {
+			return nil, fmt.Errorf("%v 中没有容器", baseMetadata["name"])
+		}
+		container := map[string]interface{}{"name": containerName}
+		if o.Image != "" {
+			container["image"] = o.Image
+		}
+		names := make([]string, 0, len(o.Env))
+		for name := range o.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var env []interface{}
+		for _, name := range names {
+			env = append(env, map[string]interface{}{"name": name, "value": o.Env[name]})
+		}
+		for _, name := range o.RemovedEnv {
+			env = append(env, map[string]interface{}{"name": name, "$patch": "delete"})
+		}
+		if len(env) > 0 {
+			container["env"] = env
+		}
+		templateSpec["containers"] = []interface{}{container}
+	}
+
+	if o.AffinityChanged {
+		nodeAffinity := ensureMap(ensureMap(templateSpec, "affinity"), "nodeAffinity")
+		if len(o.NodeAffinity) == 0 {
+			// null表示删除源环境中必需的节点条件
+			nodeAffinity["requiredDuringSchedulingIgnoredDuringExecution"] = nil
+		} else {
+			nodeAffinity["requiredDuringSchedulingIgnoredDuringExecution"] = map[string]interface{}{
+				"nodeSelectorTerms": nodeSelectorTerms(o.NodeAffinity),
+			}
+		}
+	}
+	if len(templateSpec) == 0 {
+		// 只修改副本数时去掉空的Pod模板
+		spec, _ := nestedMap(patch, "spec")
+		delete(spec, "template")
+		delete(spec, "jobTemplate")
+	}
+	return patch, nil
+}
+
+// firstContainerName 返回Pod模板中第一个容器的名称, 本地缓存只记录单容器工作负载的镜像和环境变量
+func firstContainerName(object map[string]interface{}) string {
+	template, ok := podTemplate(object)
+	if !ok {
+		return ""
+	}
+	spec, _ := nestedMap(template, "spec")
+	list, _ := spec["containers"].([]interface{})
+	if len(list) == 0 {
+		return ""
+	}
+	container, _ := list[0].(map[string]interface{})
+	name, _ := container["name"].(string)
+	return name
+}
@@ -2,39 +2,91 @@ package rancher
 
 import (
 	"RancherMan/rancher/types/configMaps"
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 type WorkloadResp struct {
+	Id          string `json:"id"`
+	Type        string `json:"type"`
 	Name        string
 	NamespaceID string
 	ProjectID   string
-	Containers  []Container
+	Scale       *int // 副本数, 只有Deployment和StatefulSet有
+	Scheduling  struct {
+		Node struct {
+			RequireAll []string // 必需的节点条件, 如 role=node
+		}
+	}
+	Containers []Container
 }
 
 type Container struct {
+	Name            string
 	Image           string
 	ImagePullPolicy string
 	Environment     map[string]string
+	Ports           []ContainerPortResp
+	InitContainer   bool // Rancher把init容器和普通容器放在同一个列表中, 用该字段区分
+}
+
+type ContainerPortResp struct {
+	Name          string
+	Protocol      string
+	ContainerPort int
 }
 
 type NamespaceResp struct {
 	Name        string
 	ProjectId   string
 	Description string
+	Labels      map[string]string
 }
 
 type PodResp struct {
+	Id          string
+	Name        string
 	ProjectId   string
 	NamespaceId string
 	WorkloadId  string
+	NodeId      string
 	State       string
+	Containers  []PodContainerResp
+}
+
+type PodContainerResp struct {
+	Name         string
+	Image        string
+	State        string
+	RestartCount int
+}
+
+// RestartCount 返回pod中所有容器的重启次数之和
+func (p PodResp) RestartCount() int {
+	count := 0
+	for _, container := range p.Containers {
+		count += container.RestartCount
+	}
+	return count
+}
+
+// ContainerNames 返回pod中所有容器的名称
+func (p PodResp) ContainerNames() []string {
+	names := make([]string, 0, len(p.Containers))
+	for _, container := range p.Containers {
+		names = append(names, container.Name)
+	}
+	return names
 }
 
 type ServiceResp struct {
@@ -54,215 +106,388 @@ type PortResp struct {
 	NodePort   int
 }
 
-func makeProjectRequest(environment Environment, method, url string, payload []byte) (*http.Response, error) {
-	project := environment.Project
-	fullURL := fmt.Sprintf("project/%s/%s", project, url)
-	return makeRequest(environment, method, fullURL, payload, "")
+// DefaultTimeout 环境未配置timeout时单个请求的超时时间
+const DefaultTimeout = 30 * time.Second
+
+// Client Rancher API客户端, 绑定一个环境的地址和凭证
+type Client struct {
+	environment Environment
+	httpClient  *http.Client
+}
+
+// NewClient 根据环境创建API客户端
+func NewClient(environment Environment) *Client {
+	return &Client{
+		environment: environment,
+		httpClient:  &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}},
+	}
+}
+
+// Environment 返回客户端所属的环境
+func (c *Client) Environment() Environment {
+	return c.environment
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.environment.Timeout > 0 {
+		return c.environment.Timeout
+	}
+	return DefaultTimeout
+}
+
+// cancelOnClose 在响应体关闭时释放请求的超时上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (c *Client) projectURL(url string) string {
+	return fmt.Sprintf("project/%s/%s", c.environment.Project, url)
 }
 
-func makeRequest(environment Environment, method, url string, payload []byte, accept string) (*http.Response, error) {
-	baseURL := environment.BaseURL
-	fullURL := fmt.Sprintf("%s/%s", baseURL, url)
-	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+// do 向Rancher API发送请求, 非2xx响应会被转换为*APIError, 调用方负责关闭返回的Body
+// 每个请求都受环境超时约束, ctx被取消时请求会立即中止
+func (c *Client) do(ctx context.Context, method, url string, payload []byte, accept string) (*http.Response, error) {
+	return c.send(ctx, method, fmt.Sprintf("%s/%s", c.environment.BaseURL, url), payload, accept)
+}
+
+// send 向完整地址发送请求, 用于Rancher API和k8s代理
+func (c *Client) send(ctx context.Context, method, fullURL string, payload []byte, accept string) (*http.Response, error) {
 	var body io.Reader
 	if payload != nil {
-		body = strings.NewReader(string(payload))
+		body = bytes.NewReader(payload)
 	}
-	req, err := http.NewRequest(method, fullURL, body)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, &APIError{Method: method, URL: fullURL, Err: err}
 	}
 	if accept != "" {
 		req.Header.Set("Accept", accept)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(environment.username, environment.password)
-	return client.Do(req)
+	req.SetBasicAuth(c.environment.username, c.environment.password)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// 先检查是否超时或被取消, cancel之后ctx.Err()总是返回context.Canceled
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		cancel()
+		return nil, &APIError{Method: method, URL: fullURL, Err: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer cancel()
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(method, fullURL, resp, respBody)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
 }
 
-func Scale(environment Environment, namespace string, workload string, replicas int) bool {
+// doJSON 发送请求并将响应解码到out, out为nil时丢弃响应体
+func (c *Client) doJSON(ctx context.Context, method, url string, payload []byte, out interface{}) error {
+	resp, err := c.do(ctx, method, url, payload, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &APIError{Method: method, URL: resp.Request.URL.String(), StatusCode: resp.StatusCode, Err: fmt.Errorf("解析响应失败: %w", err)}
+	}
+	return nil
+}
 
-	service := workload
+// serviceName 去掉workload名称中可能带有的类型和命名空间前缀
+func serviceName(workload string) string {
 	if colonIndex := strings.LastIndex(workload, ":"); colonIndex > 0 {
-		service = workload[colonIndex+1:]
+		return workload[colonIndex+1:]
 	}
+	return workload
+}
 
+// Scale 调整工作负载的副本数, 仅支持Deployment和StatefulSet
+func (c *Client) Scale(ctx context.Context, kind string, namespace string, workload string, replicas int) error {
+	if !IsScalable(kind) {
+		return &UnsupportedKindError{Kind: kind, Operation: "调整副本数"}
+	}
 	payload := map[string]int{"scale": replicas}
 	jsonPayload, _ := json.Marshal(payload)
+	url := c.projectURL(fmt.Sprintf("workloads/%s", workloadID(kind, namespace, workload)))
+	return c.doJSON(ctx, "PUT", url, jsonPayload, nil)
+}
 
-	resp, err := makeProjectRequest(environment, "PUT", fmt.Sprintf("workloads/deployment:%s:%s", namespace, service), jsonPayload)
-
-	if err != nil {
-		return false
+// Redeploy 重新部署工作负载, Job和CronJob不支持
+func (c *Client) Redeploy(ctx context.Context, kind string, namespace string, workload string) error {
+	switch NormalizeKind(kind) {
+	case KindCronJob, KindJob:
+		return &UnsupportedKindError{Kind: kind, Operation: "重新部署"}
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	url := c.projectURL(fmt.Sprintf("workloads/%s?action=redeploy", workloadID(kind, namespace, workload)))
+	return c.doJSON(ctx, "POST", url, nil, nil)
 }
 
-func Redeploy(environment Environment, namespace string, workload string) bool {
+// Start 启动工作负载: 可伸缩类型调整为1个副本, CronJob恢复调度
+func (c *Client) Start(ctx context.Context, kind string, namespace string, workload string) error {
+	if NormalizeKind(kind) == KindCronJob {
+		return c.SuspendCronJob(ctx, namespace, workload, false)
+	}
+	if !IsScalable(kind) {
+		return &UnsupportedKindError{Kind: kind, Operation: "打开"}
+	}
+	return c.Scale(ctx, kind, namespace, workload, 1)
+}
 
-	service := workload
-	if colonIndex := strings.LastIndex(workload, ":"); colonIndex > 0 {
-		service = workload[colonIndex+1:]
+// Stop 停止工作负载: 可伸缩类型调整为0个副本, CronJob暂停调度
+func (c *Client) Stop(ctx context.Context, kind string, namespace string, workload string) error {
+	if NormalizeKind(kind) == KindCronJob {
+		return c.SuspendCronJob(ctx, namespace, workload, true)
 	}
+	if !IsScalable(kind) {
+		return &UnsupportedKindError{Kind: kind, Operation: "关闭"}
+	}
+	return c.Scale(ctx, kind, namespace, workload, 0)
+}
 
-	resp, err := makeProjectRequest(environment, "POST", fmt.Sprintf("workloads/deployment:%s:%s?action=redeploy", namespace, service), nil)
+// SuspendCronJob 暂停或恢复CronJob的调度
+func (c *Client) SuspendCronJob(ctx context.Context, namespace string, workload string, suspend bool) error {
+	url := c.projectURL(fmt.Sprintf("workloads/%s", workloadID(KindCronJob, namespace, workload)))
+	// 读取完整对象后再写回, 避免覆盖cronJobConfig中的其它字段
+	var cronJob map[string]interface{}
+	if err := c.doJSON(ctx, "GET", url, nil, &cronJob); err != nil {
+		return err
+	}
+	cronJobConfig, _ := cronJob["cronJobConfig"].(map[string]interface{})
+	if cronJobConfig == nil {
+		cronJobConfig = make(map[string]interface{})
+	}
+	cronJobConfig["suspend"] = suspend
+	cronJob["cronJobConfig"] = cronJobConfig
+	jsonPayload, err := json.Marshal(cronJob)
 	if err != nil {
-		fmt.Println("失败")
-		return false
+		return fmt.Errorf("序列化CronJob失败: %w", err)
 	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode == http.StatusOK
+	return c.doJSON(ctx, "PUT", url, jsonPayload, nil)
 }
 
-func GetConfigMaps(environment Environment, confPath string) (string, error) {
-	resp, err := makeProjectRequest(environment, "GET", fmt.Sprintf("configMaps/%s", confPath), nil)
+// TriggerJob 立即执行一次任务: CronJob按jobTemplate创建Job, Job复制自身后重新创建
+func (c *Client) TriggerJob(ctx context.Context, kind string, namespace string, workload string) (string, error) {
+	kind = NormalizeKind(kind)
+	if kind != KindCronJob && kind != KindJob {
+		return "", &UnsupportedKindError{Kind: kind, Operation: "立即执行"}
+	}
+	source, err := c.GetWorkloadYaml(ctx, kind, namespace, workload)
+	if err != nil {
+		return "", err
+	}
+	var object map[string]interface{}
+	if err := yaml.Unmarshal([]byte(source), &object); err != nil {
+		return "", fmt.Errorf("解析%s失败: %w", workload, err)
+	}
+	name := fmt.Sprintf("%s-manual-%d", serviceName(workload), time.Now().Unix())
+	var spec map[string]interface{}
+	annotations := map[string]interface{}{}
+	if kind == KindCronJob {
+		spec, _ = nestedMap(object, "spec", "jobTemplate", "spec")
+		annotations["cronjob.kubernetes.io/instantiate"] = "manual"
+	} else {
+		spec, _ = nestedMap(object, "spec")
+		// 由控制器生成的selector和标签不能复用
+		delete(spec, "selector")
+		delete(spec, "manualSelector")
+		if labels, ok := nestedMap(spec, "template", "metadata", "labels"); ok {
+			for _, key := range jobControllerLabels {
+				delete(labels, key)
+			}
+		}
+	}
+	if spec == nil {
+		return "", fmt.Errorf("%s中没有可执行的任务模板", workload)
+	}
+	job := map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   namespace,
+			"annotations": annotations,
+		},
+		"spec": spec,
+	}
+	jobYaml, err := yaml.Marshal(job)
 	if err != nil {
-		log.Printf("Error fetching nginx config: %v", err)
+		return "", fmt.Errorf("生成Job失败: %w", err)
+	}
+	if _, err := c.ImportYaml(ctx, namespace, jobYaml); err != nil {
 		return "", err
 	}
+	return name, nil
+}
 
+// nestedMap 按路径获取嵌套的map
+func nestedMap(object map[string]interface{}, path ...string) (map[string]interface{}, bool) {
+	current := object
+	for _, key := range path {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// GetConfigMaps 获取nginx配置所在configMap中的default.conf
+func (c *Client) GetConfigMaps(ctx context.Context, confPath string) (string, error) {
 	var configMap struct {
 		Data struct {
 			DefaultConf string `json:"default.conf"`
 		} `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&configMap); err != nil {
-		log.Printf("Error decoding nginx config: %v", err)
+	if err := c.doJSON(ctx, "GET", c.projectURL(fmt.Sprintf("configMaps/%s", confPath)), nil, &configMap); err != nil {
 		return "", err
 	}
-	resp.Body.Close()
 	return configMap.Data.DefaultConf, nil
 }
 
-func GetWorkloadList(environment Environment) ([]WorkloadResp, error) {
-
-	resp, err := makeProjectRequest(environment, "GET", "workloads?limit=-1", nil)
-	if err != nil {
-		log.Printf("Error fetching workloads: %v", err)
-		return nil, err
-	}
-
+func (c *Client) GetWorkloadList(ctx context.Context) ([]WorkloadResp, error) {
 	var workloadsResponse struct {
 		Data []WorkloadResp `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&workloadsResponse); err != nil {
-		log.Printf("Error decoding workloads: %v", err)
+	if err := c.doJSON(ctx, "GET", c.projectURL("workloads?limit=-1"), nil, &workloadsResponse); err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 	return workloadsResponse.Data, nil
 }
 
-func GetNamespaceList(environment Environment) ([]NamespaceResp, error) {
-
-	resp, err := makeRequest(environment, "GET", "cluster/local/namespaces?limit=-1", nil, "")
-	if err != nil {
-		log.Printf("Error fetching namespace: %v", err)
-		return nil, err
-	}
-
-	var NamespaceResponse struct {
+// GetNamespaceList 获取当前项目所在集群的所有命名空间
+func (c *Client) GetNamespaceList(ctx context.Context) ([]NamespaceResp, error) {
+	var namespaceResponse struct {
 		Data []NamespaceResp `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&NamespaceResponse); err != nil {
-		log.Printf("Error decoding namespace: %v", err)
+	url := fmt.Sprintf("cluster/%s/namespaces?limit=-1", c.clusterID())
+	if err := c.doJSON(ctx, "GET", url, nil, &namespaceResponse); err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
-	return NamespaceResponse.Data, nil
+	return namespaceResponse.Data, nil
 }
 
-func GetPodList(environment Environment) ([]PodResp, error) {
-
-	resp, err := makeProjectRequest(environment, "GET", "pods?limit=-1", nil)
-	if err != nil {
-		log.Printf("Error fetching pods: %v", err)
+func (c *Client) GetPodList(ctx context.Context) ([]PodResp, error) {
+	var podsResponse struct {
+		Data []PodResp `json:"data"`
+	}
+	if err := c.doJSON(ctx, "GET", c.projectURL("pods?limit=-1"), nil, &podsResponse); err != nil {
 		return nil, err
 	}
+	return podsResponse.Data, nil
+}
 
+// GetWorkloadPods 实时获取某个工作负载下的pod列表
+func (c *Client) GetWorkloadPods(ctx context.Context, kind string, namespace string, workload string) ([]PodResp, error) {
 	var podsResponse struct {
 		Data []PodResp `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&podsResponse); err != nil {
-		log.Printf("Error decoding pods: %v", err)
+	url := c.projectURL(fmt.Sprintf("pods?workloadId=%s&limit=-1", neturl.QueryEscape(workloadID(kind, namespace, workload))))
+	if err := c.doJSON(ctx, "GET", url, nil, &podsResponse); err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 	return podsResponse.Data, nil
 }
 
-func GetDeploymentYaml(environment Environment, namespace string, workload string) (string, error) {
-	project := environment.Project
-	url := fmt.Sprintf("project/%s/workloads/deployment:%s:%s/yaml?export=true", project, namespace, workload)
-	response, err := makeRequest(environment, "GET", url, nil, "application/yaml")
+// GetWorkloadYaml 按类型导出工作负载的YAML
+func (c *Client) GetWorkloadYaml(ctx context.Context, kind string, namespace string, workload string) (string, error) {
+	url := c.projectURL(fmt.Sprintf("workloads/%s/yaml?export=true", workloadID(kind, namespace, workload)))
+	response, err := c.do(ctx, "GET", url, nil, "application/yaml")
 	if err != nil {
-		log.Printf("Error fetching workloads: %v", err)
 		return "", err
 	}
 	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		log.Printf("Error reading response body: %v", err)
-		return "", err
+		return "", &APIError{Method: "GET", URL: response.Request.URL.String(), StatusCode: response.StatusCode, Err: err}
 	}
 
 	return string(body), nil
 }
 
-func GetConfigMapList(environment Environment, namespace string) ([]configMaps.ConfigMap, error) {
-	resp, err := makeProjectRequest(environment, "GET", fmt.Sprintf("configMap?namespaceId=%s&limit=-1", namespace), nil)
-	if err != nil {
-		log.Printf("Error fetching configMap: %v", err)
-		return nil, err
-	}
-
+func (c *Client) GetConfigMapList(ctx context.Context, namespace string) ([]configMaps.ConfigMap, error) {
 	var configMapsResponse struct {
 		Data []configMaps.ConfigMap `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&configMapsResponse); err != nil {
-		log.Printf("Error decoding configMap: %v", err)
+	url := c.projectURL(fmt.Sprintf("configMap?namespaceId=%s&limit=-1", namespace))
+	if err := c.doJSON(ctx, "GET", url, nil, &configMapsResponse); err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 	return configMapsResponse.Data, nil
 }
 
-func ImportYaml(environment Environment, defaultNamespace string, yaml []byte) error {
+// ImportYaml 导入YAML到当前项目所在的集群, 资源未指定命名空间时使用defaultNamespace。
+// 返回Rancher报告的每个资源的导入状态
+func (c *Client) ImportYaml(ctx context.Context, defaultNamespace string, yaml []byte) ([]ImportedResource, error) {
 	payload := map[string]string{
 		"yaml":             string(yaml),
 		"defaultNamespace": defaultNamespace,
 	}
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshaling yaml payload: %v", err)
-		return err
+		return nil, fmt.Errorf("序列化yaml请求失败: %w", err)
 	}
-	response, err := makeRequest(environment, "POST", "clusters/local?action=importYaml", jsonPayload, "")
-	if err != nil {
-		log.Printf("Error importing yaml: %v", err)
-		return err
+	var output struct {
+		Message string `json:"message"`
 	}
-	defer response.Body.Close()
-	return nil
+	if err := c.doJSON(ctx, "POST", fmt.Sprintf("clusters/%s?action=importYaml", c.clusterID()), jsonPayload, &output); err != nil {
+		return nil, err
+	}
+	return ParseImportOutput(output.Message), nil
 }
 
-func GetServiceList(environment Environment) ([]ServiceResp, error) {
-	resp, err := makeProjectRequest(environment, "GET", "services?limit=-1", nil)
-	if err != nil {
-		log.Printf("Error fetching services: %v", err)
+// GetNamespace 获取当前项目所在集群中的命名空间, 不存在时返回nil
+func (c *Client) GetNamespace(ctx context.Context, name string) (*NamespaceResp, error) {
+	var namespace NamespaceResp
+	url := fmt.Sprintf("cluster/%s/namespaces/%s", c.clusterID(), neturl.PathEscape(name))
+	if err := c.doJSON(ctx, "GET", url, nil, &namespace); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
+	return &namespace, nil
+}
+
+// CreateNamespace 在当前项目中创建命名空间
+func (c *Client) CreateNamespace(ctx context.Context, namespace NamespaceResp) error {
+	namespace.ProjectId = c.environment.Project
+	payload := map[string]interface{}{
+		"type":        "namespace",
+		"name":        namespace.Name,
+		"projectId":   namespace.ProjectId,
+		"description": namespace.Description,
+		"labels":      namespace.Labels,
+	}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化命名空间失败: %w", err)
+	}
+	return c.doJSON(ctx, "POST", fmt.Sprintf("cluster/%s/namespaces", c.clusterID()), jsonPayload, nil)
+}
 
+func (c *Client) GetServiceList(ctx context.Context) ([]ServiceResp, error) {
 	var servicesResponse struct {
 		Data []ServiceResp `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&servicesResponse); err != nil {
-		log.Printf("Error decoding services: %v", err)
+	if err := c.doJSON(ctx, "GET", c.projectURL("services?limit=-1"), nil, &servicesResponse); err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 	return servicesResponse.Data, nil
 }
@@ -0,0 +1,194 @@
+package rancher
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 变更记录的类型
+const (
+	ChangeWorkloadAdded   = "workload_added"
+	ChangeWorkloadRemoved = "workload_removed"
+	ChangeImage           = "image_changed"
+	ChangeEnvironment     = "env_changed"
+	ChangeReplicas        = "replicas_changed"
+	ChangePodState        = "pod_state_changed"
+)
+
+// ChangeEvent 同步时发现的一次变更
+type ChangeEvent struct {
+	ID          uint      `gorm:"primaryKey"`
+	CreatedAt   time.Time `gorm:"index"` // 发现变更的同步时间
+	Environment string    `gorm:"size:20;index"`
+	Cluster     string    `gorm:"size:50"`
+	Namespace   string    `gorm:"size:63"`
+	Workload    string    `gorm:"size:100;index"` // 所属的工作负载, Pod的变更也记录所属工作负载
+	Kind        string    `gorm:"size:20"`        // 变更对象的类型, 如deployment、Pod
+	Name        string    `gorm:"size:100"`       // 变更对象的名称
+	Type        string    `gorm:"size:30"`
+	Field       string    `gorm:"size:255"` // 镜像变化时为容器名称, 环境变量变化时为 容器/变量名
+	OldValue    string    `gorm:"type:text"`
+	NewValue    string    `gorm:"type:text"`
+}
+
+func (ChangeEvent) TableName() string {
+	return "change_event"
+}
+
+// ChangeEventFilter 查询变更记录的条件, 为空的条件不限制
+type ChangeEventFilter struct {
+	Environment string
+	Namespace   string
+	Workload    string
+	Limit       int // 最多返回的记录数, 0表示不限制
+}
+
+// GetChangeEvents 按条件查询变更记录, 最新的在前, 同一次同步的记录按生成顺序排列
+func (dm *DatabaseManager) GetChangeEvents(filter ChangeEventFilter) ([]ChangeEvent, error) {
+	query := dm.db.Model(&ChangeEvent{})
+	if filter.Environment != "" {
+		query = query.Where("environment = ?", filter.Environment)
+	}
+	if filter.Namespace != "" {
+		query = query.Where("namespace = ?", filter.Namespace)
+	}
+	if filter.Workload != "" {
+		query = query.Where("workload = ?", filter.Workload)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var events []ChangeEvent
+	result := query.Order("created_at DESC, id").Find(&events)
+	return events, result.Error
+}
+
+// insertChangeEvents 在同步的事务中保存变更记录
+func insertChangeEvents(tx *gorm.DB, events []ChangeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(events, 100).Error
+}
+
+// workloadChangeEvents 根据工作负载的差异生成变更记录。访问路径、节点亲和性等其他字段的变化不记录
+func workloadChangeEvents(diff SyncDiff[Workload], now time.Time) []ChangeEvent {
+	var events []ChangeEvent
+	newEvent := func(w Workload, changeType string) ChangeEvent {
+		return ChangeEvent{CreatedAt: now, Environment: w.Environment, Cluster: w.Cluster, Namespace: w.Namespace,
+			Workload: w.Name, Kind: w.Kind, Name: w.Name, Type: changeType}
+	}
+	for _, w := range diff.Added {
+		event := newEvent(w, ChangeWorkloadAdded)
+		event.NewValue = w.Image
+		events = append(events, event)
+	}
+	for _, w := range diff.Removed {
+		event := newEvent(w, ChangeWorkloadRemoved)
+		event.OldValue = w.Image
+		events = append(events, event)
+	}
+	for _, change := range diff.Changed {
+		oldContainers := containersByName(change.Old)
+		newContainers := containersByName(change.New)
+		for _, name := range containerNames(oldContainers, newContainers) {
+			oldContainer, newContainer := oldContainers[name], newContainers[name]
+			if oldContainer.Image != newContainer.Image {
+				event := newEvent(change.New, ChangeImage)
+				event.Field = name
+				event.OldValue, event.NewValue = oldContainer.Image, newContainer.Image
+				events = append(events, event)
+			}
+			// 无法解析的环境变量视为空
+			oldEnv, _ := parseContainerEnvironment(oldContainer.Environment)
+			newEnv, _ := parseContainerEnvironment(newContainer.Environment)
+			for _, key := range sortedKeys(oldEnv, newEnv) {
+				oldValue, oldExists := oldEnv[key]
+				newValue, newExists := newEnv[key]
+				if oldExists == newExists && oldValue == newValue {
+					continue
+				}
+				event := newEvent(change.New, ChangeEnvironment)
+				event.Field = name + "/" + key
+				event.OldValue, event.NewValue = oldValue, newValue
+				events = append(events, event)
+			}
+		}
+		oldReplicas, newReplicas := replicasText(change.Old.Replicas), replicasText(change.New.Replicas)
+		if oldReplicas != newReplicas {
+			event := newEvent(change.New, ChangeReplicas)
+			event.OldValue, event.NewValue = oldReplicas, newReplicas
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// podChangeEvents 记录Pod状态的变化, 新建和删除的Pod不记录。所属工作负载取workloadId的最后一部分,
+// 如 deployment:big-data:api 记录为 api, 与工作负载的变更记录相同
+func podChangeEvents(diff SyncDiff[Pod], now time.Time) []ChangeEvent {
+	var events []ChangeEvent
+	for _, change := range diff.Changed {
+		if change.Old.State == change.New.State {
+			continue
+		}
+		pod := change.New
+		parts := strings.Split(pod.WorkloadId, ":")
+		events = append(events, ChangeEvent{CreatedAt: now, Environment: pod.Environment, Cluster: pod.Cluster, Namespace: pod.NamespaceId,
+			Workload: parts[len(parts)-1], Kind: "Pod", Name: pod.Name, Type: ChangePodState, OldValue: change.Old.State, NewValue: pod.State})
+	}
+	return events
+}
+
+// containersByName 按名称索引工作负载的容器, 没有容器数据时使用工作负载的镜像和环境变量作为主容器
+func containersByName(w Workload) map[string]WorkloadContainer {
+	containers := map[string]WorkloadContainer{}
+	if len(w.Containers) == 0 {
+		containers[w.Name] = WorkloadContainer{Name: w.Name, Image: w.Image, Environment: w.ContainerEnvironment, Role: ContainerMain}
+	}
+	for _, container := range w.Containers {
+		containers[container.Name] = container
+	}
+	return containers
+}
+
+// containerNames 两组容器的所有名称, 按名称排序
+func containerNames(a, b map[string]WorkloadContainer) []string {
+	var names []string
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, exists := a[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys 两组环境变量的所有键, 按名称排序
+func sortedKeys(a, b map[string]string) []string {
+	var keys []string
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, exists := a[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func replicasText(replicas *int) string {
+	if replicas == nil {
+		return ""
+	}
+	return strconv.Itoa(*replicas)
+}
@@ -0,0 +1,43 @@
+package rancher
+
+import (
+	"testing"
+)
+
+// TestPodChangeEvents Pod状态的变化记录在所属工作负载的名称下, 可以与工作负载的变更一起查询
+func TestPodChangeEvents(t *testing.T) {
+	dm := newTestDatabase(t)
+	pod := Pod{Environment: "dev", ProjectId: "c-1:p-1", Cluster: "c-1", NamespaceId: "app",
+		WorkloadId: "deployment:app:api", Name: "api-7d9f-x2k", State: "running"}
+	other := Pod{Environment: "dev", ProjectId: "c-1:p-1", Cluster: "c-1", NamespaceId: "app",
+		WorkloadId: "deployment:app:web", Name: "web-5c4b-q8z", State: "running"}
+	if _, err := dm.SyncPods("dev", []Pod{pod, other}); err != nil {
+		t.Fatal(err)
+	}
+	pod.State = "error"
+	pod.RestartCount = 3
+	other.RestartCount = 1 // 状态未变化, 不记录
+	diff, err := dm.SyncPods("dev", []Pod{pod, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changed) != 2 {
+		t.Errorf("Pod的差异 %s", diff.Summary())
+	}
+
+	events, err := dm.GetChangeEvents(ChangeEventFilter{Environment: "dev", Workload: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("api 的变更记录 %+v", events)
+	}
+	event := events[0]
+	if event.Type != ChangePodState || event.Kind != "Pod" || event.Name != pod.Name || event.Namespace != "app" ||
+		event.OldValue != "running" || event.NewValue != "error" {
+		t.Errorf("变更记录 %+v", event)
+	}
+	if all, _ := dm.GetChangeEvents(ChangeEventFilter{Environment: "dev"}); len(all) != 1 {
+		t.Errorf("全部变更记录 %+v", all)
+	}
+}
@@ -2,30 +2,77 @@ package rancher
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 // Workload 工作负载模型
 type Workload struct {
-	ID                   uint   `gorm:"primaryKey"`
-	Environment          string `gorm:"size:20"`
-	ProjectId            string `gorm:"size:20"`
-	Namespace            string `gorm:"size:50"`
-	Name                 string `gorm:"size:30"`
-	Image                string `gorm:"size:100"`
-	ImagePullPolicy      string `gorm:"size:20"`
-	ContainerEnvironment string `gorm:"size:255"`
-	AccessPath           string `gorm:"size:500"`
+	ID                   uint                `gorm:"primaryKey"`
+	Environment          string              `gorm:"size:20"`
+	ProjectId            string              `gorm:"size:20"`
+	Cluster              string              `gorm:"size:50"` // 集群ID, 旧格式的项目ID为local
+	Namespace            string              `gorm:"size:63"`
+	Name                 string              `gorm:"size:100"`
+	Kind                 string              `gorm:"size:20"`
+	Image                string              `gorm:"size:255"`
+	ImagePullPolicy      string              `gorm:"size:20"`
+	ContainerEnvironment string              `gorm:"type:text"`
+	AccessPath           string              `gorm:"size:500"`
+	Replicas             *int                // 副本数, 没有副本数的类型为空
+	NodeAffinity         string              `gorm:"size:255"` // 必需的节点条件, JSON数组, 如 ["role=node"]
+	Containers           []WorkloadContainer `gorm:"foreignKey:WorkloadID"`
 }
 
 func (Workload) TableName() string {
 	return "workload"
 }
 
+// Images 返回工作负载所有容器的镜像(去重, 按容器顺序), 没有容器数据时返回Image
+func (w Workload) Images() []string {
+	var images []string
+	seen := map[string]bool{}
+	for _, container := range w.Containers {
+		if container.Image != "" && !seen[container.Image] {
+			seen[container.Image] = true
+			images = append(images, container.Image)
+		}
+	}
+	if len(images) == 0 && w.Image != "" {
+		images = append(images, w.Image)
+	}
+	return images
+}
+
+// 容器在工作负载中的角色
+const (
+	ContainerMain    = "main"    // 主容器, 工作负载的Image取自该容器
+	ContainerSidecar = "sidecar" // 主容器之外的其他容器
+	ContainerInit    = "init"    // init容器
+)
+
+// WorkloadContainer 工作负载中的一个容器, 包括init容器和sidecar
+type WorkloadContainer struct {
+	ID              uint   `gorm:"primaryKey"`
+	WorkloadID      uint   `gorm:"index"`
+	Name            string `gorm:"size:50"`
+	Image           string `gorm:"size:200"`
+	ImagePullPolicy string `gorm:"size:20"`
+	Environment     string `gorm:"type:text"` // 环境变量, JSON对象
+	Ports           string `gorm:"size:255"`  // 端口, 逗号分隔, 如 http:8080/TCP
+	Role            string `gorm:"size:10"`   // main、sidecar 或 init
+}
+
+func (WorkloadContainer) TableName() string {
+	return "workload_container"
+}
+
 // Config 配置模型
 type Config struct {
 	ID      uint   `gorm:"primaryKey"`
@@ -39,10 +86,11 @@ func (Config) TableName() string {
 // Namespace 命名空间模型
 type Namespace struct {
 	ID          uint   `gorm:"primaryKey"`
-	Name        string `gorm:"size:30"`
-	Project     string `gorm:"size:30"`
+	Name        string `gorm:"size:63"`
+	Project     string `gorm:"size:50"`
+	Cluster     string `gorm:"size:50"`
 	Environment string `gorm:"size:20"`
-	Description string `gorm:"size:30"`
+	Description string `gorm:"size:255"`
 }
 
 func (Namespace) TableName() string {
@@ -51,12 +99,17 @@ func (Namespace) TableName() string {
 
 // Pod 模型
 type Pod struct {
-	ID          uint   `gorm:"primaryKey"`
-	Environment string `gorm:"size:20"`
-	ProjectId   string `gorm:"size:20"`
-	NamespaceId string `gorm:"size:20"`
-	WorkloadId  string `gorm:"size:80"`
-	State       string `gorm:"size:10"`
+	ID           uint   `gorm:"primaryKey"`
+	Environment  string `gorm:"size:20"`
+	ProjectId    string `gorm:"size:20"`
+	Cluster      string `gorm:"size:50"`
+	NamespaceId  string `gorm:"size:63"`
+	WorkloadId   string `gorm:"size:100"`
+	Name         string `gorm:"size:100"`
+	NodeId       string `gorm:"size:50"`
+	Containers   string `gorm:"size:255"`
+	RestartCount int
+	State        string `gorm:"size:10"`
 }
 
 func (Pod) TableName() string {
@@ -67,9 +120,9 @@ func (Pod) TableName() string {
 type UploadConfig struct {
 	ID     uint   `gorm:"primaryKey"`
 	Dir    string `gorm:"size:100"`
-	Script string `gorm:"size:30"`
-	Jar    string `gorm:"size:50"`
-	Image  string `gorm:"size:50"`
+	Script string `gorm:"size:100"`
+	Jar    string `gorm:"size:200"`
+	Image  string `gorm:"size:200"`
 }
 
 func (UploadConfig) TableName() string {
@@ -81,9 +134,10 @@ type Service struct {
 	ID           uint   `gorm:"primaryKey"`
 	Environment  string `gorm:"size:20"`
 	ProjectId    string `gorm:"size:20"`
-	NamespaceId  string `gorm:"size:20"`
-	Name         string `gorm:"size:20"`
-	WorkloadId   string `gorm:"size:40"`
+	Cluster      string `gorm:"size:50"`
+	NamespaceId  string `gorm:"size:63"`
+	Name         string `gorm:"size:100"`
+	WorkloadId   string `gorm:"size:100"`
 	Kind         string `gorm:"size:10"`
 	PortName     string `gorm:"size:50"`
 	PortProtocol string `gorm:"size:10"`
@@ -125,26 +179,45 @@ func NewDatabaseManager(dbFile string) (*DatabaseManager, error) {
 		dbFile = filepath.Join(appDir, "app.db")
 	}
 
-	fmt.Printf("使用数据库文件: %s\n", dbFile)
+	fmt.Fprintf(os.Stderr, "使用数据库文件: %s\n", dbFile)
 
-	db, err := gorm.Open(sqlite.Open(dbFile), &gorm.Config{})
+	// 日志输出到标准错误, 避免混入命令行模式的输出
+	db, err := gorm.Open(sqlite.Open(dbFile), &gorm.Config{
+		Logger: logger.New(log.New(os.Stderr, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  true,
+		}),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %v", err)
 	}
+	// 多个环境并发同步时, SQLite的多个连接同时写入会返回database is locked, 只使用一个连接使写入依次执行
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
 
 	dm := &DatabaseManager{
 		db:     db,
 		dbFile: dbFile,
 	}
 
-	// 自动迁移数据库结构
-	if err := dm.initDatabase(); err != nil {
+	// 执行未完成的数据库迁移
+	if err := dm.migrate(); err != nil {
 		return nil, err
 	}
 
 	return dm, nil
 }
 
+// DataDir 返回数据库文件所在目录, 密钥文件等应用数据也保存在这里
+func (dm *DatabaseManager) DataDir() string {
+	return filepath.Dir(dm.dbFile)
+}
+
 // Close 关闭数据库连接
 func (dm *DatabaseManager) Close() error {
 	sqlDB, err := dm.db.DB()
@@ -154,15 +227,10 @@ func (dm *DatabaseManager) Close() error {
 	return sqlDB.Close()
 }
 
-// initDatabase 初始化数据库，创建必要的表
-func (dm *DatabaseManager) initDatabase() error {
-	return dm.db.AutoMigrate(&Workload{}, &Config{}, &Namespace{}, &Pod{}, &UploadConfig{}, &Service{})
-}
-
 // GetWorkloadDetailsByEnvNamespace 根据环境和命名空间获取工作负载详细信息
 func (dm *DatabaseManager) GetWorkloadDetailsByEnvNamespace(environment, namespace string) ([]Workload, error) {
 	var workloads []Workload
-	result := dm.db.Where("environment = ? AND namespace = ?", environment, namespace).Find(&workloads)
+	result := dm.db.Preload("Containers").Where("environment = ? AND namespace = ?", environment, namespace).Find(&workloads)
 	return workloads, result.Error
 }
 
@@ -182,19 +250,37 @@ func (dm *DatabaseManager) GetWorkloadCountByEnvironment(environment string) (in
 	return count, result.Error
 }
 
-// DeleteWorkloadByEnvNamespace 根据环境和命名空间删除工作负载
+// DeleteWorkloadByEnvNamespace 根据环境和命名空间删除工作负载及其容器
 func (dm *DatabaseManager) DeleteWorkloadByEnvNamespace(environment, namespace string) (int64, error) {
-	result := dm.db.Where("environment = ? AND namespace = ?", environment, namespace).Delete(&Workload{})
-	return result.RowsAffected, result.Error
+	var affected int64
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		workloads := tx.Model(&Workload{}).Select("id").Where("environment = ? AND namespace = ?", environment, namespace)
+		if err := tx.Where("workload_id IN (?)", workloads).Delete(&WorkloadContainer{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("environment = ? AND namespace = ?", environment, namespace).Delete(&Workload{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
 }
 
-// DeleteWorkloadByEnv 根据环境删除工作负载
+// DeleteWorkloadByEnv 根据环境删除工作负载及其容器
 func (dm *DatabaseManager) DeleteWorkloadByEnv(environment string) (int64, error) {
-	result := dm.db.Where("environment = ?", environment).Delete(&Workload{})
-	return result.RowsAffected, result.Error
+	var affected int64
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		workloads := tx.Model(&Workload{}).Select("id").Where("environment = ?", environment)
+		if err := tx.Where("workload_id IN (?)", workloads).Delete(&WorkloadContainer{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("environment = ?", environment).Delete(&Workload{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
 }
 
-// InsertWorkloads 批量插入工作负载
+// InsertWorkloads 批量插入工作负载, 同时插入其容器
 func (dm *DatabaseManager) InsertWorkloads(workloads []Workload) error {
 	return dm.db.Transaction(func(tx *gorm.DB) error {
 		for _, workload := range workloads {
@@ -257,7 +343,7 @@ func (dm *DatabaseManager) UpdateWorkload(workload *Workload) error {
 // GetWorkloadByID 根据ID获取工作负载
 func (dm *DatabaseManager) GetWorkloadByID(id uint) (*Workload, error) {
 	var workload Workload
-	result := dm.db.First(&workload, id)
+	result := dm.db.Preload("Containers").First(&workload, id)
 	if result.Error == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -267,7 +353,14 @@ func (dm *DatabaseManager) GetWorkloadByID(id uint) (*Workload, error) {
 // GetWorkloadsByNamespace 根据命��空间获取工作负载列表
 func (dm *DatabaseManager) GetWorkloadsByNamespace(namespace string) ([]Workload, error) {
 	var workloads []Workload
-	result := dm.db.Where("namespace = ?", namespace).Find(&workloads)
+	result := dm.db.Preload("Containers").Where("namespace = ?", namespace).Find(&workloads)
+	return workloads, result.Error
+}
+
+// GetWorkloadsByProjectNamespace 根据环境、项目和命名空间查询workload列表
+func (dm *DatabaseManager) GetWorkloadsByProjectNamespace(environment, projectId, namespace string) ([]Workload, error) {
+	var workloads []Workload
+	result := dm.db.Preload("Containers").Where("environment = ? AND project_id = ? AND namespace = ?", environment, projectId, namespace).Find(&workloads)
 	return workloads, result.Error
 }
 
@@ -296,6 +389,13 @@ func (dm *DatabaseManager) GetAllNamespacesDetail() ([]Namespace, error) {
 	return namespaces, result.Error
 }
 
+// GetNamespacesByEnvironment 根据环境获取命名空间列表
+func (dm *DatabaseManager) GetNamespacesByEnvironment(environment string) ([]Namespace, error) {
+	var namespaces []Namespace
+	result := dm.db.Where("environment = ?", environment).Find(&namespaces)
+	return namespaces, result.Error
+}
+
 // DeletePodByEnvironment 根据环境名称删除pod数据
 func (dm *DatabaseManager) DeletePodByEnvironment(environment string) (int64, error) {
 	result := dm.db.Where("environment = ?", environment).Delete(&Pod{})
@@ -338,6 +438,23 @@ func (dm *DatabaseManager) GetPodsByEnvNamespaceWorkload(environment string, nam
 	return pods, result.Error
 }
 
+// GetPodsByProjectNamespace 根据环境、项目和命名空间查询pod列表
+func (dm *DatabaseManager) GetPodsByProjectNamespace(environment string, projectId string, namespaceId string) ([]Pod, error) {
+	var pods []Pod
+	result := dm.db.Where("environment = ? AND project_id = ? AND namespace_id = ?", environment, projectId, namespaceId).
+		Find(&pods)
+	return pods, result.Error
+}
+
+// GetPodsByProjectNamespaceWorkload 根据环境、项目、命名空间和workload查询pod列表
+func (dm *DatabaseManager) GetPodsByProjectNamespaceWorkload(environment string, projectId string, namespaceId string, workload string) ([]Pod, error) {
+	var pods []Pod
+	result := dm.db.Where("environment = ? AND project_id = ? AND namespace_id = ? AND workload_id LIKE ?",
+		environment, projectId, namespaceId, "%"+workload+"%").
+		Find(&pods)
+	return pods, result.Error
+}
+
 // ClearAllData 清除namespace,pod,workload的数据
 func (dm *DatabaseManager) ClearAllData() error {
 	return dm.db.Transaction(func(tx *gorm.DB) error {
@@ -351,7 +468,14 @@ func (dm *DatabaseManager) ClearAllData() error {
 			return err
 		}
 
-		// 清除workload数据
+		// 清除变更记录
+		if err := tx.Exec("DELETE FROM change_event").Error; err != nil {
+			return err
+		}
+		// 清除workload及其容器数据
+		if err := tx.Exec("DELETE FROM workload_container").Error; err != nil {
+			return err
+		}
 		if err := tx.Exec("DELETE FROM workload").Error; err != nil {
 			return err
 		}
@@ -0,0 +1,147 @@
+package rancher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError 表示一次Rancher API调用失败的详细信息
+type APIError struct {
+	Method     string // 请求方法
+	URL        string // 请求地址
+	StatusCode int    // HTTP状态码, 网络错误时为0
+	Code       string // Rancher返回的错误码, 如 NotFound、Conflict
+	Message    string // Rancher返回的错误信息
+	Err        error  // 底层错误(网络、解码等)
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s", e.Method, e.URL))
+	if e.StatusCode != 0 {
+		sb.WriteString(fmt.Sprintf(": HTTP %d", e.StatusCode))
+	}
+	if e.Code != "" {
+		sb.WriteString(fmt.Sprintf(" %s", e.Code))
+	}
+	if e.Message != "" {
+		sb.WriteString(fmt.Sprintf(": %s", e.Message))
+	}
+	if e.Err != nil {
+		sb.WriteString(fmt.Sprintf(": %v", e.Err))
+	}
+	return sb.String()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError 根据响应构建APIError, 尽量解析Rancher的错误体
+func newAPIError(method, url string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Method:     method,
+		URL:        url,
+		StatusCode: resp.StatusCode,
+	}
+	var errorBody struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err == nil {
+		apiErr.Code = errorBody.Code
+		apiErr.Message = errorBody.Message
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// statusOf 获取错误对应的HTTP状态码, 非APIError返回0
+func statusOf(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsUnauthorized 是否为认证失败(令牌无效或已过期)
+func IsUnauthorized(err error) bool {
+	return statusOf(err) == http.StatusUnauthorized
+}
+
+// IsForbidden 是否为权限不足
+func IsForbidden(err error) bool {
+	return statusOf(err) == http.StatusForbidden
+}
+
+// IsNotFound 是否为资源不存在
+func IsNotFound(err error) bool {
+	return statusOf(err) == http.StatusNotFound
+}
+
+// IsConflict 是否为资源冲突
+func IsConflict(err error) bool {
+	return statusOf(err) == http.StatusConflict
+}
+
+// IsCanceled 是否为用户主动取消
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// IsTimeout 是否为请求超时
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// ErrorReason 将错误转换为适合在界面上展示的原因描述
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	if IsCanceled(err) {
+		return "已取消"
+	}
+	if IsTimeout(err) {
+		return "请求超时"
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	var reason string
+	switch {
+	case apiErr.StatusCode == 0:
+		reason = "网络错误"
+	case apiErr.StatusCode == http.StatusUnauthorized:
+		reason = "认证失败, 令牌可能已过期"
+	case apiErr.StatusCode == http.StatusForbidden:
+		reason = "权限不足"
+	case apiErr.StatusCode == http.StatusNotFound:
+		reason = "资源不存在"
+	case apiErr.StatusCode == http.StatusConflict:
+		reason = "资源冲突"
+	case apiErr.StatusCode >= 500:
+		reason = "Rancher服务端错误"
+	default:
+		reason = "请求失败"
+	}
+	detail := apiErr.Message
+	if detail == "" && apiErr.Err != nil {
+		detail = apiErr.Err.Error()
+	}
+	if apiErr.StatusCode != 0 {
+		reason = fmt.Sprintf("%s(HTTP %d)", reason, apiErr.StatusCode)
+	}
+	if detail != "" {
+		reason = fmt.Sprintf("%s: %s", reason, detail)
+	}
+	return reason
+}
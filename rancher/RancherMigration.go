@@ -0,0 +1,243 @@
+package rancher
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration 已执行的数据库迁移
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:100"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migration 一次数据库结构或数据的变更, 只向前执行, 已发布的迁移不能修改
+type migration struct {
+	version int
+	name    string
+	migrate func(tx *gorm.DB) error
+}
+
+// migrations 按版本排列的所有迁移。修改表结构、重命名字段或补充数据时在末尾追加新的迁移,
+// 不要再直接依赖AutoMigrate。迁移中的表结构使用固定的SQL, 不引用模型, 之后修改模型不会改变已发布的迁移
+var migrations = []migration{
+	{1, "创建表结构", func(tx *gorm.DB) error {
+		// 旧版本启动时执行AutoMigrate, 已有的数据库在这里补齐缺少的表和字段
+		return createTables(tx, schemaV1, []string{
+			"CREATE INDEX IF NOT EXISTS `idx_workload_container_workload_id` ON `workload_container`(`workload_id`)",
+		})
+	}},
+	{2, "为旧数据补充主容器", backfillMainContainers},
+	{3, "增加集群字段", addClusterColumns},
+	{4, "增加变更记录", func(tx *gorm.DB) error {
+		return createTables(tx, []tableSchema{{"change_event", []string{
+			"`created_at` datetime", "`environment` text", "`cluster` text", "`namespace` text", "`workload` text",
+			"`kind` text", "`name` text", "`type` text", "`field` text", "`old_value` text", "`new_value` text",
+		}, nil}}, []string{
+			"CREATE INDEX IF NOT EXISTS `idx_change_event_created_at` ON `change_event`(`created_at`)",
+			"CREATE INDEX IF NOT EXISTS `idx_change_event_environment` ON `change_event`(`environment`)",
+			"CREATE INDEX IF NOT EXISTS `idx_change_event_workload` ON `change_event`(`workload`)",
+		})
+	}},
+	{5, "为旧数据补充工作负载类型", backfillWorkloadKinds},
+}
+
+// tableSchema 迁移中一张表的结构, 每张表都有自增的id主键
+type tableSchema struct {
+	name        string
+	columns     []string // 字段定义, 如 "`name` text"
+	constraints []string // 建表时的约束
+}
+
+// schemaV1 引入迁移之前的版本用AutoMigrate创建的表结构
+var schemaV1 = []tableSchema{
+	{"workload", []string{
+		"`environment` text", "`project_id` text", "`namespace` text", "`name` text", "`kind` text", "`image` text",
+		"`image_pull_policy` text", "`container_environment` text", "`access_path` text", "`replicas` integer", "`node_affinity` text",
+	}, nil},
+	{"workload_container", []string{
+		"`workload_id` integer", "`name` text", "`image` text", "`image_pull_policy` text", "`environment` text", "`ports` text", "`role` text",
+	}, []string{"CONSTRAINT `fk_workload_containers` FOREIGN KEY (`workload_id`) REFERENCES `workload`(`id`)"}},
+	{"config", []string{"`content` text"}, nil},
+	{"namespace", []string{"`name` text", "`project` text", "`environment` text", "`description` text"}, nil},
+	{"pod", []string{
+		"`environment` text", "`project_id` text", "`namespace_id` text", "`workload_id` text", "`name` text", "`node_id` text",
+		"`containers` text", "`restart_count` integer", "`state` text",
+	}, nil},
+	{"upload_config", []string{"`dir` text", "`script` text", "`jar` text", "`image` text"}, nil},
+	{"service", []string{
+		"`environment` text", "`project_id` text", "`namespace_id` text", "`name` text", "`workload_id` text", "`kind` text",
+		"`port_name` text", "`port_protocol` text", "`port` integer", "`target_port` integer", "`node_port` integer",
+	}, nil},
+}
+
+// createTables 创建不存在的表; 表已存在时只补充缺少的字段, 不修改已有字段。然后创建索引
+func createTables(tx *gorm.DB, tables []tableSchema, indexes []string) error {
+	for _, table := range tables {
+		if !tx.Migrator().HasTable(table.name) {
+			definitions := append([]string{"`id` integer PRIMARY KEY AUTOINCREMENT"}, table.columns...)
+			definitions = append(definitions, table.constraints...)
+			if err := tx.Exec(fmt.Sprintf("CREATE TABLE `%s` (%s)", table.name, strings.Join(definitions, ","))).Error; err != nil {
+				return fmt.Errorf("创建表%s失败: %w", table.name, err)
+			}
+			continue
+		}
+		for _, column := range table.columns {
+			if err := addColumn(tx, table.name, column); err != nil {
+				return err
+			}
+		}
+	}
+	for _, index := range indexes {
+		if err := tx.Exec(index).Error; err != nil {
+			return fmt.Errorf("创建索引失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// addColumn 字段不存在时增加字段, definition如 "`cluster` text"
+func addColumn(tx *gorm.DB, table string, definition string) error {
+	name := strings.Trim(strings.Fields(definition)[0], "`")
+	if tx.Migrator().HasColumn(table, name) {
+		return nil
+	}
+	if err := tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", table, definition)).Error; err != nil {
+		return fmt.Errorf("%s增加字段%s失败: %w", table, name, err)
+	}
+	return nil
+}
+
+// backfillMainContainers 保存容器之前同步的工作负载只有主容器的镜像和环境变量, 按这些字段补充一个主容器
+func backfillMainContainers(tx *gorm.DB) error {
+	return tx.Exec(`INSERT INTO workload_container (workload_id, name, image, image_pull_policy, environment, ports, role)
+		SELECT id, name, image, image_pull_policy, container_environment, '', 'main'
+		FROM workload
+		WHERE image <> '' AND id NOT IN (SELECT workload_id FROM workload_container)`).Error
+}
+
+// addClusterColumns 增量同步按集群区分不同集群中的同名资源, 旧数据的集群从项目ID中解析
+func addClusterColumns(tx *gorm.DB) error {
+	for _, table := range []struct {
+		name    string
+		project string
+	}{
+		{"namespace", "project"},
+		{"workload", "project_id"},
+		{"pod", "project_id"},
+		{"service", "project_id"},
+	} {
+		if err := addColumn(tx, table.name, "`cluster` text"); err != nil {
+			return err
+		}
+		// 与ClusterOfProject相同: 项目ID中冒号之前的部分, 没有冒号时为local
+		err := tx.Exec(fmt.Sprintf(`UPDATE %[1]s SET cluster = CASE WHEN instr(%[2]s, ':') > 1
+			THEN substr(%[2]s, 1, instr(%[2]s, ':') - 1) ELSE 'local' END
+			WHERE cluster IS NULL OR cluster = ''`, table.name, table.project)).Error
+		if err != nil {
+			return fmt.Errorf("补充%s的集群失败: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// backfillWorkloadKinds 增加类型字段之前只同步Deployment, 旧数据的类型为空, 补充为deployment。
+// 同步时按类型识别同一工作负载, 类型为空时会被当作删除后重新新增
+func backfillWorkloadKinds(tx *gorm.DB) error {
+	return tx.Exec("UPDATE workload SET kind = 'deployment' WHERE kind IS NULL OR kind = ''").Error
+}
+
+// SchemaVersion 返回数据库当前的迁移版本, 没有执行过迁移时为0
+func (dm *DatabaseManager) SchemaVersion() (int, error) {
+	if !dm.db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var version int
+	result := dm.db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version)
+	return version, result.Error
+}
+
+// migrate 依次执行未完成的迁移, 每个迁移在单独的事务中执行并记录到schema_migrations。
+// 执行前先备份数据库文件, 迁移失败时可以用备份恢复
+func (dm *DatabaseManager) migrate() error {
+	version, err := dm.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	latest := migrations[len(migrations)-1].version
+	if version > latest {
+		return fmt.Errorf("数据库版本%d高于程序支持的版本%d, 请使用新版本的程序", version, latest)
+	}
+	if version == latest {
+		return nil
+	}
+
+	backup, err := dm.backup(version)
+	if err != nil {
+		return fmt.Errorf("迁移前备份数据库失败: %w", err)
+	}
+	err = dm.db.Exec("CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` integer,`name` text,`applied_at` datetime,PRIMARY KEY (`version`))").Error
+	if err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "执行数据库迁移 %d: %s\n", m.version, m.name)
+		err := dm.db.Transaction(func(tx *gorm.DB) error {
+			if err := m.migrate(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			if backup != "" {
+				return fmt.Errorf("数据库迁移%d(%s)失败, 迁移前的备份: %s: %w", m.version, m.name, backup, err)
+			}
+			return fmt.Errorf("数据库迁移%d(%s)失败: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// backup 将数据库文件复制到同一目录, 文件名包含迁移前的版本和时间。新建的空数据库不需要备份, 返回空路径
+func (dm *DatabaseManager) backup(version int) (string, error) {
+	info, err := os.Stat(dm.dbFile)
+	if os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	source, err := os.Open(dm.dbFile)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	backup := fmt.Sprintf("%s.v%d-%s.bak", dm.dbFile, version, time.Now().Format("20060102150405"))
+	target, err := os.Create(backup)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return "", err
+	}
+	if err := target.Close(); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "已备份数据库: %s\n", backup)
+	return backup, nil
+}
@@ -0,0 +1,128 @@
+package rancher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+// newTestDatabase 打开一个内存数据库并执行所有迁移, 测试结束时关闭
+func newTestDatabase(t *testing.T) *DatabaseManager {
+	t.Helper()
+	dm, err := NewDatabaseManager(":memory:")
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() { dm.Close() })
+	return dm
+}
+
+func latestVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// TestMigrationSchemaMatchesModels 迁移创建的表结构需要包含模型的所有字段
+func TestMigrationSchemaMatchesModels(t *testing.T) {
+	dm := newTestDatabase(t)
+	version, err := dm.SchemaVersion()
+	if err != nil || version != latestVersion() {
+		t.Fatalf("数据库版本 %d, 错误 %v, 期望 %d", version, err, latestVersion())
+	}
+	for _, model := range []interface{}{&Workload{}, &WorkloadContainer{}, &Config{}, &Namespace{}, &Pod{}, &UploadConfig{}, &Service{}, &ChangeEvent{}} {
+		parsed, err := schema.Parse(model, &sync.Map{}, dm.db.NamingStrategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range parsed.Fields {
+			if field.DBName != "" && !dm.db.Migrator().HasColumn(parsed.Table, field.DBName) {
+				t.Errorf("表%s缺少字段%s", parsed.Table, field.DBName)
+			}
+		}
+	}
+}
+
+// TestMigrateLegacyDatabase 引入迁移之前的数据库: 补齐字段和表, 补充主容器、集群和类型, 并在迁移前备份
+func TestMigrateLegacyDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "app.db")
+	legacy, err := NewDatabaseManager(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 还原为最早版本的表结构: 没有迁移记录、容器表、类型和集群字段
+	for _, statement := range []string{
+		"DROP TABLE schema_migrations",
+		"DROP TABLE workload_container",
+		"DROP TABLE change_event",
+		"DROP TABLE workload",
+		"DROP TABLE namespace",
+		"CREATE TABLE `workload` (`id` integer PRIMARY KEY AUTOINCREMENT,`environment` text,`project_id` text,`namespace` text,`name` text,`image` text,`image_pull_policy` text,`container_environment` text,`access_path` text)",
+		"CREATE TABLE `namespace` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text,`project` text,`environment` text,`description` text)",
+		"INSERT INTO workload (environment, project_id, namespace, name, image, image_pull_policy, container_environment, access_path) VALUES ('dev', 'c-abc:p-xyz', 'big-data', 'api', 'api:1.0', 'Always', '{\"A\":\"1\"}', '')",
+		"INSERT INTO namespace (name, project, environment, description) VALUES ('big-data', 'p-xyz', 'dev', '')",
+	} {
+		if err := legacy.db.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	legacy.Close()
+
+	dm, err := NewDatabaseManager(dbFile)
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	defer dm.Close()
+	if version, _ := dm.SchemaVersion(); version != latestVersion() {
+		t.Errorf("数据库版本 %d, 期望 %d", version, latestVersion())
+	}
+	backups, _ := filepath.Glob(dbFile + ".v0-*.bak")
+	if len(backups) != 1 {
+		t.Errorf("迁移前的备份: %v", backups)
+	}
+
+	workloads, err := dm.GetWorkloadDetailsByEnvNamespace("dev", "big-data")
+	if err != nil || len(workloads) != 1 {
+		t.Fatalf("工作负载 %v, 错误 %v", workloads, err)
+	}
+	workload := workloads[0]
+	if workload.Cluster != "c-abc" {
+		t.Errorf("工作负载的集群 %q, 期望 c-abc", workload.Cluster)
+	}
+	if workload.Kind != KindDeployment {
+		t.Errorf("工作负载的类型 %q, 期望 %s", workload.Kind, KindDeployment)
+	}
+	if len(workload.Containers) != 1 || workload.Containers[0].Role != ContainerMain || workload.Containers[0].Image != "api:1.0" {
+		t.Errorf("补充的主容器 %+v", workload.Containers)
+	}
+	var namespace Namespace
+	if err := dm.db.First(&namespace).Error; err != nil || namespace.Cluster != "local" {
+		t.Errorf("命名空间的集群 %q, 错误 %v, 期望 local", namespace.Cluster, err)
+	}
+	if !dm.db.Migrator().HasTable("change_event") {
+		t.Errorf("缺少变更记录表")
+	}
+}
+
+// TestMigrateNewerDatabase 数据库版本高于程序支持的版本时拒绝打开
+func TestMigrateNewerDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "app.db")
+	dm, err := NewDatabaseManager(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm.db.Create(&SchemaMigration{Version: latestVersion() + 1, Name: "未来的迁移"})
+	dm.Close()
+
+	if _, err := NewDatabaseManager(dbFile); err == nil || !strings.Contains(err.Error(), "高于程序支持的版本") {
+		t.Errorf("期望拒绝打开, 实际错误 %v", err)
+	}
+	if backups, _ := filepath.Glob(dbFile + ".v*.bak"); len(backups) != 0 {
+		t.Errorf("不需要迁移时不应备份: %v", backups)
+	}
+	if _, err := os.Stat(dbFile); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,146 @@
+package rancher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NamespacedResource 通过Rancher的k8s代理访问的命名空间级资源类型
+type NamespacedResource struct {
+	Kind       string // 资源类型, 如 Deployment
+	APIVersion string // 如 apps/v1, 核心资源为 v1
+	Plural     string // API路径中的名称, 如 deployments
+}
+
+// 克隆整个命名空间和导出时涉及的资源类型
+var (
+	ResourceSecret                = NamespacedResource{Kind: "Secret", APIVersion: "v1", Plural: "secrets"}
+	ResourceConfigMap             = NamespacedResource{Kind: "ConfigMap", APIVersion: "v1", Plural: "configmaps"}
+	ResourcePersistentVolumeClaim = NamespacedResource{Kind: "PersistentVolumeClaim", APIVersion: "v1", Plural: "persistentvolumeclaims"}
+	ResourceService               = NamespacedResource{Kind: "Service", APIVersion: "v1", Plural: "services"}
+	ResourceDeployment            = NamespacedResource{Kind: "Deployment", APIVersion: "apps/v1", Plural: "deployments"}
+	ResourceStatefulSet           = NamespacedResource{Kind: "StatefulSet", APIVersion: "apps/v1", Plural: "statefulsets"}
+	ResourceIngress               = NamespacedResource{Kind: "Ingress", APIVersion: "networking.k8s.io/v1", Plural: "ingresses"}
+	ResourceDaemonSet             = NamespacedResource{Kind: "DaemonSet", APIVersion: "apps/v1", Plural: "daemonsets"}
+	ResourceCronJob               = NamespacedResource{Kind: "CronJob", APIVersion: "batch/v1", Plural: "cronjobs"}
+	ResourceJob                   = NamespacedResource{Kind: "Job", APIVersion: "batch/v1", Plural: "jobs"}
+)
+
+// ImportableResources 可以导入的资源类型, 按导入顺序排列: 被引用的资源在前, 工作负载在其使用的配置、
+// 密钥和存储之后, Ingress在其指向的Service之后
+var ImportableResources = []NamespacedResource{
+	ResourceSecret,
+	ResourceConfigMap,
+	ResourcePersistentVolumeClaim,
+	ResourceService,
+	ResourceDeployment,
+	ResourceStatefulSet,
+	ResourceDaemonSet,
+	ResourceCronJob,
+	ResourceJob,
+	ResourceIngress,
+}
+
+// ResourceByKind 按kind查找可以导入的资源类型
+func ResourceByKind(kind string) (NamespacedResource, bool) {
+	for _, resource := range ImportableResources {
+		if resource.Kind == kind {
+			return resource, true
+		}
+	}
+	return NamespacedResource{}, false
+}
+
+// WorkloadResource 返回工作负载类型对应的资源类型
+func WorkloadResource(kind string) (NamespacedResource, bool) {
+	switch NormalizeKind(kind) {
+	case KindDeployment:
+		return ResourceDeployment, true
+	case KindStatefulSet:
+		return ResourceStatefulSet, true
+	case KindDaemonSet:
+		return ResourceDaemonSet, true
+	case KindCronJob:
+		return ResourceCronJob, true
+	case KindJob:
+		return ResourceJob, true
+	}
+	return NamespacedResource{}, false
+}
+
+// path 返回命名空间下该类型资源的API路径
+func (r NamespacedResource) path(namespace string) string {
+	group := "api/" + r.APIVersion
+	if strings.Contains(r.APIVersion, "/") {
+		group = "apis/" + r.APIVersion
+	}
+	return fmt.Sprintf("%s/namespaces/%s/%s", group, neturl.PathEscape(namespace), r.Plural)
+}
+
+// ListResources 通过k8s代理列出命名空间下某类资源的完整对象, 命名空间不存在时返回空列表
+func (c *Client) ListResources(ctx context.Context, resource NamespacedResource, namespace string) ([]map[string]interface{}, error) {
+	location, err := c.k8sURL(resource.path(namespace), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.send(ctx, "GET", location.String(), nil, "application/json")
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, &APIError{Method: "GET", URL: location.String(), StatusCode: response.StatusCode, Err: err}
+	}
+	// JSON也是合法的YAML, 用YAML解码可以保留整数类型
+	var list struct {
+		Items []map[string]interface{} `yaml:"items"`
+	}
+	if err := yaml.Unmarshal(body, &list); err != nil {
+		return nil, &APIError{Method: "GET", URL: location.String(), StatusCode: response.StatusCode, Err: fmt.Errorf("解析响应失败: %w", err)}
+	}
+	// 列表中的对象不带apiVersion和kind, 导入时需要补上
+	for _, item := range list.Items {
+		item["apiVersion"] = resource.APIVersion
+		item["kind"] = resource.Kind
+	}
+	return list.Items, nil
+}
+
+// ObjectName 返回资源的metadata.name
+func ObjectName(object map[string]interface{}) string {
+	metadata, _ := nestedMap(object, "metadata")
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+// IsGeneratedResource 判断资源是否由集群或Rancher自动生成, 克隆命名空间时应跳过:
+// 带ownerReferences的资源(如Rancher按工作负载端口生成的Service)、服务账号令牌、Helm发布记录和kube-root-ca.crt
+func IsGeneratedResource(object map[string]interface{}) bool {
+	if metadata, ok := nestedMap(object, "metadata"); ok {
+		if owners, ok := metadata["ownerReferences"].([]interface{}); ok && len(owners) > 0 {
+			return true
+		}
+	}
+	switch object["kind"] {
+	case ResourceSecret.Kind:
+		secretType, _ := object["type"].(string)
+		return IsGeneratedSecretType(secretType)
+	case ResourceConfigMap.Kind:
+		return ObjectName(object) == "kube-root-ca.crt"
+	}
+	return false
+}
+
+// IsGeneratedSecretType 判断Secret是否为集群自动维护的类型: 服务账号令牌和Helm发布记录
+func IsGeneratedSecretType(secretType string) bool {
+	return secretType == "kubernetes.io/service-account-token" || strings.HasPrefix(secretType, "helm.sh/")
+}
@@ -0,0 +1,74 @@
+package rancher
+
+import (
+	"RancherMan/rancher/types/secrets"
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+)
+
+// GetSecretList 通过k8s代理列出命名空间中的Secret, 不包括服务账号令牌和Helm发布记录
+func (c *Client) GetSecretList(ctx context.Context, namespace string) ([]secrets.Secret, error) {
+	location, err := c.k8sURL(ResourceSecret.path(namespace), nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []secrets.Secret `json:"items"`
+	}
+	if err := c.sendJSON(ctx, "GET", location.String(), nil, &list); err != nil {
+		return nil, err
+	}
+	var result []secrets.Secret
+	for _, secret := range list.Items {
+		if !IsGeneratedSecretType(secret.Type) {
+			result = append(result, secret)
+		}
+	}
+	return result, nil
+}
+
+// CreateSecret 在命名空间中创建Secret
+func (c *Client) CreateSecret(ctx context.Context, secret secrets.Secret) error {
+	location, err := c.k8sURL(ResourceSecret.path(secret.Metadata.Namespace), nil)
+	if err != nil {
+		return err
+	}
+	return c.saveSecret(ctx, "POST", location.String(), secret)
+}
+
+// UpdateSecret 替换已有的Secret。secret需要带有读取时的resourceVersion, 期间被他人修改时返回冲突错误
+func (c *Client) UpdateSecret(ctx context.Context, secret secrets.Secret) error {
+	location, err := c.k8sURL(ResourceSecret.path(secret.Metadata.Namespace)+"/"+neturl.PathEscape(secret.Metadata.Name), nil)
+	if err != nil {
+		return err
+	}
+	return c.saveSecret(ctx, "PUT", location.String(), secret)
+}
+
+func (c *Client) saveSecret(ctx context.Context, method string, location string, secret secrets.Secret) error {
+	secret.ApiVersion = ResourceSecret.APIVersion
+	secret.Kind = ResourceSecret.Kind
+	payload, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("序列化Secret失败: %w", err)
+	}
+	return c.sendJSON(ctx, method, location, payload, nil)
+}
+
+// sendJSON 向完整地址发送请求并将响应解码到out, out为nil时丢弃响应体
+func (c *Client) sendJSON(ctx context.Context, method, fullURL string, payload []byte, out interface{}) error {
+	response, err := c.send(ctx, method, fullURL, payload, "application/json")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return &APIError{Method: method, URL: fullURL, StatusCode: response.StatusCode, Err: fmt.Errorf("解析响应失败: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package rancher
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// LogOptions 读取容器日志的参数
+type LogOptions struct {
+	Namespace    string
+	Pod          string
+	Container    string
+	Follow       bool      // 持续跟踪新日志
+	TailLines    int       // 只读取最后N行, 0表示全部
+	SinceTime    time.Time // 只读取该时间之后的日志
+	SinceSeconds int       // 只读取最近N秒的日志, 与SinceTime二选一
+	Previous     bool      // 读取上一个已退出容器的日志
+	Timestamps   bool      // 每行前附加时间戳
+}
+
+func (o LogOptions) query() neturl.Values {
+	query := neturl.Values{}
+	if o.Container != "" {
+		query.Set("container", o.Container)
+	}
+	if o.Follow {
+		query.Set("follow", "true")
+	}
+	if o.TailLines > 0 {
+		query.Set("tailLines", strconv.Itoa(o.TailLines))
+	}
+	if !o.SinceTime.IsZero() {
+		query.Set("sinceTime", o.SinceTime.UTC().Format(time.RFC3339))
+	} else if o.SinceSeconds > 0 {
+		query.Set("sinceSeconds", strconv.Itoa(o.SinceSeconds))
+	}
+	if o.Previous {
+		query.Set("previous", "true")
+	}
+	if o.Timestamps {
+		query.Set("timestamps", "true")
+	}
+	return query
+}
+
+// clusterID 返回客户端当前项目所在的集群ID
+func (c *Client) clusterID() string {
+	return ClusterOfProject(c.environment.Project)
+}
+
+// k8sURL 构建Rancher转发到下游集群Kubernetes API的地址
+func (c *Client) k8sURL(path string, query neturl.Values) (*neturl.URL, error) {
+	base, err := neturl.Parse(c.environment.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的base_url: %w", err)
+	}
+	// base_url 一般以 /v3 结尾, k8s代理位于服务根路径下
+	if index := strings.Index(base.Path, "/v3"); index >= 0 {
+		base.Path = base.Path[:index]
+	}
+	base.Path = fmt.Sprintf("%s/k8s/clusters/%s/%s", strings.TrimSuffix(base.Path, "/"), c.clusterID(), path)
+	base.RawQuery = query.Encode()
+	return base, nil
+}
+
+// k8sProxyURL 构建Rancher转发到下游集群Kubernetes API的websocket地址
+func (c *Client) k8sProxyURL(path string, query neturl.Values) (*neturl.URL, error) {
+	location, err := c.k8sURL(path, query)
+	if err != nil {
+		return nil, err
+	}
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	case "http":
+		location.Scheme = "ws"
+	}
+	return location, nil
+}
+
+// dialK8sWebsocket 通过Rancher代理建立到Kubernetes API的websocket连接
+func (c *Client) dialK8sWebsocket(ctx context.Context, path string, query neturl.Values, protocol string) (*websocket.Conn, error) {
+	location, err := c.k8sProxyURL(path, query)
+	if err != nil {
+		return nil, err
+	}
+	origin := &neturl.URL{Scheme: "https", Host: location.Host}
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, &APIError{Method: "GET", URL: location.String(), Err: err}
+	}
+	config.Protocol = []string{protocol}
+	config.TlsConfig = &tls.Config{InsecureSkipVerify: true}
+	request, _ := http.NewRequest("GET", location.String(), nil)
+	request.SetBasicAuth(c.environment.username, c.environment.password)
+	config.Header = http.Header{"Authorization": request.Header["Authorization"]}
+
+	dialCtx, cancel := context.WithTimeout(ctx, c.timeout())
+	defer cancel()
+	conn, err := config.DialContext(dialCtx)
+	if err != nil {
+		if ctxErr := dialCtx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return nil, &APIError{Method: "GET", URL: location.String(), Err: err}
+	}
+	return conn, nil
+}
+
+// StreamLogs 通过websocket读取容器日志并写入out, 直到日志结束或ctx被取消
+func (c *Client) StreamLogs(ctx context.Context, options LogOptions, out io.Writer) error {
+	path := fmt.Sprintf("api/v1/namespaces/%s/pods/%s/log", options.Namespace, options.Pod)
+	conn, err := c.dialK8sWebsocket(ctx, path, options.query(), "base64.binary.k8s.io")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// ctx取消时关闭连接以中断阻塞的读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var frame string
+		if err := websocket.Message.Receive(conn, &frame); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("读取日志失败: %w", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(frame)
+		if err != nil {
+			return fmt.Errorf("解码日志失败: %w", err)
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+}
+
+// exec通道编号, 参见Kubernetes的channel.k8s.io协议
+const (
+	execStdin  = '0'
+	execStdout = '1'
+	execStderr = '2'
+	execError  = '3'
+	execResize = '4'
+)
+
+// ExecOptions 进入容器执行命令的参数
+type ExecOptions struct {
+	Namespace string
+	Pod       string
+	Container string
+	Command   []string // 为空时自动选择bash或sh
+	TTY       bool
+}
+
+// ExecSession 一个容器内的交互式会话
+type ExecSession struct {
+	conn  *websocket.Conn
+	mutex sync.Mutex
+}
+
+// DefaultShellCommand 优先使用bash, 不存在时退回sh
+var DefaultShellCommand = []string{"/bin/sh", "-c", "TERM=xterm-256color; export TERM; [ -x /bin/bash ] && exec /bin/bash || exec /bin/sh"}
+
+// Exec 通过Rancher的exec websocket进入容器
+func (c *Client) Exec(ctx context.Context, options ExecOptions) (*ExecSession, error) {
+	command := options.Command
+	if len(command) == 0 {
+		command = DefaultShellCommand
+	}
+	query := neturl.Values{}
+	if options.Container != "" {
+		query.Set("container", options.Container)
+	}
+	query.Set("stdin", "1")
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	if options.TTY {
+		query.Set("tty", "1")
+	}
+	for _, part := range command {
+		query.Add("command", part)
+	}
+	path := fmt.Sprintf("api/v1/namespaces/%s/pods/%s/exec", options.Namespace, options.Pod)
+	conn, err := c.dialK8sWebsocket(ctx, path, query, "base64.channel.k8s.io")
+	if err != nil {
+		return nil, err
+	}
+	return &ExecSession{conn: conn}, nil
+}
+
+func (s *ExecSession) send(channel byte, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return websocket.Message.Send(s.conn, string(channel)+base64.StdEncoding.EncodeToString(data))
+}
+
+// Write 向容器的标准输入写入数据
+func (s *ExecSession) Write(data []byte) (int, error) {
+	if err := s.send(execStdin, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Resize 调整终端大小
+func (s *ExecSession) Resize(width, height int) error {
+	size, _ := json.Marshal(map[string]int{"Width": width, "Height": height})
+	return s.send(execResize, size)
+}
+
+// Stream 持续读取容器输出直到会话结束
+func (s *ExecSession) Stream(stdout, stderr io.Writer) error {
+	for {
+		var frame string
+		if err := websocket.Message.Receive(s.conn, &frame); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("读取容器输出失败: %w", err)
+		}
+		if len(frame) == 0 {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(frame[1:])
+		if err != nil {
+			return fmt.Errorf("解码容器输出失败: %w", err)
+		}
+		switch frame[0] {
+		case execStdout:
+			stdout.Write(data)
+		case execStderr:
+			stderr.Write(data)
+		case execError:
+			// 错误通道返回的是Status对象, 成功退出时status为Success
+			var status struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(data, &status) == nil && status.Status != "" && status.Status != "Success" {
+				return fmt.Errorf("命令执行失败: %s", status.Message)
+			}
+		}
+	}
+}
+
+// Close 关闭会话
+func (s *ExecSession) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,259 @@
+package rancher
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SyncKey 同步时识别同一资源的键, 本地数据和Rancher中的数据键相同时视为同一资源
+type SyncKey struct {
+	Environment string
+	Cluster     string
+	Namespace   string
+	Kind        string
+	Name        string
+}
+
+// Changed 同一资源同步前后的内容
+type Changed[T any] struct {
+	Old T
+	New T
+}
+
+// SyncDiff 本地数据与Rancher中数据的差异
+type SyncDiff[T any] struct {
+	Added   []T
+	Changed []Changed[T]
+	Removed []T
+}
+
+// Empty 是否没有任何变化
+func (d SyncDiff[T]) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// Summary 变化数量的说明, 如 新增1 变化2 删除0
+func (d SyncDiff[T]) Summary() string {
+	return fmt.Sprintf("新增%d 变化%d 删除%d", len(d.Added), len(d.Changed), len(d.Removed))
+}
+
+// EnvironmentDiff 更新环境时命名空间和工作负载的差异
+type EnvironmentDiff struct {
+	Namespaces SyncDiff[Namespace]
+	Workloads  SyncDiff[Workload]
+}
+
+// diffRecords 按键比较本地数据和获取到的数据。获取到的数据中键重复时只保留第一个,
+// 新增的按获取顺序排列, 删除的按本地数据的顺序排列
+func diffRecords[T any](current, fetched []T, key func(T) SyncKey, same func(a, b T) bool) SyncDiff[T] {
+	var diff SyncDiff[T]
+	currentByKey := make(map[SyncKey]T, len(current))
+	for _, item := range current {
+		currentByKey[key(item)] = item
+	}
+	seen := make(map[SyncKey]bool, len(fetched))
+	for _, item := range fetched {
+		k := key(item)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		old, exists := currentByKey[k]
+		if !exists {
+			diff.Added = append(diff.Added, item)
+		} else if !same(old, item) {
+			diff.Changed = append(diff.Changed, Changed[T]{Old: old, New: item})
+		}
+	}
+	for _, item := range current {
+		if !seen[key(item)] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+	return diff
+}
+
+func namespaceKey(n Namespace) SyncKey {
+	return SyncKey{Environment: n.Environment, Cluster: n.Cluster, Kind: "Namespace", Name: n.Name}
+}
+
+func sameNamespace(a, b Namespace) bool {
+	a.ID, b.ID = 0, 0
+	return a == b
+}
+
+// workloadKey 类型经过规范化, 旧数据中为空的类型与获取到的deployment视为同一类型
+func workloadKey(w Workload) SyncKey {
+	return SyncKey{Environment: w.Environment, Cluster: w.Cluster, Namespace: w.Namespace, Kind: NormalizeKind(w.Kind), Name: w.Name}
+}
+
+// sameWorkload 比较除ID之外的所有字段, 包括容器
+func sameWorkload(a, b Workload) bool {
+	if len(a.Containers) != len(b.Containers) {
+		return false
+	}
+	for i := range a.Containers {
+		x, y := a.Containers[i], b.Containers[i]
+		x.ID, x.WorkloadID, y.ID, y.WorkloadID = 0, 0, 0, 0
+		if x != y {
+			return false
+		}
+	}
+	if (a.Replicas == nil) != (b.Replicas == nil) || (a.Replicas != nil && *a.Replicas != *b.Replicas) {
+		return false
+	}
+	a.ID, b.ID = 0, 0
+	a.Replicas, b.Replicas = nil, nil
+	a.Containers, b.Containers = nil, nil
+	return a.Environment == b.Environment && a.ProjectId == b.ProjectId && a.Cluster == b.Cluster &&
+		a.Namespace == b.Namespace && a.Name == b.Name && a.Kind == b.Kind && a.Image == b.Image &&
+		a.ImagePullPolicy == b.ImagePullPolicy && a.ContainerEnvironment == b.ContainerEnvironment &&
+		a.AccessPath == b.AccessPath && a.NodeAffinity == b.NodeAffinity
+}
+
+func podKey(p Pod) SyncKey {
+	return SyncKey{Environment: p.Environment, Cluster: p.Cluster, Namespace: p.NamespaceId, Kind: "Pod", Name: p.Name}
+}
+
+func samePod(a, b Pod) bool {
+	a.ID, b.ID = 0, 0
+	return a == b
+}
+
+// serviceKey 每个端口一行, 名称中包含端口和协议
+func serviceKey(s Service) SyncKey {
+	return SyncKey{Environment: s.Environment, Cluster: s.Cluster, Namespace: s.NamespaceId, Kind: "Service",
+		Name: fmt.Sprintf("%s:%d/%s", s.Name, s.Port, s.PortProtocol)}
+}
+
+func sameService(a, b Service) bool {
+	a.ID, b.ID = 0, 0
+	return a == b
+}
+
+// SyncEnvironment 将环境的命名空间和工作负载更新为获取到的数据。只写入有变化的记录,
+// 未变化的记录保留原来的ID; 所有修改和变更记录在同一个事务中写入, 失败时本地数据保持不变。
+// 环境第一次同步时所有工作负载都是新增的, 不记录变更
+func (dm *DatabaseManager) SyncEnvironment(environment string, namespaces []Namespace, workloads []Workload) (*EnvironmentDiff, error) {
+	diff := &EnvironmentDiff{}
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		var currentNamespaces []Namespace
+		if err := tx.Where("environment = ?", environment).Order("id").Find(&currentNamespaces).Error; err != nil {
+			return err
+		}
+		diff.Namespaces = diffRecords(currentNamespaces, namespaces, namespaceKey, sameNamespace)
+		if err := applyDiff(tx, diff.Namespaces, func(n Namespace) uint { return n.ID }, func(n *Namespace, id uint) { n.ID = id }); err != nil {
+			return fmt.Errorf("更新命名空间失败: %w", err)
+		}
+
+		var currentWorkloads []Workload
+		if err := tx.Preload("Containers", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Where("environment = ?", environment).Order("id").Find(&currentWorkloads).Error; err != nil {
+			return err
+		}
+		diff.Workloads = diffRecords(currentWorkloads, workloads, workloadKey, sameWorkload)
+		if err := applyWorkloadDiff(tx, diff.Workloads); err != nil {
+			return fmt.Errorf("更新工作负载失败: %w", err)
+		}
+		if len(currentWorkloads) == 0 {
+			return nil
+		}
+		if err := insertChangeEvents(tx, workloadChangeEvents(diff.Workloads, time.Now())); err != nil {
+			return fmt.Errorf("保存变更记录失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// SyncPods 将环境的Pod更新为获取到的数据, 只写入有变化的记录, 同时记录Pod状态的变化
+func (dm *DatabaseManager) SyncPods(environment string, pods []Pod) (SyncDiff[Pod], error) {
+	var diff SyncDiff[Pod]
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		var current []Pod
+		if err := tx.Where("environment = ?", environment).Order("id").Find(&current).Error; err != nil {
+			return err
+		}
+		diff = diffRecords(current, pods, podKey, samePod)
+		if err := applyDiff(tx, diff, func(p Pod) uint { return p.ID }, func(p *Pod, id uint) { p.ID = id }); err != nil {
+			return err
+		}
+		return insertChangeEvents(tx, podChangeEvents(diff, time.Now()))
+	})
+	return diff, err
+}
+
+// SyncServices 将环境的端口映射更新为获取到的数据, 只写入有变化的记录
+func (dm *DatabaseManager) SyncServices(environment string, services []Service) (SyncDiff[Service], error) {
+	var diff SyncDiff[Service]
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		var current []Service
+		if err := tx.Where("environment = ?", environment).Order("id").Find(&current).Error; err != nil {
+			return err
+		}
+		diff = diffRecords(current, services, serviceKey, sameService)
+		return applyDiff(tx, diff, func(s Service) uint { return s.ID }, func(s *Service, id uint) { s.ID = id })
+	})
+	return diff, err
+}
+
+// applyDiff 插入新增的记录, 按原ID更新有变化的记录, 删除不存在的记录
+func applyDiff[T any](tx *gorm.DB, diff SyncDiff[T], getID func(T) uint, setID func(*T, uint)) error {
+	for i := range diff.Added {
+		if err := tx.Create(&diff.Added[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range diff.Changed {
+		change := &diff.Changed[i]
+		setID(&change.New, getID(change.Old))
+		if err := tx.Save(&change.New).Error; err != nil {
+			return err
+		}
+	}
+	for _, item := range diff.Removed {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyWorkloadDiff 与applyDiff相同, 工作负载有变化时同时替换其容器
+func applyWorkloadDiff(tx *gorm.DB, diff SyncDiff[Workload]) error {
+	for i := range diff.Added {
+		if err := tx.Create(&diff.Added[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range diff.Changed {
+		workload := &diff.Changed[i].New
+		workload.ID = diff.Changed[i].Old.ID
+		if err := tx.Omit("Containers").Save(workload).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workload_id = ?", workload.ID).Delete(&WorkloadContainer{}).Error; err != nil {
+			return err
+		}
+		for j := range workload.Containers {
+			workload.Containers[j].WorkloadID = workload.ID
+			if err := tx.Create(&workload.Containers[j]).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, workload := range diff.Removed {
+		if err := tx.Where("workload_id = ?", workload.ID).Delete(&WorkloadContainer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&Workload{}, workload.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
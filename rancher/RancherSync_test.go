@@ -0,0 +1,284 @@
+package rancher
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func testWorkload(cluster, namespace, name, image string) Workload {
+	return Workload{Environment: "dev", ProjectId: cluster + ":p-1", Cluster: cluster, Namespace: namespace, Name: name,
+		Kind: "deployment", Image: image, Containers: []WorkloadContainer{{Name: name, Image: image, Role: ContainerMain}}}
+}
+
+func workloadNames(workloads []Workload) []string {
+	var names []string
+	for _, w := range workloads {
+		names = append(names, w.Cluster+"/"+w.Name)
+	}
+	return names
+}
+
+func TestDiffRecords(t *testing.T) {
+	current := []Workload{
+		testWorkload("c-1", "app", "api", "api:1.0"),
+		testWorkload("c-1", "app", "web", "web:1.0"),
+		testWorkload("c-1", "app", "job", "job:1.0"),
+	}
+	fetched := []Workload{
+		testWorkload("c-1", "app", "worker", "worker:1.0"),
+		testWorkload("c-1", "app", "api", "api:1.0"),
+		testWorkload("c-1", "app", "web", "web:2.0"),
+		testWorkload("c-1", "app", "worker", "worker:2.0"), // 键重复, 只保留第一个
+	}
+	diff := diffRecords(current, fetched, workloadKey, sameWorkload)
+	if got := workloadNames(diff.Added); !reflect.DeepEqual(got, []string{"c-1/worker"}) || diff.Added[0].Image != "worker:1.0" {
+		t.Errorf("新增 %v", got)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Old.Image != "web:1.0" || diff.Changed[0].New.Image != "web:2.0" {
+		t.Errorf("变化 %+v", diff.Changed)
+	}
+	if got := workloadNames(diff.Removed); !reflect.DeepEqual(got, []string{"c-1/job"}) {
+		t.Errorf("删除 %v", got)
+	}
+	if diff.Summary() != "新增1 变化1 删除1" {
+		t.Errorf("说明 %s", diff.Summary())
+	}
+
+	// 只有ID不同时视为未变化
+	same := diffRecords(diff.Removed, []Workload{testWorkload("c-1", "app", "job", "job:1.0")}, workloadKey, sameWorkload)
+	if !same.Empty() {
+		t.Errorf("未变化的记录 %s", same.Summary())
+	}
+}
+
+func TestSyncKey(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b Workload
+		same bool
+	}{
+		{"相同", testWorkload("c-1", "app", "api", "api:1.0"), testWorkload("c-1", "app", "api", "api:2.0"), true},
+		{"不同集群", testWorkload("c-1", "app", "api", "api:1.0"), testWorkload("c-2", "app", "api", "api:1.0"), false},
+		{"不同命名空间", testWorkload("c-1", "app", "api", "api:1.0"), testWorkload("c-1", "web", "api", "api:1.0"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := workloadKey(tc.a) == workloadKey(tc.b); got != tc.same {
+				t.Errorf("键相同 = %v, 期望 %v", got, tc.same)
+			}
+		})
+	}
+
+	statefulSet := testWorkload("c-1", "app", "api", "api:1.0")
+	statefulSet.Kind = "statefulSet"
+	if workloadKey(statefulSet) == workloadKey(testWorkload("c-1", "app", "api", "api:1.0")) {
+		t.Errorf("不同类型的同名工作负载键相同")
+	}
+
+	// 不同集群中的同名工作负载各自保存
+	diff := diffRecords([]Workload{testWorkload("c-1", "app", "api", "api:1.0")},
+		[]Workload{testWorkload("c-1", "app", "api", "api:1.0"), testWorkload("c-2", "app", "api", "api:1.0")},
+		workloadKey, sameWorkload)
+	if got := workloadNames(diff.Added); !reflect.DeepEqual(got, []string{"c-2/api"}) || len(diff.Changed) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("差异 新增%v %s", got, diff.Summary())
+	}
+}
+
+func TestSyncEnvironment(t *testing.T) {
+	dm := newTestDatabase(t)
+	namespaces := []Namespace{{Name: "app", Project: "p-1", Cluster: "c-1", Environment: "dev"}}
+	first := []Workload{
+		testWorkload("c-1", "app", "api", "api:1.0"),
+		testWorkload("c-1", "app", "web", "web:1.0"),
+		testWorkload("c-2", "app", "api", "api:1.0"),
+	}
+	diff, err := dm.SyncEnvironment("dev", namespaces, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Workloads.Added) != 3 || len(diff.Namespaces.Added) != 1 {
+		t.Errorf("第一次同步 命名空间%s 工作负载%s", diff.Namespaces.Summary(), diff.Workloads.Summary())
+	}
+	if events, _ := dm.GetChangeEvents(ChangeEventFilter{}); len(events) != 0 {
+		t.Errorf("第一次同步不应记录变更: %+v", events)
+	}
+	before := workloadIDs(t, dm)
+
+	second := []Workload{
+		testWorkload("c-1", "app", "api", "api:1.0"),
+		testWorkload("c-1", "app", "web", "web:2.0"),
+		testWorkload("c-1", "app", "worker", "worker:1.0"),
+	}
+	diff, err = dm.SyncEnvironment("dev", namespaces, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Workloads.Summary() != "新增1 变化1 删除1" || !diff.Namespaces.Empty() {
+		t.Errorf("第二次同步 命名空间%s 工作负载%s", diff.Namespaces.Summary(), diff.Workloads.Summary())
+	}
+	after := workloadIDs(t, dm)
+	for _, key := range []string{"c-1/api", "c-1/web"} {
+		if before[key] == 0 || after[key] != before[key] {
+			t.Errorf("%s 的ID从 %d 变为 %d", key, before[key], after[key])
+		}
+	}
+	if _, exists := after["c-2/api"]; exists {
+		t.Errorf("c-2/api 没有被删除")
+	}
+
+	events, err := dm.GetChangeEvents(ChangeEventFilter{Environment: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Cluster+"/"+event.Name+" "+event.Type)
+	}
+	want := []string{"c-1/worker " + ChangeWorkloadAdded, "c-2/api " + ChangeWorkloadRemoved, "c-1/web " + ChangeImage}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("变更记录 %v, 期望 %v", types, want)
+	}
+
+	workloads, err := dm.GetWorkloadDetailsByEnvNamespace("dev", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range workloads {
+		if len(w.Containers) != 1 || w.Containers[0].Image != w.Image {
+			t.Errorf("%s 的容器 %+v", w.Name, w.Containers)
+		}
+	}
+}
+
+// TestSyncEnvironmentRollback 事务中途失败时本地数据保持不变
+func TestSyncEnvironmentRollback(t *testing.T) {
+	dm := newTestDatabase(t)
+	workloads := []Workload{testWorkload("c-1", "app", "api", "api:1.0"), testWorkload("c-1", "app", "web", "web:1.0")}
+	if _, err := dm.SyncEnvironment("dev", nil, workloads); err != nil {
+		t.Fatal(err)
+	}
+	before := workloadIDs(t, dm)
+
+	// 删除变更记录表, 使写入变更记录时失败, 此时工作负载已在事务中修改
+	if err := dm.db.Exec("DROP TABLE change_event").Error; err != nil {
+		t.Fatal(err)
+	}
+	changed := []Workload{testWorkload("c-1", "app", "api", "api:2.0"), testWorkload("c-1", "app", "worker", "worker:1.0")}
+	if _, err := dm.SyncEnvironment("dev", nil, changed); err == nil {
+		t.Fatal("期望同步失败")
+	}
+
+	after := workloadIDs(t, dm)
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("回滚后的工作负载 %v, 期望 %v", after, before)
+	}
+	workloadsAfter, err := dm.GetWorkloadDetailsByEnvNamespace("dev", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range workloadsAfter {
+		if w.Name == "api" && (w.Image != "api:1.0" || len(w.Containers) != 1 || w.Containers[0].Image != "api:1.0") {
+			t.Errorf("回滚后 api 的镜像 %s, 容器 %+v", w.Image, w.Containers)
+		}
+	}
+}
+
+// TestSyncEnvironmentLegacyKind 旧数据的类型为空, 与获取到的deployment是同一工作负载: 保留ID, 不记录新增和删除
+func TestSyncEnvironmentLegacyKind(t *testing.T) {
+	dm := newTestDatabase(t)
+	if _, err := dm.SyncEnvironment("dev", nil, []Workload{testWorkload("c-1", "app", "api", "api:1.0")}); err != nil {
+		t.Fatal(err)
+	}
+	for _, legacy := range []struct {
+		name string
+		kind interface{}
+	}{{"NULL", nil}, {"空字符串", ""}} {
+		if err := dm.db.Exec("UPDATE workload SET kind = ?", legacy.kind).Error; err != nil {
+			t.Fatal(err)
+		}
+		before := workloadIDs(t, dm)
+
+		diff, err := dm.SyncEnvironment("dev", nil, []Workload{testWorkload("c-1", "app", "api", "api:1.0")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff.Workloads.Added) != 0 || len(diff.Workloads.Removed) != 0 {
+			t.Errorf("类型为%s时的差异 %s", legacy.name, diff.Workloads.Summary())
+		}
+		if after := workloadIDs(t, dm); !reflect.DeepEqual(before, after) {
+			t.Errorf("类型为%s时ID从 %v 变为 %v", legacy.name, before, after)
+		}
+		if events, _ := dm.GetChangeEvents(ChangeEventFilter{}); len(events) != 0 {
+			t.Errorf("类型为%s时的变更记录 %+v", legacy.name, events)
+		}
+		workloads, err := dm.GetWorkloadDetailsByEnvNamespace("dev", "app")
+		if err != nil || len(workloads) != 1 || workloads[0].Kind != KindDeployment {
+			t.Errorf("类型为%s时同步后的工作负载 %+v, 错误 %v", legacy.name, workloads, err)
+		}
+	}
+}
+
+// workloadIDs 按 集群/名称 返回dev环境中工作负载的ID
+func workloadIDs(t *testing.T, dm *DatabaseManager) map[string]uint {
+	t.Helper()
+	var workloads []Workload
+	if err := dm.db.Where("environment = ?", "dev").Find(&workloads).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]uint{}
+	for _, w := range workloads {
+		ids[w.Cluster+"/"+w.Name] = w.ID
+	}
+	return ids
+}
+
+// TestSyncEnvironmentConcurrent 多个环境同时同步到同一个数据库文件, 只有一个连接, 写入依次执行不会出现database is locked
+func TestSyncEnvironmentConcurrent(t *testing.T) {
+	dm, err := NewDatabaseManager(filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dm.Close()
+
+	envNames := []string{"dev", "test", "staging", "prod", "demo", "perf"}
+	var wg sync.WaitGroup
+	errs := make([]error, len(envNames))
+	for i, envName := range envNames {
+		wg.Add(1)
+		go func(i int, envName string) {
+			defer wg.Done()
+			for round := 1; round <= 3; round++ {
+				var workloads []Workload
+				for _, name := range []string{"api", "web", "worker"} {
+					w := testWorkload("c-1", "app", name, fmt.Sprintf("%s:%d", name, round))
+					w.Environment = envName
+					workloads = append(workloads, w)
+				}
+				namespaces := []Namespace{{Name: "app", Project: "p-1", Cluster: "c-1", Environment: envName}}
+				if _, err := dm.SyncEnvironment(envName, namespaces, workloads); err != nil {
+					errs[i] = err
+					return
+				}
+			}
+		}(i, envName)
+	}
+	wg.Wait()
+
+	for i, envName := range envNames {
+		if errs[i] != nil {
+			t.Errorf("%s 同步失败: %v", envName, errs[i])
+			continue
+		}
+		workloads, err := dm.GetWorkloadDetailsByEnvNamespace(envName, "app")
+		if err != nil || len(workloads) != 3 {
+			t.Errorf("%s 的工作负载 %d 个, 错误 %v", envName, len(workloads), err)
+		}
+		// 第二、三次同步各有3个镜像变化
+		if events, _ := dm.GetChangeEvents(ChangeEventFilter{Environment: envName}); len(events) != 6 {
+			t.Errorf("%s 的变更记录 %d 条, 期望 6", envName, len(events))
+		}
+	}
+}
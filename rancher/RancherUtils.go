@@ -1,99 +1,195 @@
 package rancher
 
 import (
+	"RancherMan/rancher/config"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"encoding/json"
-
-	"gopkg.in/yaml.v2"
 )
 
 type Environment struct {
 	ID        string
 	Name      string
 	BaseURL   string
-	Project   string
+	Project   string    // 客户端当前使用的项目ID, 默认为Projects中的第一个
+	Projects  []Project // 环境中的所有集群/项目
 	Ip        string
+	Timeout   time.Duration // 单个请求的超时时间, 0表示使用默认值
 	username  string
 	password  string
 	nginxList []NginxMap
 }
 
+// Project 环境中的一个集群/项目
+type Project struct {
+	ID      string // 完整项目ID, 如 c-abcde:p-xyz
+	Cluster string // 集群ID
+	Name    string // 显示名称
+}
+
+// ClusterOfProject 从项目ID(如 c-abcde:p-xyz)中解析集群ID, 旧格式配置默认为local
+func ClusterOfProject(projectID string) string {
+	if colonIndex := strings.Index(projectID, ":"); colonIndex > 0 {
+		return projectID[:colonIndex]
+	}
+	return "local"
+}
+
+// WithProject 返回使用指定项目的环境副本, 用于创建访问该项目的客户端; projectID为空时保持不变
+func (e Environment) WithProject(projectID string) Environment {
+	if projectID != "" {
+		e.Project = projectID
+	}
+	return e
+}
+
+// FindProject 根据项目ID或显示名称查找环境中的项目
+func (e Environment) FindProject(project string) (Project, bool) {
+	for _, p := range e.Projects {
+		if p.ID == project || p.Name == project {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
 type NginxMap struct {
 	Name     string
 	BaseUrl  string
 	ConfPath string
 }
 
-func LoadConfigFromDb(db *DatabaseManager) (map[string]interface{}, error) {
+// LoadConfigFromDb 从数据库读取、解密并解析配置。配置有校验问题时同时返回解析结果和*config.ValidationError,
+// 调用方可以继续使用其中有效的部分。旧版本保存的明文密钥会在读取时加密回写。
+func LoadConfigFromDb(db *DatabaseManager) (*config.Config, error) {
 	configContent, err := db.GetConfigContent(1)
+	if err != nil || configContent == "" {
+		// 尚未保存过配置
+		return &config.Config{}, nil
+	}
+	box, err := config.OpenSecretBox(db.DataDir())
+	if errors.Is(err, config.ErrNoKey) && !config.HasEncryptedSecrets([]byte(configContent)) {
+		// 旧版本保存的明文配置, 设置主密码或生成密钥文件之前仍可使用, 暂不加密
+		cfg, parseErr := config.Parse([]byte(configContent))
+		if cfg == nil {
+			return &config.Config{}, parseErr
+		}
+		return cfg, errors.Join(fmt.Errorf("配置中的密钥尚未加密: %w", err), parseErr)
+	}
 	if err != nil {
-		return make(map[string]interface{}), err
+		return &config.Config{}, err
 	}
-	if configContent == "" {
-		return make(map[string]interface{}), nil
+	plain, err := config.DecryptSecrets([]byte(configContent), box)
+	if err != nil {
+		return &config.Config{}, err
 	}
-	var config map[string]interface{}
-	if err := yaml.Unmarshal([]byte(configContent), &config); err != nil {
-		fmt.Printf("从数据库解析配置时出错: %v\n", err)
-		return make(map[string]interface{}), err
+	cfg, err := config.Parse(plain)
+	if cfg == nil {
+		return &config.Config{}, err
 	}
-	return config, nil
+	if config.HasPlainSecrets([]byte(configContent)) {
+		if saveErr := SaveConfigToDb(db, configContent); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "加密配置中的密钥失败: %v\n", saveErr)
+		}
+	}
+	return cfg, err
 }
 
-func SaveConfigToDb(db *DatabaseManager, content string) {
+// SaveConfigToDb 加密配置中的敏感字段后保存
+func SaveConfigToDb(db *DatabaseManager, content string) error {
+	box, err := config.OpenSecretBox(db.DataDir())
+	if err != nil {
+		return err
+	}
+	encrypted, err := config.EncryptSecrets([]byte(content), box, nil)
+	if err != nil {
+		return err
+	}
 	db.DeleteConfig(1)
-	db.InsertConfig(1, content)
+	return db.InsertConfig(1, string(encrypted))
 }
 
-func UpdateEnvironment(db *DatabaseManager, envName string, environment *Environment, forceUpdate bool) {
-	workloadCount, _ := db.GetWorkloadCountByEnvironment(environment.Name)
+// UpdateEnvironment 更新环境中所有集群/项目的命名空间和工作负载。全部获取成功后才修改本地数据,
+// 只写入有变化的记录并返回差异; 不需要更新时返回nil
+func UpdateEnvironment(ctx context.Context, db *DatabaseManager, envName string, environment *Environment, forceUpdate bool) (*EnvironmentDiff, error) {
+	workloadCount, _ := db.GetWorkloadCountByEnvironment(envName)
 	update := forceUpdate
 	if workloadCount == 0 {
 		update = true
 	}
-	if update {
-		// 更新namespace
-		var namespaceDBList []Namespace
-		db.DeleteNamespaceByEnvironment(envName)
-		allNamespaces, _ := GetNamespaceList(*environment)
-		var namespaceList []NamespaceResp
-		for _, ns := range allNamespaces {
-			if ns.ProjectId == environment.Project {
-				namespaceList = append(namespaceList, ns)
+	if !update {
+		return nil, nil
+	}
+	// Get nginx reverse proxy list
+	client := NewClient(*environment)
+	var nginxProxyList []ConfigEntry
+	for _, nginxConfig := range environment.nginxList {
+		nginxConf, err := client.GetConfigMaps(ctx, nginxConfig.ConfPath)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
+			// nginx配置只用于补充访问路径, 获取失败不影响同步
+			fmt.Fprintf(os.Stderr, "获取nginx配置%s失败: %v\n", nginxConfig.Name, err)
+			continue
 		}
-		for _, namespace := range namespaceList {
-			namespaceDBList = append(namespaceDBList, Namespace{
-				Name:        namespace.Name,
-				Environment: envName,
-				Project:     namespace.ProjectId,
-				Description: namespace.Description,
-			})
-		}
-		db.InsertNamespaces(namespaceDBList)
-		// 更新workload
-		db.DeleteWorkloadByEnv(envName)
-		// Get nginx reverse proxy list
-		var nginxProxyList []ConfigEntry
-		for _, nginxConfig := range environment.nginxList {
-			nginxConf, _ := GetConfigMaps(*environment, nginxConfig.ConfPath)
 
-			configList, _ := ParseNginxConfig(nginxConfig.BaseUrl, nginxConf)
-			nginxProxyList = append(nginxProxyList, configList...)
+		configList, _ := ParseNginxConfig(nginxConfig.BaseUrl, nginxConf)
+		nginxProxyList = append(nginxProxyList, configList...)
+	}
+	lookupDict := CreateLookupDict(nginxProxyList)
+
+	var namespaceDBList []Namespace
+	var workloadsDBList []Workload
+	// 同一集群的命名空间只获取一次
+	clusterNamespaces := map[string][]NamespaceResp{}
+	for _, project := range environment.Projects {
+		client := NewClient(environment.WithProject(project.ID))
+		allNamespaces, ok := clusterNamespaces[project.Cluster]
+		if !ok {
+			var err error
+			if allNamespaces, err = client.GetNamespaceList(ctx); err != nil {
+				return nil, fmt.Errorf("获取%s的命名空间失败: %w", project.Name, err)
+			}
+			clusterNamespaces[project.Cluster] = allNamespaces
+		}
+		for _, namespace := range allNamespaces {
+			if namespace.ProjectId == project.ID {
+				namespaceDBList = append(namespaceDBList, Namespace{
+					Name:        namespace.Name,
+					Environment: envName,
+					Project:     namespace.ProjectId,
+					Cluster:     project.Cluster,
+					Description: namespace.Description,
+				})
+			}
 		}
-		lookupDict := CreateLookupDict(nginxProxyList)
-		workloadList, _ := GetWorkloadList(*environment)
 
-		var workloadsDBList []Workload
+		workloadList, err := client.GetWorkloadList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s的工作负载失败: %w", project.Name, err)
+		}
 		for _, workload := range workloadList {
+			// 工作负载的镜像和环境变量取自主容器, 所有容器另外保存
+			containers := workloadContainers(workload.Name, workload.Containers)
 			var image, imagePullPolicy, containerEnvironment string
-			if len(workload.Containers) == 1 {
-				image = workload.Containers[0].Image
-				imagePullPolicy = workload.Containers[0].ImagePullPolicy
-				if envData, err := json.Marshal(workload.Containers[0].Environment); err == nil {
-					containerEnvironment = string(envData)
+			for _, container := range containers {
+				if container.Role == ContainerMain {
+					image = container.Image
+					imagePullPolicy = container.ImagePullPolicy
+					containerEnvironment = container.Environment
+				}
+			}
+			var nodeAffinity string
+			if requireAll := workload.Scheduling.Node.RequireAll; len(requireAll) > 0 {
+				if affinityData, err := json.Marshal(requireAll); err == nil {
+					nodeAffinity = string(affinityData)
 				}
 			}
 			accessPath := LookupService(lookupDict, workload.Name, workload.NamespaceID)
@@ -101,128 +197,227 @@ func UpdateEnvironment(db *DatabaseManager, envName string, environment *Environ
 				Environment:          envName,
 				Namespace:            workload.NamespaceID,
 				ProjectId:            workload.ProjectID,
+				Cluster:              project.Cluster,
 				Name:                 workload.Name,
+				Kind:                 NormalizeKind(workload.Type),
 				Image:                image,
 				ImagePullPolicy:      imagePullPolicy,
 				ContainerEnvironment: containerEnvironment,
 				AccessPath:           accessPath,
+				Replicas:             workload.Scale,
+				NodeAffinity:         nodeAffinity,
+				Containers:           containers,
 			})
 		}
-		db.InsertWorkloads(workloadsDBList)
 	}
-}
 
-func UpdateService(db *DatabaseManager, envName string, environment *Environment) {
-	// 删除旧的pod数据
-	db.DeleteServiceByEnvironment(envName)
-
-	// 获取所有pod
-	serviceList, err := GetServiceList(*environment)
+	// 更新namespace和workload
+	diff, err := db.SyncEnvironment(envName, namespaceDBList, workloadsDBList)
 	if err != nil {
-		fmt.Printf("获取服务列表失败: %v\n", err)
-		return
+		return nil, fmt.Errorf("保存工作负载数据失败: %w", err)
+	}
+	return diff, nil
+}
+
+// workloadContainers 转换工作负载的所有容器。与工作负载同名的普通容器作为主容器, 没有同名容器时取第一个普通容器,
+// 其余普通容器作为sidecar
+func workloadContainers(workloadName string, containers []Container) []WorkloadContainer {
+	main := -1
+	for i, container := range containers {
+		if container.InitContainer {
+			continue
+		}
+		if main < 0 || container.Name == workloadName {
+			main = i
+		}
+		if container.Name == workloadName {
+			break
+		}
+	}
+	result := make([]WorkloadContainer, 0, len(containers))
+	for i, container := range containers {
+		role := ContainerSidecar
+		if container.InitContainer {
+			role = ContainerInit
+		} else if i == main {
+			role = ContainerMain
+		}
+		var environment string
+		if envData, err := json.Marshal(container.Environment); err == nil {
+			environment = string(envData)
+		}
+		ports := make([]string, 0, len(container.Ports))
+		for _, port := range container.Ports {
+			text := fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
+			if port.Name != "" {
+				text = port.Name + ":" + text
+			}
+			ports = append(ports, text)
+		}
+		result = append(result, WorkloadContainer{
+			Name:            container.Name,
+			Image:           container.Image,
+			ImagePullPolicy: container.ImagePullPolicy,
+			Environment:     environment,
+			Ports:           strings.Join(ports, ","),
+			Role:            role,
+		})
 	}
+	return result
+}
 
+// UpdateService 更新环境中所有项目的端口映射, 全部获取成功后才修改本地数据
+func UpdateService(ctx context.Context, db *DatabaseManager, envName string, environment *Environment) (SyncDiff[Service], error) {
 	var servicesDBList []Service
-	for _, service := range serviceList {
-		for _, port := range service.Ports {
-			workloadId := ""
-			if len(service.TargetWorkloadIds) == 1 {
-				parts := strings.Split(service.TargetWorkloadIds[0], ":")
-				workloadId = parts[len(parts)-1] // 获取最后一个元素
+	for _, project := range environment.Projects {
+		// 获取项目的所有service
+		serviceList, err := NewClient(environment.WithProject(project.ID)).GetServiceList(ctx)
+		if err != nil {
+			return SyncDiff[Service]{}, fmt.Errorf("获取%s的服务列表失败: %w", project.Name, err)
+		}
+		for _, service := range serviceList {
+			for _, port := range service.Ports {
+				workloadId := ""
+				if len(service.TargetWorkloadIds) == 1 {
+					parts := strings.Split(service.TargetWorkloadIds[0], ":")
+					workloadId = parts[len(parts)-1] // 获取最后一个元素
+				}
+				servicesDBList = append(servicesDBList, Service{
+					Environment:  envName,
+					ProjectId:    service.ProjectId,
+					Cluster:      project.Cluster,
+					NamespaceId:  service.NamespaceId,
+					Name:         service.Name,
+					WorkloadId:   workloadId,
+					Kind:         service.Kind,
+					PortName:     port.Name,
+					PortProtocol: port.Protocol,
+					Port:         port.Port,
+					TargetPort:   port.TargetPort,
+					NodePort:     port.NodePort,
+				})
 			}
-			servicesDBList = append(servicesDBList, Service{
+		}
+	}
+
+	diff, err := db.SyncServices(envName, servicesDBList)
+	if err != nil {
+		return diff, fmt.Errorf("保存服务数据失败: %w", err)
+	}
+	return diff, nil
+}
+
+// UpdatePod 更新环境中所有项目的Pod状态, 全部获取成功后才修改本地数据
+func UpdatePod(ctx context.Context, db *DatabaseManager, envName string, environment *Environment) (SyncDiff[Pod], error) {
+	var podsDBList []Pod
+	for _, project := range environment.Projects {
+		// 获取项目的所有pod
+		podList, err := NewClient(environment.WithProject(project.ID)).GetPodList(ctx)
+		if err != nil {
+			return SyncDiff[Pod]{}, fmt.Errorf("获取%s的Pod列表失败: %w", project.Name, err)
+		}
+		for _, pod := range podList {
+			podsDBList = append(podsDBList, Pod{
 				Environment:  envName,
-				ProjectId:    service.ProjectId,
-				NamespaceId:  service.NamespaceId,
-				Name:         service.Name,
-				WorkloadId:   workloadId,
-				Kind:         service.Kind,
-				PortName:     port.Name,
-				PortProtocol: port.Protocol,
-				Port:         port.Port,
-				TargetPort:   port.TargetPort,
-				NodePort:     port.NodePort,
+				ProjectId:    pod.ProjectId,
+				Cluster:      project.Cluster,
+				NamespaceId:  pod.NamespaceId,
+				WorkloadId:   pod.WorkloadId,
+				Name:         pod.Name,
+				NodeId:       pod.NodeId,
+				Containers:   strings.Join(pod.ContainerNames(), ","),
+				RestartCount: pod.RestartCount(),
+				State:        pod.State,
 			})
 		}
 	}
 
-	// 插入新的pod数据
-	if err := db.InsertServices(servicesDBList); err != nil {
-		fmt.Printf("插入服务数据失败: %v\n", err)
-		return
+	diff, err := db.SyncPods(envName, podsDBList)
+	if err != nil {
+		return diff, fmt.Errorf("保存Pod数据失败: %w", err)
 	}
+	return diff, nil
 }
 
-func UpdatePod(db *DatabaseManager, envName string, environment *Environment) {
-	// 删除旧的pod数据
-	db.DeletePodByEnvironment(envName)
+// GetEnvironmentNames 返回配置中所有环境的标识, 按名称排序
+func GetEnvironmentNames(cfg *config.Config) []string {
+	return cfg.EnvironmentNames()
+}
 
-	// 获取所有pod
-	podList, err := GetPodList(*environment)
+// GetJumpHostFromConfig 解析跳板机配置及其中引用的环境变量和文件, 未配置时返回nil
+func GetJumpHostFromConfig(cfg *config.Config) (*JumpHostConfig, error) {
+	if cfg.JumpHost == nil {
+		return nil, nil
+	}
+	jumpHost, err := cfg.JumpHost.Resolve()
 	if err != nil {
-		fmt.Printf("获取Pod列表失败: %v\n", err)
-		return
+		return nil, err
 	}
+	return &JumpHostConfig{
+		Ip:       jumpHost.Ip,
+		Port:     strconv.Itoa(jumpHost.Port),
+		Username: jumpHost.Username,
+		Password: jumpHost.Password,
+		RootPath: jumpHost.RootPath,
+	}, nil
+}
 
-	var podsDBList []Pod
-	for _, pod := range podList {
-		podsDBList = append(podsDBList, Pod{
-			Environment: envName,
-			ProjectId:   pod.ProjectId,
-			NamespaceId: pod.NamespaceId,
-			WorkloadId:  pod.WorkloadId,
-			State:       pod.State,
-		})
+// GetCloneIgnoreTagWorkload 解析克隆时不更新镜像标签的工作负载列表
+func GetCloneIgnoreTagWorkload(cfg *config.Config) []string {
+	return cfg.CloneIgnoreTagWorkload
+}
+
+// GetEnvironmentFromConfig 根据环境标识创建环境, 同时解析配置中引用的环境变量和文件
+func GetEnvironmentFromConfig(cfg *config.Config, envName string) (*Environment, error) {
+	env, ok := cfg.Environment[envName]
+	if !ok {
+		return nil, fmt.Errorf("找不到环境: %s", envName)
+	}
+	// 解析引用的环境变量和文件
+	env, err := env.Resolve(envName)
+	if err != nil {
+		return nil, err
 	}
 
-	// 插入新的pod数据
-	if err := db.InsertPods(podsDBList); err != nil {
-		fmt.Printf("插入Pod数据失败: %v\n", err)
-		return
+	// 解析nginx配置
+	var nginxConfigs []NginxMap
+	for name, nginx := range env.Nginx {
+		nginxConfigs = append(nginxConfigs, NginxMap{
+			Name:     name,
+			BaseUrl:  nginx.BaseURL,
+			ConfPath: nginx.NginxConf,
+		})
 	}
-}
 
-func GetEnvironmentFromConfig(config map[string]interface{}, envName string) (*Environment, error) {
-	// 从配置中获取environments部分
-	environments, ok := config["environment"].(map[interface{}]interface{})
-	if !ok {
-		fmt.Println("配置中找不到environment部分")
-		return nil, fmt.Errorf("配置中找不到environment部分")
-	}
-
-	// 查找指定的环境
-	for name, envData := range environments {
-		if name.(string) == envName {
-			env := envData.(map[interface{}]interface{})
-			key := env["key"].(map[interface{}]interface{})
-
-			// 解析nginx配置
-			var nginxConfigs []NginxMap
-			if nginxData, exists := env["nginx"].(map[interface{}]interface{}); exists {
-				for Name, nginxConfig := range nginxData {
-					nginx := nginxConfig.(map[interface{}]interface{})
-					nginxConfigs = append(nginxConfigs, NginxMap{
-						Name:     Name.(string),
-						BaseUrl:  nginx["base_url"].(string),
-						ConfPath: nginx["nginx_conf"].(string),
-					})
-				}
-			}
+	// 可选的请求超时时间, 单位秒
+	var timeout time.Duration
+	if env.Timeout > 0 {
+		timeout = time.Duration(env.Timeout) * time.Second
+	}
 
-			return &Environment{
-				ID:        name.(string),
-				Name:      env["name"].(string),
-				BaseURL:   env["base_url"].(string),
-				Project:   env["project"].(string),
-				Ip:        env["ip"].(string),
-				username:  key["name"].(string),
-				password:  key["token"].(string),
-				nginxList: nginxConfigs,
-			}, nil
+	// 旧格式只有一个project, 新格式可以配置多个集群/项目
+	var projects []Project
+	if len(env.Projects) == 0 {
+		projects = append(projects, Project{ID: env.Project, Cluster: ClusterOfProject(env.Project), Name: env.Project})
+	}
+	for _, project := range env.Projects {
+		name := project.Name
+		if name == "" {
+			name = project.ID()
 		}
+		projects = append(projects, Project{ID: project.ID(), Cluster: ClusterOfProject(project.ID()), Name: name})
 	}
 
-	fmt.Printf("找不到环境: %s\n", envName)
-	return nil, fmt.Errorf("找不到环境: %s", envName)
+	return &Environment{
+		ID:        envName,
+		Name:      env.Name,
+		BaseURL:   env.BaseURL,
+		Project:   projects[0].ID,
+		Projects:  projects,
+		Ip:        env.Ip,
+		Timeout:   timeout,
+		username:  env.Key.Name,
+		password:  env.Key.Token,
+		nginxList: nginxConfigs,
+	}, nil
 }
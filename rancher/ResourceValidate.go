@@ -0,0 +1,85 @@
+package rancher
+
+import (
+	"RancherMan/rancher/types/configMaps"
+	"RancherMan/rancher/types/workload"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateResource 检查要导入的资源是否完整, 返回资源类型。Deployment和StatefulSet按workload.Deployment校验,
+// CronJob按workload.CronJob校验, ConfigMap按configMaps.ConfigMap校验, 其他可导入的类型只检查名称
+func ValidateResource(content []byte) (NamespacedResource, error) {
+	var header struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Metadata   struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal(content, &header); err != nil {
+		return NamespacedResource{}, fmt.Errorf("解析YAML失败: %w", err)
+	}
+	if header.Kind == "" || header.APIVersion == "" {
+		return NamespacedResource{}, fmt.Errorf("缺少apiVersion或kind, 不是Kubernetes资源")
+	}
+	resource, ok := ResourceByKind(header.Kind)
+	if !ok {
+		return NamespacedResource{}, fmt.Errorf("不支持导入%s", header.Kind)
+	}
+	if header.Metadata.Name == "" {
+		return resource, fmt.Errorf("%s缺少metadata.name", header.Kind)
+	}
+
+	switch resource {
+	case ResourceDeployment, ResourceStatefulSet:
+		var deployment workload.Deployment
+		if err := yaml.Unmarshal(content, &deployment); err != nil {
+			return resource, fmt.Errorf("%s %s 的格式不正确: %w", header.Kind, header.Metadata.Name, err)
+		}
+		return resource, validateDeployment(deployment)
+	case ResourceCronJob:
+		var cronJob workload.CronJob
+		if err := yaml.Unmarshal(content, &cronJob); err != nil {
+			return resource, fmt.Errorf("CronJob %s 的格式不正确: %w", header.Metadata.Name, err)
+		}
+		if cronJob.Spec.Schedule == "" {
+			return resource, fmt.Errorf("%s缺少spec.schedule", header.Metadata.Name)
+		}
+		return resource, validateContainers(header.Metadata.Name, cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers)
+	case ResourceConfigMap:
+		var configMap configMaps.ConfigMap
+		if err := yaml.Unmarshal(content, &configMap); err != nil {
+			return resource, fmt.Errorf("ConfigMap %s 的格式不正确: %w", header.Metadata.Name, err)
+		}
+	}
+	return resource, nil
+}
+
+// validateDeployment 检查工作负载的选择器和容器
+func validateDeployment(deployment workload.Deployment) error {
+	name := deployment.Metadata.Name
+	if deployment.Spec.Selector == nil || len(deployment.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("%s缺少spec.selector.matchLabels", name)
+	}
+	for key, value := range deployment.Spec.Selector.MatchLabels {
+		if deployment.Spec.Template.Metadata.Labels[key] != value {
+			return fmt.Errorf("%s的Pod模板标签与选择器 %s=%s 不匹配", name, key, value)
+		}
+	}
+	return validateContainers(name, deployment.Spec.Template.Spec.Containers)
+}
+
+// validateContainers 检查Pod模板中至少有一个容器, 且每个容器都有名称和镜像
+func validateContainers(name string, containers []workload.Container) error {
+	if len(containers) == 0 {
+		return fmt.Errorf("%s没有容器", name)
+	}
+	for i, container := range containers {
+		if container.Name == "" || container.Image == "" {
+			return fmt.Errorf("%s的第%d个容器缺少名称或镜像", name, i+1)
+		}
+	}
+	return nil
+}
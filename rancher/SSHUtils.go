@@ -2,6 +2,7 @@ package rancher
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -52,14 +53,14 @@ func ListUploadConfig(jumpHostConfig *JumpHostConfig, batchSize int, listener Pr
 	// 连接到跳板机
 	client, err := connectToJumpHost(jumpHostConfig)
 	if err != nil {
-		fmt.Printf("连接跳板机失败: %v\n", err)
+		fmt.Fprintf(os.Stderr, "连接跳板机失败: %v\n", err)
 		return
 	}
 	defer client.Close()
 
 	session, err := client.NewSession()
 	if err != nil {
-		fmt.Printf("创建SSH会话失败: %v\n", err)
+		fmt.Fprintf(os.Stderr, "创建SSH会话失败: %v\n", err)
 		return
 	}
 	defer session.Close()
@@ -77,20 +78,20 @@ func ListUploadConfig(jumpHostConfig *JumpHostConfig, batchSize int, listener Pr
 
 	// 请求伪终端
 	if err := session.RequestPty("xterm", 80, 40, modes); err != nil {
-		fmt.Printf("请求PTY失败: %v\n", err)
+		fmt.Fprintf(os.Stderr, "请求PTY失败: %v\n", err)
 		return
 	}
 
 	output, err := session.Output(cmd)
 	if err != nil && len(output) == 0 {
-		fmt.Printf("执行find命令失败: %v\n", err)
-		fmt.Printf("错误输出: %s\n", string(output))
+		fmt.Fprintf(os.Stderr, "执行find命令失败: %v\n", err)
+		fmt.Fprintf(os.Stderr, "错误输出: %s\n", string(output))
 		return
 	}
 
 	// 如果有输出，继续处理，不管是否有错误
 	if len(output) == 0 {
-		fmt.Println("命令执行成功但没有输出")
+		fmt.Fprintln(os.Stderr, "命令执行成功但没有输出")
 		return
 	}
 
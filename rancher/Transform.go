@@ -0,0 +1,495 @@
+package rancher
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Transform 对一个资源的改写步骤。资源是YAML解码得到的完整对象树, 改写时只修改涉及的字段,
+// 其余字段原样保留
+type Transform func(object map[string]interface{}) error
+
+// Pipeline 按顺序执行的改写步骤
+type Pipeline []Transform
+
+// Apply 依次执行所有改写步骤
+func (p Pipeline) Apply(object map[string]interface{}) error {
+	for _, transform := range p {
+		if err := transform(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyYaml 解码YAML, 执行改写后重新编码
+func (p Pipeline) ApplyYaml(content []byte) ([]byte, error) {
+	var object map[string]interface{}
+	if err := yaml.Unmarshal(content, &object); err != nil {
+		return nil, fmt.Errorf("解析YAML失败: %w", err)
+	}
+	if object == nil {
+		return nil, fmt.Errorf("YAML内容为空")
+	}
+	if err := p.Apply(object); err != nil {
+		return nil, err
+	}
+	yamlData, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("写入YAML失败: %w", err)
+	}
+	return yamlData, nil
+}
+
+// RenameNamespace 将资源移动到新的命名空间, 同时改写标签和选择器中Rancher生成的workloadselector值
+// (如 deployment-ns-name) 以及Service注解中形如 "deployment:ns:name" 的工作负载ID。
+// Service的selector指向各种类型的工作负载, 因此替换所有类型的前缀。环境变量、ConfigMap数据等其他字段不修改
+func RenameNamespace(from string, to string) Transform {
+	var pairs []string
+	for _, kind := range []string{KindDeployment, KindStatefulSet, KindDaemonSet, KindCronJob, KindJob} {
+		pairs = append(pairs, WorkloadSelectorPrefix(kind, from), WorkloadSelectorPrefix(kind, to))
+	}
+	pairs = append(pairs, ":"+from+":", ":"+to+":")
+	replacer := strings.NewReplacer(pairs...)
+	return func(object map[string]interface{}) error {
+		metadata := ensureMap(object, "metadata")
+		metadata["namespace"] = to
+		if from == "" || from == to {
+			return nil
+		}
+		for _, labels := range labelMaps(object) {
+			for key, value := range labels {
+				if text, ok := value.(string); ok {
+					labels[key] = replacer.Replace(text)
+				}
+			}
+		}
+		for _, selector := range labelSelectors(object) {
+			expressions, _ := selector["matchExpressions"].([]interface{})
+			for _, item := range expressions {
+				expression, _ := item.(map[string]interface{})
+				values, _ := expression["values"].([]interface{})
+				for i, value := range values {
+					if text, ok := value.(string); ok {
+						values[i] = replacer.Replace(text)
+					}
+				}
+			}
+		}
+		if annotations, ok := nestedMap(object, "metadata", "annotations"); ok {
+			if ids, ok := annotations[targetWorkloadIdsAnnotation].(string); ok {
+				annotations[targetWorkloadIdsAnnotation] = replacer.Replace(ids)
+			}
+		}
+		return nil
+	}
+}
+
+// targetWorkloadIdsAnnotation Rancher在Service上记录目标工作负载ID的注解
+const targetWorkloadIdsAnnotation = "field.cattle.io/targetWorkloadIds"
+
+// labelMaps 返回资源中所有键值形式的标签和选择器: 资源和Pod模板的标签、Service的selector、
+// 工作负载selector中的matchLabels, CronJob还包括jobTemplate中的对应字段
+func labelMaps(object map[string]interface{}) []map[string]interface{} {
+	var maps []map[string]interface{}
+	add := func(root map[string]interface{}, path ...string) {
+		if labels, ok := nestedMap(root, path...); ok {
+			maps = append(maps, labels)
+		}
+	}
+	add(object, "metadata", "labels")
+	if object["kind"] == ResourceService.Kind {
+		add(object, "spec", "selector")
+	}
+	for _, selector := range labelSelectors(object) {
+		add(selector, "matchLabels")
+	}
+	if jobTemplate, ok := nestedMap(object, "spec", "jobTemplate"); ok {
+		add(jobTemplate, "metadata", "labels")
+	}
+	if template, ok := podTemplate(object); ok {
+		add(template, "metadata", "labels")
+	}
+	return maps
+}
+
+// labelSelectors 返回工作负载的LabelSelector(包含matchLabels和matchExpressions), CronJob的在jobTemplate中
+func labelSelectors(object map[string]interface{}) []map[string]interface{} {
+	if object["kind"] == ResourceService.Kind {
+		return nil
+	}
+	var selectors []map[string]interface{}
+	for _, path := range [][]string{{"spec", "selector"}, {"spec", "jobTemplate", "spec", "selector"}} {
+		if selector, ok := nestedMap(object, path...); ok {
+			selectors = append(selectors, selector)
+		}
+	}
+	return selectors
+}
+
+// RetagImage 将与image同一次发布的容器改为使用新标签, image为空时改写所有容器。同一次发布指镜像与image相同,
+// 或与image在同一仓库目录下且标签相同, 如 registry/team/web:1.0 的初始化容器 registry/team/web-migrate:1.0;
+// 其他边车容器(如 envoyproxy/envoy)有各自的版本, 保持不变。使用摘要(@sha256:...)固定版本的镜像也保持不变
+func RetagImage(image string, tag string) Transform {
+	return func(object map[string]interface{}) error {
+		eachContainer(object, func(container map[string]interface{}) {
+			current, _ := container["image"].(string)
+			if current == "" || strings.Contains(current, "@") || (image != "" && !sameRelease(current, image)) {
+				return
+			}
+			container["image"] = imageRepository(current) + ":" + tag
+		})
+		return nil
+	}
+}
+
+// RetagWorkloadRelease 与RetagImage相同, 以资源中的主容器的镜像作为image。主容器的查找方式与同步时相同:
+// 名称与工作负载名称相同的容器, 没有时为第一个容器。克隆整个命名空间和导入本地文件时只有资源内容, 没有同步的容器数据
+func RetagWorkloadRelease(workloadName string, tag string) Transform {
+	return func(object map[string]interface{}) error {
+		image := mainContainerImage(object, workloadName)
+		if image == "" {
+			return nil
+		}
+		return RetagImage(image, tag)(object)
+	}
+}
+
+// mainContainerImage 返回Pod模板中主容器的镜像, 没有容器时为空
+func mainContainerImage(object map[string]interface{}, workloadName string) string {
+	template, ok := podTemplate(object)
+	if !ok {
+		return ""
+	}
+	spec, ok := nestedMap(template, "spec")
+	if !ok {
+		return ""
+	}
+	containers, _ := spec["containers"].([]interface{})
+	var image string
+	for i, item := range containers {
+		container, _ := item.(map[string]interface{})
+		name, _ := container["name"].(string)
+		if i == 0 || name == workloadName {
+			image, _ = container["image"].(string)
+		}
+		if name == workloadName {
+			break
+		}
+	}
+	return image
+}
+
+// sameRelease 两个镜像是否属于同一次发布: 镜像相同, 或仓库目录和标签都相同
+func sameRelease(image string, other string) bool {
+	if image == other {
+		return true
+	}
+	tag, otherTag := imageTag(image), imageTag(other)
+	if tag == "" || otherTag == "" {
+		// 没有标签或使用摘要时无法判断版本
+		return false
+	}
+	repository, otherRepository := imageRepository(image), imageRepository(other)
+	directory := repository[:strings.LastIndex(repository, "/")+1]
+	otherDirectory := otherRepository[:strings.LastIndex(otherRepository, "/")+1]
+	// 没有仓库目录的镜像(如 nginx:1.0)多为公共镜像, 只按镜像相同判断
+	return directory != "" && directory == otherDirectory && tag == otherTag
+}
+
+// RewriteRegistry 将以from开头的镜像仓库地址替换为to, 如 registry.old.com/team -> registry.new.com/team
+func RewriteRegistry(from string, to string) Transform {
+	from = strings.TrimSuffix(from, "/") + "/"
+	to = strings.TrimSuffix(to, "/") + "/"
+	return func(object map[string]interface{}) error {
+		eachContainer(object, func(container map[string]interface{}) {
+			if current, ok := container["image"].(string); ok && strings.HasPrefix(current, from) {
+				container["image"] = to + strings.TrimPrefix(current, from)
+			}
+		})
+		return nil
+	}
+}
+
+// SetReplicas 设置副本数, 只对有spec.replicas的类型生效
+func SetReplicas(replicas int) Transform {
+	return func(object map[string]interface{}) error {
+		if replicas < 0 {
+			return fmt.Errorf("无效的副本数: %d", replicas)
+		}
+		kind, _ := object["kind"].(string)
+		if !IsScalable(kind) {
+			return nil
+		}
+		ensureMap(object, "spec")["replicas"] = replicas
+		return nil
+	}
+}
+
+// serverMetadata 由服务端维护、导入时不能携带的metadata字段
+var serverMetadata = []string{"uid", "resourceVersion", "creationTimestamp", "generation", "selfLink", "managedFields", "ownerReferences"}
+
+// StripStatus 删除status、服务端维护的metadata字段, 以及Rancher和kubectl添加在资源上的注解。
+// Pod模板上的注解(如field.cattle.io/ports)用于生成端口映射, 只删除重新部署的时间戳
+func StripStatus() Transform {
+	return func(object map[string]interface{}) error {
+		delete(object, "status")
+		if metadata, ok := nestedMap(object, "metadata"); ok {
+			for _, field := range serverMetadata {
+				delete(metadata, field)
+			}
+			if annotations, ok := nestedMap(metadata, "annotations"); ok {
+				for key := range annotations {
+					if strings.HasPrefix(key, "cattle.io/") || strings.HasPrefix(key, "field.cattle.io/") ||
+						key == "deployment.kubernetes.io/revision" || key == "kubectl.kubernetes.io/last-applied-configuration" {
+						delete(annotations, key)
+					}
+				}
+				if len(annotations) == 0 {
+					delete(metadata, "annotations")
+				}
+			}
+		}
+		if template, ok := podTemplate(object); ok {
+			if annotations, ok := nestedMap(template, "metadata", "annotations"); ok {
+				delete(annotations, "cattle.io/timestamp")
+			}
+		}
+		return nil
+	}
+}
+
+// ResetServiceAddresses 删除Service中由集群分配的地址和NodePort, 由目标集群重新分配, 避免端口冲突
+func ResetServiceAddresses() Transform {
+	return func(object map[string]interface{}) error {
+		spec, ok := nestedMap(object, "spec")
+		if !ok {
+			return nil
+		}
+		for _, field := range []string{"clusterIP", "clusterIPs", "healthCheckNodePort"} {
+			delete(spec, field)
+		}
+		ports, _ := spec["ports"].([]interface{})
+		for _, item := range ports {
+			if port, ok := item.(map[string]interface{}); ok {
+				delete(port, "nodePort")
+			}
+		}
+		return nil
+	}
+}
+
+// UnbindVolumeClaim 删除PVC与现有存储卷的绑定信息, 在目标命名空间中按存储类重新申请存储卷
+func UnbindVolumeClaim() Transform {
+	return func(object map[string]interface{}) error {
+		if spec, ok := nestedMap(object, "spec"); ok {
+			delete(spec, "volumeName")
+		}
+		if annotations, ok := nestedMap(object, "metadata", "annotations"); ok {
+			for key := range annotations {
+				if strings.HasPrefix(key, "pv.kubernetes.io/") || strings.HasPrefix(key, "volume.beta.kubernetes.io/") || strings.HasPrefix(key, "volume.kubernetes.io/") {
+					delete(annotations, key)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// jobControllerLabels Job控制器在Job和Pod模板上生成的标签, 值为原Job的uid和名称
+var jobControllerLabels = []string{"controller-uid", "job-name", "batch.kubernetes.io/controller-uid", "batch.kubernetes.io/job-name"}
+
+// ResetJobSelector 删除Job中由控制器生成的selector和标签, 由目标集群按新的uid重新生成, 否则导入时校验失败
+func ResetJobSelector() Transform {
+	return func(object map[string]interface{}) error {
+		if object["kind"] != ResourceJob.Kind {
+			return nil
+		}
+		spec, ok := nestedMap(object, "spec")
+		if !ok {
+			return nil
+		}
+		delete(spec, "selector")
+		delete(spec, "manualSelector")
+		for _, path := range [][]string{{"metadata", "labels"}, {"spec", "template", "metadata", "labels"}} {
+			if labels, ok := nestedMap(object, path...); ok {
+				for _, key := range jobControllerLabels {
+					delete(labels, key)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// RedactedValue 脱敏后Secret中每个键的占位值
+const RedactedValue = "<redacted>"
+
+// RedactSecret 将Secret的值替换为占位符, 只保留键名。占位符写入stringData, 导出的文件可以直接阅读,
+// 导入前需要填写真实的值
+func RedactSecret() Transform {
+	return func(object map[string]interface{}) error {
+		if object["kind"] != ResourceSecret.Kind {
+			return nil
+		}
+		redacted := map[string]interface{}{}
+		for _, field := range []string{"data", "stringData"} {
+			if values, ok := nestedMap(object, field); ok {
+				for key := range values {
+					redacted[key] = RedactedValue
+				}
+			}
+			delete(object, field)
+		}
+		if len(redacted) > 0 {
+			object["stringData"] = redacted
+		}
+		return nil
+	}
+}
+
+// NodeSelectorRequirement 节点亲和性的一个匹配条件
+type NodeSelectorRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// DefaultNodeAffinity 克隆Deployment时默认添加的节点亲和性, 调度到role=node的节点
+var DefaultNodeAffinity = NodeSelectorRequirement{Key: "role", Operator: "In", Values: []string{"node"}}
+
+// ParseNodeRequirement 解析Rancher中工作负载调度规则的节点条件, 支持 key=value、key!=value、
+// key in (a,b)、key notin (a,b)、key>n、key<n、key 和 !key
+func ParseNodeRequirement(expression string) (NodeSelectorRequirement, error) {
+	expression = strings.TrimSpace(expression)
+	fields := strings.Fields(expression)
+	if len(fields) >= 3 && (fields[1] == "in" || fields[1] == "notin") {
+		list := strings.TrimSpace(strings.Join(fields[2:], " "))
+		if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+			return NodeSelectorRequirement{}, fmt.Errorf("无效的节点条件: %s", expression)
+		}
+		var values []string
+		for _, value := range strings.Split(strings.Trim(list, "()"), ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		operator := "In"
+		if fields[1] == "notin" {
+			operator = "NotIn"
+		}
+		return NodeSelectorRequirement{Key: fields[0], Operator: operator, Values: values}, nil
+	}
+	for _, item := range []struct{ symbol, operator string }{{"!=", "NotIn"}, {"==", "In"}, {"=", "In"}, {">", "Gt"}, {"<", "Lt"}} {
+		if key, value, found := strings.Cut(expression, item.symbol); found {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if key == "" || value == "" {
+				return NodeSelectorRequirement{}, fmt.Errorf("无效的节点条件: %s", expression)
+			}
+			return NodeSelectorRequirement{Key: key, Operator: item.operator, Values: []string{value}}, nil
+		}
+	}
+	if expression == "" || len(fields) > 1 {
+		return NodeSelectorRequirement{}, fmt.Errorf("无效的节点条件: %s", expression)
+	}
+	if key, found := strings.CutPrefix(expression, "!"); found {
+		return NodeSelectorRequirement{Key: key, Operator: "DoesNotExist"}, nil
+	}
+	return NodeSelectorRequirement{Key: expression, Operator: "Exists"}, nil
+}
+
+// InjectNodeAffinity 在Pod模板没有必需的节点亲和性时添加一个, 已有时保持不变
+func InjectNodeAffinity(requirement NodeSelectorRequirement) Transform {
+	return func(object map[string]interface{}) error {
+		template, ok := podTemplate(object)
+		if !ok {
+			return nil
+		}
+		required := ensureMap(ensureMap(ensureMap(ensureMap(template, "spec"), "affinity"), "nodeAffinity"), "requiredDuringSchedulingIgnoredDuringExecution")
+		if terms, ok := required["nodeSelectorTerms"].([]interface{}); ok && len(terms) > 0 {
+			return nil
+		}
+		required["nodeSelectorTerms"] = nodeSelectorTerms([]NodeSelectorRequirement{requirement})
+		return nil
+	}
+}
+
+// nodeSelectorTerms 生成只有一个匹配项的nodeSelectorTerms, 所有条件都需要满足
+func nodeSelectorTerms(requirements []NodeSelectorRequirement) []interface{} {
+	expressions := make([]interface{}, 0, len(requirements))
+	for _, requirement := range requirements {
+		expression := map[string]interface{}{
+			"key":      requirement.Key,
+			"operator": requirement.Operator,
+		}
+		if len(requirement.Values) > 0 {
+			values := make([]interface{}, 0, len(requirement.Values))
+			for _, value := range requirement.Values {
+				values = append(values, value)
+			}
+			expression["values"] = values
+		}
+		expressions = append(expressions, expression)
+	}
+	return []interface{}{
+		map[string]interface{}{"matchExpressions": expressions},
+	}
+}
+
+// podTemplate 返回工作负载的Pod模板, CronJob的模板在jobTemplate中
+func podTemplate(object map[string]interface{}) (map[string]interface{}, bool) {
+	if template, ok := nestedMap(object, "spec", "template"); ok {
+		return template, true
+	}
+	return nestedMap(object, "spec", "jobTemplate", "spec", "template")
+}
+
+// eachContainer 对Pod模板中的每个容器和初始化容器调用fn
+func eachContainer(object map[string]interface{}, fn func(container map[string]interface{})) {
+	template, ok := podTemplate(object)
+	if !ok {
+		return
+	}
+	spec, ok := nestedMap(template, "spec")
+	if !ok {
+		return
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := spec[field].([]interface{})
+		for _, item := range containers {
+			if container, ok := item.(map[string]interface{}); ok {
+				fn(container)
+			}
+		}
+	}
+}
+
+// ensureMap 返回object[key], 不存在时创建
+func ensureMap(object map[string]interface{}, key string) map[string]interface{} {
+	if value, ok := object[key].(map[string]interface{}); ok {
+		return value
+	}
+	value := map[string]interface{}{}
+	object[key] = value
+	return value
+}
+
+// imageRepository 去掉镜像的标签和摘要, 保留仓库地址中的端口
+func imageRepository(image string) string {
+	if atIndex := strings.Index(image, "@"); atIndex >= 0 {
+		image = image[:atIndex]
+	}
+	if colonIndex := strings.LastIndex(image, ":"); colonIndex > strings.LastIndex(image, "/") {
+		return image[:colonIndex]
+	}
+	return image
+}
+
+// imageTag 返回镜像的标签, 没有标签或使用摘要固定版本时为空
+func imageTag(image string) string {
+	repository := imageRepository(image)
+	if repository == image || strings.Contains(image, "@") {
+		return ""
+	}
+	return image[len(repository)+1:]
+}
@@ -0,0 +1,729 @@
+package rancher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseObject 解析测试用的YAML, 缩进使用制表符时替换为空格
+func parseObject(t *testing.T, content string) map[string]interface{} {
+	t.Helper()
+	var object map[string]interface{}
+	if err := yaml.Unmarshal([]byte(strings.ReplaceAll(content, "\t", "    ")), &object); err != nil {
+		t.Fatalf("解析YAML失败: %v", err)
+	}
+	return object
+}
+
+type transformCase struct {
+	name      string
+	transform Transform
+	input     string
+	want      string
+	wantErr   bool
+}
+
+func runTransformCases(t *testing.T, cases []transformCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := Pipeline{tc.transform}.ApplyYaml([]byte(strings.ReplaceAll(tc.input, "\t", "    ")))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回错误, 实际结果:\n%s", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("改写失败: %v", err)
+			}
+			got := parseObject(t, string(output))
+			want := parseObject(t, tc.want)
+			if !reflect.DeepEqual(got, want) {
+				wantYaml, _ := yaml.Marshal(want)
+				t.Errorf("改写结果不一致\n实际:\n%s\n期望:\n%s", output, wantYaml)
+			}
+		})
+	}
+}
+
+func TestStripStatus(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "删除status和服务端字段",
+			transform: StripStatus(),
+			input: `
+kind: Deployment
+metadata:
+	name: web
+	namespace: dev
+	uid: 1234
+	resourceVersion: "99"
+	creationTimestamp: "2024-01-01T00:00:00Z"
+	generation: 3
+	ownerReferences: [{kind: ReplicaSet}]
+	annotations:
+		cattle.io/creator: norman
+		field.cattle.io/publicEndpoints: "[]"
+		deployment.kubernetes.io/revision: "3"
+		kubectl.kubernetes.io/last-applied-configuration: "{}"
+		team: backend
+spec:
+	template:
+		metadata:
+			annotations:
+				cattle.io/timestamp: "2024-01-01T00:00:00Z"
+				field.cattle.io/ports: "[]"
+status:
+	replicas: 1
+`,
+			want: `
+kind: Deployment
+metadata:
+	name: web
+	namespace: dev
+	annotations:
+		team: backend
+spec:
+	template:
+		metadata:
+			annotations:
+				field.cattle.io/ports: "[]"
+`,
+		},
+		{
+			name:      "注解全部删除时去掉annotations",
+			transform: StripStatus(),
+			input: `
+kind: ConfigMap
+metadata:
+	name: app
+	annotations:
+		cattle.io/creator: norman
+data:
+	a: "1"
+`,
+			want: `
+kind: ConfigMap
+metadata:
+	name: app
+data:
+	a: "1"
+`,
+		},
+	})
+}
+
+func TestRenameNamespace(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "改写workloadselector标签和选择器",
+			transform: RenameNamespace("dev", "test"),
+			input: `
+kind: Deployment
+metadata:
+	name: web
+	namespace: dev
+	labels:
+		workload.user.cattle.io/workloadselector: deployment-dev-web
+		app: web
+spec:
+	selector:
+		matchLabels:
+			workload.user.cattle.io/workloadselector: deployment-dev-web
+		matchExpressions:
+			- key: workload.user.cattle.io/workloadselector
+			  operator: In
+			  values: [deployment-dev-web]
+	template:
+		metadata:
+			labels:
+				workload.user.cattle.io/workloadselector: deployment-dev-web
+		spec:
+			containers:
+				- name: web
+				  image: web:1.0
+				  env:
+					- name: SELECTOR
+					  value: deployment-dev-web
+					- name: WORKLOAD_ID
+					  value: deployment:dev:web
+`,
+			want: `
+kind: Deployment
+metadata:
+	name: web
+	namespace: test
+	labels:
+		workload.user.cattle.io/workloadselector: deployment-test-web
+		app: web
+spec:
+	selector:
+		matchLabels:
+			workload.user.cattle.io/workloadselector: deployment-test-web
+		matchExpressions:
+			- key: workload.user.cattle.io/workloadselector
+			  operator: In
+			  values: [deployment-test-web]
+	template:
+		metadata:
+			labels:
+				workload.user.cattle.io/workloadselector: deployment-test-web
+		spec:
+			containers:
+				- name: web
+				  image: web:1.0
+				  env:
+					- name: SELECTOR
+					  value: deployment-dev-web
+					- name: WORKLOAD_ID
+					  value: deployment:dev:web
+`,
+		},
+		{
+			name:      "Service的selector和目标工作负载注解",
+			transform: RenameNamespace("dev", "test"),
+			input: `
+kind: Service
+metadata:
+	name: web
+	namespace: dev
+	annotations:
+		field.cattle.io/targetWorkloadIds: '["statefulset:dev:web"]'
+		note: deployment:dev:web
+spec:
+	selector:
+		workload.user.cattle.io/workloadselector: statefulSet-dev-web
+`,
+			want: `
+kind: Service
+metadata:
+	name: web
+	namespace: test
+	annotations:
+		field.cattle.io/targetWorkloadIds: '["statefulset:test:web"]'
+		note: deployment:dev:web
+spec:
+	selector:
+		workload.user.cattle.io/workloadselector: statefulSet-test-web
+`,
+		},
+		{
+			name:      "CronJob的jobTemplate",
+			transform: RenameNamespace("dev", "test"),
+			input: `
+kind: CronJob
+metadata:
+	name: clean
+	namespace: dev
+spec:
+	jobTemplate:
+		metadata:
+			labels:
+				workload.user.cattle.io/workloadselector: cronJob-dev-clean
+		spec:
+			template:
+				metadata:
+					labels:
+						workload.user.cattle.io/workloadselector: cronJob-dev-clean
+`,
+			want: `
+kind: CronJob
+metadata:
+	name: clean
+	namespace: test
+spec:
+	jobTemplate:
+		metadata:
+			labels:
+				workload.user.cattle.io/workloadselector: cronJob-test-clean
+		spec:
+			template:
+				metadata:
+					labels:
+						workload.user.cattle.io/workloadselector: cronJob-test-clean
+`,
+		},
+		{
+			name:      "不修改ConfigMap的数据",
+			transform: RenameNamespace("dev", "test"),
+			input: `
+kind: ConfigMap
+metadata:
+	name: app
+	namespace: dev
+data:
+	selector: deployment-dev-web
+	upstream: http://web.dev:8080
+	id: deployment:dev:web
+`,
+			want: `
+kind: ConfigMap
+metadata:
+	name: app
+	namespace: test
+data:
+	selector: deployment-dev-web
+	upstream: http://web.dev:8080
+	id: deployment:dev:web
+`,
+		},
+		{
+			name:      "没有原命名空间时只设置namespace",
+			transform: RenameNamespace("", "test"),
+			input: `
+kind: Deployment
+metadata:
+	name: web
+	labels:
+		workload.user.cattle.io/workloadselector: deployment-dev-web
+`,
+			want: `
+kind: Deployment
+metadata:
+	name: web
+	namespace: test
+	labels:
+		workload.user.cattle.io/workloadselector: deployment-dev-web
+`,
+		},
+	})
+}
+
+// podYaml 生成包含一个初始化容器、主容器和边车容器的Deployment
+const podYaml = `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web:1.0
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:1.0
+				- name: proxy
+				  image: envoyproxy/envoy:v1.27.0
+`
+
+func TestRetagImage(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "只改写指定镜像的容器",
+			transform: RetagImage("registry.old.com/team/web:1.0", "2.0"),
+			input:     podYaml,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web:2.0
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:2.0
+				- name: proxy
+				  image: envoyproxy/envoy:v1.27.0
+`,
+		},
+		{
+			name:      "镜像为空时改写所有容器",
+			transform: RetagImage("", "2.0"),
+			input:     podYaml,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web:2.0
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:2.0
+				- name: proxy
+				  image: envoyproxy/envoy:2.0
+`,
+		},
+		{
+			name:      "使用摘要的镜像保持不变",
+			transform: RetagImage("registry.old.com/team/web:1.0", "2.0"),
+			input: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web-migrate@sha256:4f1c2d
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:1.0
+				- name: agent
+				  image: registry.old.com/team/agent:1.0@sha256:9a8b7c
+`,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.old.com/team/web-migrate@sha256:4f1c2d
+			containers:
+				- name: web
+				  image: registry.old.com/team/web:2.0
+				- name: agent
+				  image: registry.old.com/team/agent:1.0@sha256:9a8b7c
+`,
+		},
+		{
+			name:      "保留仓库地址中的端口",
+			transform: RetagImage("", "2.0"),
+			input: `
+kind: CronJob
+spec:
+	jobTemplate:
+		spec:
+			template:
+				spec:
+					containers:
+						- name: clean
+						  image: registry.local:5000/clean
+`,
+			want: `
+kind: CronJob
+spec:
+	jobTemplate:
+		spec:
+			template:
+				spec:
+					containers:
+						- name: clean
+						  image: registry.local:5000/clean:2.0
+`,
+		},
+	})
+}
+
+func TestRewriteRegistry(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "只替换匹配的仓库地址",
+			transform: RewriteRegistry("registry.old.com/team", "registry.new.com/team/"),
+			input:     podYaml,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			initContainers:
+				- name: migrate
+				  image: registry.new.com/team/web:1.0
+			containers:
+				- name: web
+				  image: registry.new.com/team/web:1.0
+				- name: proxy
+				  image: envoyproxy/envoy:v1.27.0
+`,
+		},
+		{
+			name:      "前缀需要匹配完整的路径",
+			transform: RewriteRegistry("registry.old.com/te", "registry.new.com/te"),
+			input:     podYaml,
+			want:      podYaml,
+		},
+	})
+}
+
+func TestSetReplicas(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "Deployment",
+			transform: SetReplicas(3),
+			input:     "kind: Deployment\nspec:\n    replicas: 1\n",
+			want:      "kind: Deployment\nspec:\n    replicas: 3\n",
+		},
+		{
+			name:      "StatefulSet没有副本数时添加",
+			transform: SetReplicas(0),
+			input:     "kind: StatefulSet\nspec: {}\n",
+			want:      "kind: StatefulSet\nspec:\n    replicas: 0\n",
+		},
+		{
+			name:      "DaemonSet不修改",
+			transform: SetReplicas(3),
+			input:     "kind: DaemonSet\nspec: {}\n",
+			want:      "kind: DaemonSet\nspec: {}\n",
+		},
+		{
+			name:      "负数",
+			transform: SetReplicas(-1),
+			input:     "kind: Deployment\nspec: {}\n",
+			wantErr:   true,
+		},
+	})
+}
+
+func TestInjectNodeAffinity(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "没有节点亲和性时添加",
+			transform: InjectNodeAffinity(DefaultNodeAffinity),
+			input: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			containers: []
+`,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			containers: []
+			affinity:
+				nodeAffinity:
+					requiredDuringSchedulingIgnoredDuringExecution:
+						nodeSelectorTerms:
+							- matchExpressions:
+								- key: role
+								  operator: In
+								  values: [node]
+`,
+		},
+		{
+			name:      "已有时保持不变",
+			transform: InjectNodeAffinity(DefaultNodeAffinity),
+			input: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			affinity:
+				nodeAffinity:
+					requiredDuringSchedulingIgnoredDuringExecution:
+						nodeSelectorTerms:
+							- matchExpressions:
+								- key: zone
+								  operator: Exists
+`,
+			want: `
+kind: Deployment
+spec:
+	template:
+		spec:
+			affinity:
+				nodeAffinity:
+					requiredDuringSchedulingIgnoredDuringExecution:
+						nodeSelectorTerms:
+							- matchExpressions:
+								- key: zone
+								  operator: Exists
+`,
+		},
+		{
+			name:      "没有Pod模板的资源不修改",
+			transform: InjectNodeAffinity(DefaultNodeAffinity),
+			input:     "kind: Service\nspec:\n    type: ClusterIP\n",
+			want:      "kind: Service\nspec:\n    type: ClusterIP\n",
+		},
+	})
+}
+
+func TestResetServiceAddresses(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "删除集群分配的地址和NodePort",
+			transform: ResetServiceAddresses(),
+			input: `
+kind: Service
+spec:
+	type: NodePort
+	clusterIP: 10.43.0.10
+	clusterIPs: [10.43.0.10]
+	healthCheckNodePort: 30100
+	ports:
+		- name: http
+		  port: 80
+		  targetPort: 8080
+		  nodePort: 30080
+`,
+			want: `
+kind: Service
+spec:
+	type: NodePort
+	ports:
+		- name: http
+		  port: 80
+		  targetPort: 8080
+`,
+		},
+	})
+}
+
+func TestUnbindVolumeClaim(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "删除存储卷绑定",
+			transform: UnbindVolumeClaim(),
+			input: `
+kind: PersistentVolumeClaim
+metadata:
+	name: data
+	annotations:
+		pv.kubernetes.io/bind-completed: "yes"
+		volume.beta.kubernetes.io/storage-provisioner: rancher.io/local-path
+		volume.kubernetes.io/selected-node: node1
+		team: backend
+spec:
+	storageClassName: local-path
+	volumeName: pvc-1234
+	resources:
+		requests:
+			storage: 1Gi
+`,
+			want: `
+kind: PersistentVolumeClaim
+metadata:
+	name: data
+	annotations:
+		team: backend
+spec:
+	storageClassName: local-path
+	resources:
+		requests:
+			storage: 1Gi
+`,
+		},
+	})
+}
+
+func TestRedactSecret(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "data和stringData都替换为占位符",
+			transform: RedactSecret(),
+			input: `
+kind: Secret
+type: Opaque
+data:
+	password: cGFzc3dvcmQ=
+stringData:
+	user: admin
+`,
+			want: `
+kind: Secret
+type: Opaque
+stringData:
+	password: <redacted>
+	user: <redacted>
+`,
+		},
+		{
+			name:      "其他类型不修改",
+			transform: RedactSecret(),
+			input:     "kind: ConfigMap\ndata:\n    password: plain\n",
+			want:      "kind: ConfigMap\ndata:\n    password: plain\n",
+		},
+	})
+}
+
+func TestSameRelease(t *testing.T) {
+	cases := []struct {
+		image string
+		other string
+		want  bool
+	}{
+		{"registry.old.com/team/web:1.0", "registry.old.com/team/web:1.0", true},
+		{"registry.old.com/team/web-migrate:1.0", "registry.old.com/team/web:1.0", true},
+		{"registry.old.com/team/web-migrate:0.9", "registry.old.com/team/web:1.0", false},
+		{"registry.old.com/other/web:1.0", "registry.old.com/team/web:1.0", false},
+		{"envoyproxy/envoy:v1.27.0", "registry.old.com/team/web:1.0", false},
+		{"registry.local:5000/team/job", "registry.local:5000/team/web", false},
+		{"redis:1.0", "web:1.0", false},
+		{"registry.old.com/team/web-migrate@sha256:4f1c2d", "registry.old.com/team/web@sha256:4f1c2d", false},
+		{"registry.old.com/team/web-migrate:1.0@sha256:4f1c2d", "registry.old.com/team/web:1.0", false},
+	}
+	for _, tc := range cases {
+		if got := sameRelease(tc.image, tc.other); got != tc.want {
+			t.Errorf("sameRelease(%q, %q) = %v, 期望 %v", tc.image, tc.other, got, tc.want)
+		}
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	cases := []struct {
+		image      string
+		repository string
+		tag        string
+	}{
+		{"registry.old.com/team/web:1.0", "registry.old.com/team/web", "1.0"},
+		{"registry.local:5000/team/web", "registry.local:5000/team/web", ""},
+		{"registry.local:5000/team/web:1.0", "registry.local:5000/team/web", "1.0"},
+		{"registry.old.com/team/web@sha256:4f1c2d", "registry.old.com/team/web", ""},
+		{"registry.old.com/team/web:1.0@sha256:4f1c2d", "registry.old.com/team/web", ""},
+		{"nginx", "nginx", ""},
+	}
+	for _, tc := range cases {
+		if got := imageRepository(tc.image); got != tc.repository {
+			t.Errorf("imageRepository(%q) = %q, 期望 %q", tc.image, got, tc.repository)
+		}
+		if got := imageTag(tc.image); got != tc.tag {
+			t.Errorf("imageTag(%q) = %q, 期望 %q", tc.image, got, tc.tag)
+		}
+	}
+}
+
+func TestResetJobSelector(t *testing.T) {
+	runTransformCases(t, []transformCase{
+		{
+			name:      "删除控制器生成的selector和标签",
+			transform: ResetJobSelector(),
+			input: `
+kind: Job
+metadata:
+	name: init-db
+	labels:
+		controller-uid: 1234
+		job-name: init-db
+		app: init-db
+spec:
+	selector:
+		matchLabels:
+			controller-uid: 1234
+	template:
+		metadata:
+			labels:
+				controller-uid: 1234
+				batch.kubernetes.io/controller-uid: 1234
+				batch.kubernetes.io/job-name: init-db
+				job-name: init-db
+				app: init-db
+`,
+			want: `
+kind: Job
+metadata:
+	name: init-db
+	labels:
+		app: init-db
+spec:
+	template:
+		metadata:
+			labels:
+				app: init-db
+`,
+		},
+		{
+			name:      "其他类型不修改",
+			transform: ResetJobSelector(),
+			input:     "kind: Deployment\nspec:\n    selector:\n        matchLabels:\n            job-name: a\n",
+			want:      "kind: Deployment\nspec:\n    selector:\n        matchLabels:\n            job-name: a\n",
+		},
+	})
+}
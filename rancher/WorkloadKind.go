@@ -0,0 +1,75 @@
+package rancher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 工作负载类型, 取值与Rancher API中workload的type字段一致
+const (
+	KindDeployment  = "deployment"
+	KindStatefulSet = "statefulSet"
+	KindDaemonSet   = "daemonSet"
+	KindCronJob     = "cronJob"
+	KindJob         = "job"
+)
+
+// UnsupportedKindError 表示该类型的工作负载不支持某个操作
+type UnsupportedKindError struct {
+	Kind      string
+	Operation string
+}
+
+func (e *UnsupportedKindError) Error() string {
+	return fmt.Sprintf("%s不支持%s操作", KindLabel(e.Kind), e.Operation)
+}
+
+// NormalizeKind 规范化工作负载类型, 兼容旧数据中为空的情况
+func NormalizeKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case "", "deployment":
+		return KindDeployment
+	case "statefulset":
+		return KindStatefulSet
+	case "daemonset":
+		return KindDaemonSet
+	case "cronjob":
+		return KindCronJob
+	case "job":
+		return KindJob
+	}
+	return kind
+}
+
+// KindLabel 返回工作负载类型的中文名称
+func KindLabel(kind string) string {
+	switch NormalizeKind(kind) {
+	case KindDeployment:
+		return "无状态"
+	case KindStatefulSet:
+		return "有状态"
+	case KindDaemonSet:
+		return "守护进程集"
+	case KindCronJob:
+		return "定时任务"
+	case KindJob:
+		return "任务"
+	}
+	return kind
+}
+
+// workloadID 生成Rancher中工作负载的ID, 如 statefulset:default:mysql
+func workloadID(kind, namespace, name string) string {
+	return fmt.Sprintf("%s:%s:%s", strings.ToLower(NormalizeKind(kind)), namespace, serviceName(name))
+}
+
+// WorkloadSelectorPrefix 返回Rancher生成的workloadselector标签前缀, 如 statefulSet-default-
+func WorkloadSelectorPrefix(kind, namespace string) string {
+	return fmt.Sprintf("%s-%s-", NormalizeKind(kind), namespace)
+}
+
+// IsScalable 该类型是否可以调整副本数
+func IsScalable(kind string) bool {
+	kind = NormalizeKind(kind)
+	return kind == KindDeployment || kind == KindStatefulSet
+}
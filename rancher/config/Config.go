@@ -0,0 +1,270 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 应用配置
+type Config struct {
+	Environment            map[string]EnvironmentConfig `yaml:"environment"`
+	JumpHost               *JumpHostConfig              `yaml:"jump_host,omitempty"`
+	CloneIgnoreTagWorkload []string                     `yaml:"clone_ignore_tag_workload,omitempty"`
+
+	root *yaml.Node // 解析得到的YAML节点, 用于在校验错误中定位行号
+}
+
+// EnvironmentConfig 一个Rancher环境
+type EnvironmentConfig struct {
+	Name     string                 `yaml:"name"`
+	BaseURL  string                 `yaml:"base_url"`
+	Project  string                 `yaml:"project,omitempty"`  // 单个项目的完整ID, 如 c-abcde:p-xyz
+	Projects []ProjectConfig        `yaml:"projects,omitempty"` // 多个集群/项目, 与project二选一
+	Ip       string                 `yaml:"ip,omitempty"`
+	Timeout  int                    `yaml:"timeout,omitempty"` // 单个请求的超时时间, 单位秒
+	Key      KeyConfig              `yaml:"key"`
+	Nginx    map[string]NginxConfig `yaml:"nginx,omitempty"`
+}
+
+// ProjectConfig 环境中的一个集群/项目
+type ProjectConfig struct {
+	Cluster string `yaml:"cluster"`        // 集群ID, 如 c-abcde
+	Project string `yaml:"project"`        // 项目ID, 如 p-xyz, 也可以写完整ID c-abcde:p-xyz
+	Name    string `yaml:"name,omitempty"` // 显示名称, 为空时使用完整项目ID
+}
+
+// ID 返回完整的项目ID
+func (p ProjectConfig) ID() string {
+	if strings.Contains(p.Project, ":") || p.Cluster == "" {
+		return p.Project
+	}
+	return p.Cluster + ":" + p.Project
+}
+
+// KeyConfig Rancher API密钥
+type KeyConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+}
+
+// NginxConfig 用于解析访问路径的nginx反向代理配置
+type NginxConfig struct {
+	BaseURL   string `yaml:"base_url"`
+	NginxConf string `yaml:"nginx_conf"`
+}
+
+// JumpHostConfig 跳板机
+type JumpHostConfig struct {
+	Ip       string `yaml:"ip"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	RootPath string `yaml:"root_path"`
+}
+
+// Problem 配置中的一个问题
+type Problem struct {
+	Path    string // YAML路径, 如 environment.dev.key.token
+	Line    int    // 行号, 0表示无法定位
+	Message string
+}
+
+func (p Problem) String() string {
+	var location string
+	if p.Line > 0 {
+		location = fmt.Sprintf("第%d行 ", p.Line)
+	}
+	if p.Path != "" {
+		return fmt.Sprintf("%s%s: %s", location, p.Path, p.Message)
+	}
+	return location + p.Message
+}
+
+// ValidationError 配置校验失败, 包含所有发现的问题
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = problem.String()
+	}
+	return fmt.Sprintf("配置有%d个问题:\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// typeErrorLine 匹配yaml.TypeError中的行号
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// Parse 解析YAML配置。字段类型错误会和Validate的结果一起以*ValidationError返回,
+// 此时返回的Config中其余字段仍然可用; YAML语法错误时Config为nil。
+func Parse(content []byte) (*Config, error) {
+	config := &Config{}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("配置不是有效的YAML: %w", err)
+	}
+	config.root = &root
+
+	var problems []Problem
+	if len(root.Content) > 0 {
+		err := root.Decode(config)
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, message := range typeErr.Errors {
+				problem := Problem{Message: "类型错误: " + message}
+				if match := typeErrorLine.FindStringSubmatch(message); match != nil {
+					problem.Line, _ = strconv.Atoi(match[1])
+					problem.Message = "类型错误: " + match[2]
+				}
+				problems = append(problems, problem)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("解析配置失败: %w", err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			problems = append(problems, validationErr.Problems...)
+		}
+	}
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].Line < problems[j].Line
+		})
+		return config, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+// Validate 检查必填字段和取值范围, 返回*ValidationError包含所有问题
+func (c *Config) Validate() error {
+	var problems []Problem
+	add := func(message string, path ...string) {
+		problems = append(problems, Problem{
+			Path:    strings.Join(path, "."),
+			Line:    c.line(path...),
+			Message: message,
+		})
+	}
+	required := func(value string, path ...string) {
+		if strings.TrimSpace(value) == "" {
+			add("不能为空", path...)
+		}
+	}
+
+	if len(c.Environment) == 0 {
+		add("至少需要配置一个环境", "environment")
+	}
+	for _, id := range c.EnvironmentNames() {
+		env := c.Environment[id]
+		path := []string{"environment", id}
+		required(env.Name, append(path, "name")...)
+		required(env.BaseURL, append(path, "base_url")...)
+		if env.BaseURL != "" && !IsReference(env.BaseURL) && !strings.HasPrefix(env.BaseURL, "http://") && !strings.HasPrefix(env.BaseURL, "https://") {
+			add("必须以http://或https://开头", append(path, "base_url")...)
+		}
+		if len(env.Projects) == 0 {
+			required(env.Project, append(path, "project")...)
+		} else if env.Project != "" {
+			add("project和projects只能配置一个", append(path, "project")...)
+		}
+		seen := map[string]bool{}
+		for i, project := range env.Projects {
+			projectPath := append(path, "projects", strconv.Itoa(i))
+			required(project.Project, append(projectPath, "project")...)
+			if !strings.Contains(project.Project, ":") {
+				required(project.Cluster, append(projectPath, "cluster")...)
+			}
+			if project.Project != "" {
+				if seen[project.ID()] {
+					add("项目重复", append(projectPath, "project")...)
+				}
+				seen[project.ID()] = true
+			}
+		}
+		required(env.Key.Name, append(path, "key", "name")...)
+		required(env.Key.Token, append(path, "key", "token")...)
+		if env.Timeout < 0 {
+			add("不能小于0", append(path, "timeout")...)
+		}
+		for _, name := range sortedKeys(env.Nginx) {
+			nginx := env.Nginx[name]
+			required(nginx.BaseURL, append(path, "nginx", name, "base_url")...)
+			required(nginx.NginxConf, append(path, "nginx", name, "nginx_conf")...)
+		}
+	}
+	if jumpHost := c.JumpHost; jumpHost != nil {
+		required(jumpHost.Ip, "jump_host", "ip")
+		if jumpHost.Port <= 0 || jumpHost.Port > 65535 {
+			add("必须是1-65535之间的端口号", "jump_host", "port")
+		}
+		required(jumpHost.Username, "jump_host", "username")
+		required(jumpHost.Password, "jump_host", "password")
+		required(jumpHost.RootPath, "jump_host", "root_path")
+	}
+	for i, name := range c.CloneIgnoreTagWorkload {
+		if strings.TrimSpace(name) == "" {
+			add("不能为空", "clone_ignore_tag_workload", strconv.Itoa(i))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// EnvironmentNames 返回所有环境的标识, 按名称排序
+func (c *Config) EnvironmentNames() []string {
+	return sortedKeys(c.Environment)
+}
+
+// line 返回路径在YAML中的行号。路径不存在时返回最近的已存在父节点的行号
+func (c *Config) line(path ...string) int {
+	if c.root == nil || len(c.root.Content) == 0 {
+		return 0
+	}
+	node := c.root.Content[0]
+	line := node.Line
+	for _, key := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(key); err == nil && index < len(node.Content) {
+				next = node.Content[index]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
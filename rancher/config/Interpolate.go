@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 配置中的字符串字段支持引用环境变量和文件, 在创建环境时解析, 便于共享的配置文件中不包含个人密钥:
+//
+//	${NAME}              环境变量NAME的值, 未设置时报错
+//	${NAME:-default}     环境变量NAME未设置或为空时使用default
+//	${file:/path}        文件内容(去掉末尾换行), 路径支持 ~ 表示用户主目录
+//	${file:/path:-dflt}  文件不存在时使用dflt
+//	$${                  表示字面量 ${
+const (
+	referenceStart = "${"
+	referenceEnd   = "}"
+	defaultSep     = ":-"
+	filePrefix     = "file:"
+)
+
+// IsReference 判断值中是否包含引用
+func IsReference(value string) bool {
+	return strings.Contains(value, referenceStart)
+}
+
+// Interpolate 解析值中的所有引用
+func Interpolate(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	var result strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, referenceStart)
+		if start < 0 {
+			result.WriteString(rest)
+			break
+		}
+		// $${ 转义为字面量 ${
+		if start > 0 && rest[start-1] == '$' {
+			result.WriteString(rest[:start-1])
+			result.WriteString(referenceStart)
+			rest = rest[start+len(referenceStart):]
+			continue
+		}
+		result.WriteString(rest[:start])
+		end := strings.Index(rest[start:], referenceEnd)
+		if end < 0 {
+			return "", fmt.Errorf("引用缺少结束的}: %s", rest[start:])
+		}
+		expression := rest[start+len(referenceStart) : start+end]
+		resolved, err := resolveReference(expression)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(resolved)
+		rest = rest[start+end+len(referenceEnd):]
+	}
+	return result.String(), nil
+}
+
+// resolveReference 解析一个引用表达式, 不包含 ${ 和 }
+func resolveReference(expression string) (string, error) {
+	name, defaultValue, hasDefault := strings.Cut(expression, defaultSep)
+	if strings.HasPrefix(name, filePrefix) {
+		path := expandHome(strings.TrimPrefix(name, filePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if hasDefault && os.IsNotExist(err) {
+				return defaultValue, nil
+			}
+			return "", fmt.Errorf("读取文件%s失败: %w", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("引用的环境变量名为空")
+	}
+	value, exists := os.LookupEnv(name)
+	if (!exists || value == "") && hasDefault {
+		return defaultValue, nil
+	}
+	if !exists {
+		return "", fmt.Errorf("环境变量%s未设置", name)
+	}
+	return value, nil
+}
+
+// expandHome 将路径开头的 ~ 替换为用户主目录
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Resolve 返回解析了所有引用的环境配置
+func (e EnvironmentConfig) Resolve(id string) (EnvironmentConfig, error) {
+	resolved := e
+	fields := []struct {
+		path  string
+		value *string
+	}{
+		{"name", &resolved.Name},
+		{"base_url", &resolved.BaseURL},
+		{"project", &resolved.Project},
+		{"ip", &resolved.Ip},
+		{"key.name", &resolved.Key.Name},
+		{"key.token", &resolved.Key.Token},
+	}
+	for _, field := range fields {
+		value, err := Interpolate(*field.value)
+		if err != nil {
+			return e, fmt.Errorf("environment.%s.%s: %w", id, field.path, err)
+		}
+		*field.value = value
+	}
+	if len(e.Projects) > 0 {
+		resolved.Projects = make([]ProjectConfig, len(e.Projects))
+		for i, project := range e.Projects {
+			var err error
+			if project.Cluster, err = Interpolate(project.Cluster); err != nil {
+				return e, fmt.Errorf("environment.%s.projects.%d.cluster: %w", id, i, err)
+			}
+			if project.Project, err = Interpolate(project.Project); err != nil {
+				return e, fmt.Errorf("environment.%s.projects.%d.project: %w", id, i, err)
+			}
+			resolved.Projects[i] = project
+		}
+	}
+	if len(e.Nginx) > 0 {
+		resolved.Nginx = make(map[string]NginxConfig, len(e.Nginx))
+		for name, nginx := range e.Nginx {
+			var err error
+			if nginx.BaseURL, err = Interpolate(nginx.BaseURL); err != nil {
+				return e, fmt.Errorf("environment.%s.nginx.%s.base_url: %w", id, name, err)
+			}
+			if nginx.NginxConf, err = Interpolate(nginx.NginxConf); err != nil {
+				return e, fmt.Errorf("environment.%s.nginx.%s.nginx_conf: %w", id, name, err)
+			}
+			resolved.Nginx[name] = nginx
+		}
+	}
+	return resolved, nil
+}
+
+// Resolve 返回解析了所有引用的跳板机配置
+func (j JumpHostConfig) Resolve() (JumpHostConfig, error) {
+	resolved := j
+	fields := []struct {
+		path  string
+		value *string
+	}{
+		{"ip", &resolved.Ip},
+		{"username", &resolved.Username},
+		{"password", &resolved.Password},
+		{"root_path", &resolved.RootPath},
+	}
+	for _, field := range fields {
+		value, err := Interpolate(*field.value)
+		if err != nil {
+			return j, fmt.Errorf("jump_host.%s: %w", field.path, err)
+		}
+		*field.value = value
+	}
+	return resolved, nil
+}
@@ -0,0 +1,335 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置中的敏感字段(environment.*.key.token 和 jump_host.password)在数据库中加密保存,
+// 只在内存中解密。密钥来自主密码(界面中输入或环境变量)或应用数据目录中的密钥文件,
+// 密钥文件只在用户确认后生成(见CreateKeyFile), 不会自动生成。
+// 引用环境变量或文件的值(见Interpolate)本身不含密钥, 不加密也不隐藏。
+const (
+	// PassphraseEnv 主密码环境变量, 设置后使用主密码派生密钥, 否则使用密钥文件
+	PassphraseEnv = "RANCHERMAN_PASSPHRASE"
+	// Mask 显示配置时敏感字段的占位符, 保存时保持为占位符表示不修改
+	Mask = "****"
+
+	keyFileName     = "secret.key"
+	saltFileName    = "secret.salt"
+	encryptedPrefix = "enc:v1:"
+)
+
+// ErrDecrypt 无法解密配置中的敏感字段
+var ErrDecrypt = errors.New("无法解密配置中的密钥, 请检查主密码或密钥文件")
+
+// ErrNoKey 既没有主密码也没有密钥文件, 无法加解密敏感字段
+var ErrNoKey = errors.New("未设置主密码, 也没有密钥文件")
+
+// passphrase 界面中输入的主密码, 优先于环境变量
+var passphrase string
+
+// SetPassphrase 设置本次运行使用的主密码, 为空时使用环境变量中的主密码或密钥文件
+func SetPassphrase(value string) {
+	passphrase = value
+}
+
+// currentPassphrase 返回界面中输入或环境变量中的主密码
+func currentPassphrase() string {
+	if passphrase != "" {
+		return passphrase
+	}
+	return os.Getenv(PassphraseEnv)
+}
+
+// KeyFile 返回应用数据目录中密钥文件的路径
+func KeyFile(dir string) string {
+	return filepath.Join(dir, keyFileName)
+}
+
+// HasKey 是否已设置主密码或已有密钥文件
+func HasKey(dir string) bool {
+	if currentPassphrase() != "" {
+		return true
+	}
+	_, err := os.Stat(KeyFile(dir))
+	return err == nil
+}
+
+// UsesPassphrase 之前是否使用主密码加密过配置: 有主密码的盐但没有密钥文件
+func UsesPassphrase(dir string) bool {
+	if _, err := os.Stat(KeyFile(dir)); err == nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, saltFileName))
+	return err == nil
+}
+
+// CreateKeyFile 在应用数据目录中生成随机密钥文件并返回其路径, 文件已存在时返回错误
+func CreateKeyFile(dir string) (string, error) {
+	file := KeyFile(dir)
+	if _, err := os.Stat(file); err == nil {
+		return "", fmt.Errorf("密钥文件%s已存在", file)
+	}
+	if _, err := readOrCreateRandom(file, 32); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// SecretBox 使用AES-GCM加解密敏感字段
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox 使用32字节密钥创建SecretBox
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// OpenSecretBox 使用主密码或应用数据目录中的密钥文件创建SecretBox。使用主密码时盐不存在则自动生成,
+// 没有主密码且密钥文件不存在时返回ErrNoKey
+func OpenSecretBox(dir string) (*SecretBox, error) {
+	var key []byte
+	if passphrase := currentPassphrase(); passphrase != "" {
+		salt, err := readOrCreateRandom(filepath.Join(dir, saltFileName), 16)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32); err != nil {
+			return nil, fmt.Errorf("派生密钥失败: %w", err)
+		}
+	} else {
+		var err error
+		if key, err = os.ReadFile(KeyFile(dir)); os.IsNotExist(err) {
+			return nil, ErrNoKey
+		} else if err != nil {
+			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("密钥文件%s已损坏", KeyFile(dir))
+		}
+	}
+	return NewSecretBox(key)
+}
+
+// readOrCreateRandom 读取文件内容, 文件不存在时写入指定长度的随机数据
+func readOrCreateRandom(file string, size int) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err == nil {
+		if len(data) != size {
+			return nil, fmt.Errorf("密钥文件%s已损坏", file)
+		}
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	data = make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return nil, fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	return data, nil
+}
+
+// Encrypt 加密一个值, 已加密的值原样返回
+func (b *SecretBox) Encrypt(value string) (string, error) {
+	if IsEncrypted(value) {
+		return value, nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密一个值, 未加密的值原样返回
+func (b *SecretBox) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonceSize := b.aead.NonceSize()
+	plain, err := b.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted 判断值是否已加密
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptSecrets 加密配置内容中的敏感字段。值为Mask的字段使用previous中对应的值, 表示未修改
+func EncryptSecrets(content []byte, box *SecretBox, previous *Config) ([]byte, error) {
+	return transformSecrets(content, func(envID string, value string) (string, error) {
+		if value == Mask && previous != nil {
+			value = previous.secret(envID)
+		}
+		if value == "" || IsReference(value) {
+			return value, nil
+		}
+		return box.Encrypt(value)
+	})
+}
+
+// DecryptSecrets 解密配置内容中的敏感字段
+func DecryptSecrets(content []byte, box *SecretBox) ([]byte, error) {
+	return transformSecrets(content, func(envID string, value string) (string, error) {
+		return box.Decrypt(value)
+	})
+}
+
+// RestoreMasked 将值为Mask的敏感字段替换为previous中对应的明文
+func RestoreMasked(content []byte, previous *Config) ([]byte, error) {
+	return transformSecrets(content, func(envID string, value string) (string, error) {
+		if value == Mask && previous != nil {
+			return previous.secret(envID), nil
+		}
+		return value, nil
+	})
+}
+
+// MaskSecrets 将配置内容中的敏感字段替换为Mask, 用于在界面上显示
+func MaskSecrets(content []byte) ([]byte, error) {
+	return transformSecrets(content, func(envID string, value string) (string, error) {
+		if value == "" || IsReference(value) {
+			return value, nil
+		}
+		return Mask, nil
+	})
+}
+
+// HasPlainSecrets 判断配置内容中是否有未加密的敏感字段
+func HasPlainSecrets(content []byte) bool {
+	found := false
+	transformSecrets(content, func(envID string, value string) (string, error) {
+		if value != "" && value != Mask && !IsEncrypted(value) && !IsReference(value) {
+			found = true
+		}
+		return value, nil
+	})
+	return found
+}
+
+// HasEncryptedSecrets 判断配置内容中是否有已加密的敏感字段
+func HasEncryptedSecrets(content []byte) bool {
+	found := false
+	transformSecrets(content, func(envID string, value string) (string, error) {
+		if IsEncrypted(value) {
+			found = true
+		}
+		return value, nil
+	})
+	return found
+}
+
+// secret 返回敏感字段的值, envID为空表示跳板机密码
+func (c *Config) secret(envID string) string {
+	if envID == "" {
+		if c.JumpHost == nil {
+			return ""
+		}
+		return c.JumpHost.Password
+	}
+	return c.Environment[envID].Key.Token
+}
+
+// transformSecrets 对每个敏感字段调用fn并替换其值。没有字段被修改时原样返回, 以保留原有格式
+func transformSecrets(content []byte, fn func(envID string, value string) (string, error)) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("配置不是有效的YAML: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return content, nil
+	}
+	changed := false
+	apply := func(envID string, node *yaml.Node) error {
+		if node == nil || node.Kind != yaml.ScalarNode {
+			return nil
+		}
+		value, err := fn(envID, node.Value)
+		if err != nil {
+			return err
+		}
+		if value != node.Value {
+			node.Value = value
+			// 避免加密后的值或占位符被解析为其他类型
+			node.Tag = "!!str"
+			node.Style = yaml.DoubleQuotedStyle
+			changed = true
+		}
+		return nil
+	}
+
+	document := root.Content[0]
+	if environments := mappingValue(document, "environment"); environments != nil && environments.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(environments.Content); i += 2 {
+			envID := environments.Content[i].Value
+			key := mappingValue(environments.Content[i+1], "key")
+			if err := apply(envID, mappingValue(key, "token")); err != nil {
+				return nil, fmt.Errorf("environment.%s.key.token: %w", envID, err)
+			}
+		}
+	}
+	if err := apply("", mappingValue(mappingValue(document, "jump_host"), "password")); err != nil {
+		return nil, fmt.Errorf("jump_host.password: %w", err)
+	}
+	if !changed {
+		return content, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// mappingValue 返回映射节点中指定键的值节点
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"RancherMan/rancher/types/workload"
+	"encoding/base64"
+	"fmt"
+	"sort"
+)
+
+// Secret 表示Kubernetes的Secret资源, Data中的值为base64编码
+type Secret struct {
+	ApiVersion string            `yaml:"apiVersion" json:"apiVersion,omitempty"`
+	Kind       string            `yaml:"kind" json:"kind,omitempty"`
+	Metadata   workload.Metadata `yaml:"metadata" json:"metadata"`
+	Type       string            `yaml:"type,omitempty" json:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty" json:"data,omitempty"`
+}
+
+// Keys 返回按名称排序的所有键
+func (s Secret) Keys() []string {
+	keys := make([]string, 0, len(s.Data))
+	for key := range s.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Value 返回键对应的解码后的值
+func (s Secret) Value(key string) (string, error) {
+	encoded, ok := s.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%s中没有键%s", s.Metadata.Name, key)
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("解码%s的%s失败: %w", s.Metadata.Name, key, err)
+	}
+	return string(value), nil
+}
+
+// SetValue 以base64编码保存键对应的值
+func (s *Secret) SetValue(key string, value string) {
+	if s.Data == nil {
+		s.Data = map[string]string{}
+	}
+	s.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+}
@@ -0,0 +1,40 @@
+package workload
+
+// CronJob 表示 Kubernetes batch/v1 CronJob 资源, Pod模板与Deployment相同
+type CronJob struct {
+	ApiVersion string                 `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string                 `yaml:"kind" json:"kind"`
+	Metadata   Metadata               `yaml:"metadata" json:"metadata"`
+	Spec       CronJobSpec            `yaml:"spec" json:"spec"`
+	Status     map[string]interface{} `yaml:"status,omitempty" json:"status,omitempty"` // lastScheduleTime等, 原样保留
+	Extra      map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// CronJobSpec 表示 CronJob 的规格
+type CronJobSpec struct {
+	Schedule                   string                 `yaml:"schedule" json:"schedule"`
+	ConcurrencyPolicy          string                 `yaml:"concurrencyPolicy,omitempty" json:"concurrencyPolicy,omitempty"`
+	Suspend                    *bool                  `yaml:"suspend,omitempty" json:"suspend,omitempty"`
+	StartingDeadlineSeconds    *int64                 `yaml:"startingDeadlineSeconds,omitempty" json:"startingDeadlineSeconds,omitempty"`
+	SuccessfulJobsHistoryLimit *int32                 `yaml:"successfulJobsHistoryLimit,omitempty" json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32                 `yaml:"failedJobsHistoryLimit,omitempty" json:"failedJobsHistoryLimit,omitempty"`
+	JobTemplate                JobTemplate            `yaml:"jobTemplate" json:"jobTemplate"`
+	Extra                      map[string]interface{} `yaml:",inline" json:"-"` // timeZone等
+}
+
+// JobTemplate 表示 CronJob 创建 Job 时使用的模板
+type JobTemplate struct {
+	Metadata PodMetadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Spec     JobSpec     `yaml:"spec" json:"spec"`
+}
+
+// JobSpec 表示 Job 的规格
+type JobSpec struct {
+	Parallelism             *int32                 `yaml:"parallelism,omitempty" json:"parallelism,omitempty"`
+	Completions             *int32                 `yaml:"completions,omitempty" json:"completions,omitempty"`
+	BackoffLimit            *int32                 `yaml:"backoffLimit,omitempty" json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds   *int64                 `yaml:"activeDeadlineSeconds,omitempty" json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                 `yaml:"ttlSecondsAfterFinished,omitempty" json:"ttlSecondsAfterFinished,omitempty"`
+	Template                Template               `yaml:"template" json:"template"`
+	Extra                   map[string]interface{} `yaml:",inline" json:"-"` // completionMode、podFailurePolicy等
+}
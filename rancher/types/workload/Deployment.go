@@ -1,73 +1,191 @@
 package workload
 
+// 字段的omitempty和指针类型与Kubernetes API保持一致, 使从Rancher导出的YAML解码再编码后内容不变。
+// 模型未覆盖的字段保存在各层的Extra中, 编码时原样写回
+
 // Deployment 表示 Kubernetes Deployment 资源
 type Deployment struct {
-	ApiVersion string   `yaml:"apiVersion" json:"apiVersion"`
-	Kind       string   `yaml:"kind" json:"kind"`
-	Metadata   Metadata `yaml:"metadata" json:"metadata"`
-	Spec       Spec     `yaml:"spec" json:"spec"`
+	ApiVersion string                 `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string                 `yaml:"kind" json:"kind"`
+	Metadata   Metadata               `yaml:"metadata" json:"metadata"`
+	Spec       Spec                   `yaml:"spec" json:"spec"`
+	Status     *DeploymentStatus      `yaml:"status,omitempty" json:"status,omitempty"`
+	Extra      map[string]interface{} `yaml:",inline" json:"-"`
 }
 
 // Metadata 表示资源元数据
 type Metadata struct {
-	Name        string            `yaml:"name" json:"name"`
-	Namespace   string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
-	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
-	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Name              string                 `yaml:"name" json:"name"`
+	GenerateName      string                 `yaml:"generateName,omitempty" json:"generateName,omitempty"`
+	Namespace         string                 `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	UID               string                 `yaml:"uid,omitempty" json:"uid,omitempty"`
+	ResourceVersion   string                 `yaml:"resourceVersion,omitempty" json:"resourceVersion,omitempty"`
+	Generation        int64                  `yaml:"generation,omitempty" json:"generation,omitempty"`
+	CreationTimestamp string                 `yaml:"creationTimestamp,omitempty" json:"creationTimestamp,omitempty"`
+	Annotations       map[string]string      `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Labels            map[string]string      `yaml:"labels,omitempty" json:"labels,omitempty"`
+	OwnerReferences   []OwnerReference       `yaml:"ownerReferences,omitempty" json:"ownerReferences,omitempty"`
+	Finalizers        []string               `yaml:"finalizers,omitempty" json:"finalizers,omitempty"`
+	Extra             map[string]interface{} `yaml:",inline" json:"-"` // managedFields等
+}
+
+// OwnerReference 表示资源的所有者
+type OwnerReference struct {
+	ApiVersion         string `yaml:"apiVersion" json:"apiVersion"`
+	Kind               string `yaml:"kind" json:"kind"`
+	Name               string `yaml:"name" json:"name"`
+	UID                string `yaml:"uid" json:"uid"`
+	Controller         *bool  `yaml:"controller,omitempty" json:"controller,omitempty"`
+	BlockOwnerDeletion *bool  `yaml:"blockOwnerDeletion,omitempty" json:"blockOwnerDeletion,omitempty"`
 }
 
 // Spec 表示 Deployment 的规格
 type Spec struct {
-	Selector Selector `yaml:"selector" json:"selector"`
-	Strategy Strategy `yaml:"strategy,omitempty" json:"strategy,omitempty"`
-	Template Template `yaml:"template" json:"template"`
+	Replicas                *int32                 `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	Selector                *Selector              `yaml:"selector,omitempty" json:"selector,omitempty"`
+	Template                Template               `yaml:"template" json:"template"`
+	Strategy                *Strategy              `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	MinReadySeconds         int32                  `yaml:"minReadySeconds,omitempty" json:"minReadySeconds,omitempty"`
+	RevisionHistoryLimit    *int32                 `yaml:"revisionHistoryLimit,omitempty" json:"revisionHistoryLimit,omitempty"`
+	Paused                  bool                   `yaml:"paused,omitempty" json:"paused,omitempty"`
+	ProgressDeadlineSeconds *int32                 `yaml:"progressDeadlineSeconds,omitempty" json:"progressDeadlineSeconds,omitempty"`
+	Extra                   map[string]interface{} `yaml:",inline" json:"-"` // StatefulSet的serviceName、volumeClaimTemplates等
 }
 
 // Selector 表示标签选择器
 type Selector struct {
-	MatchLabels map[string]string `yaml:"matchLabels" json:"matchLabels"`
+	MatchLabels      map[string]string `yaml:"matchLabels,omitempty" json:"matchLabels,omitempty"`
+	MatchExpressions []MatchExpression `yaml:"matchExpressions,omitempty" json:"matchExpressions,omitempty"`
 }
 
 // Strategy 表示部署策略
 type Strategy struct {
-	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	Type          string         `yaml:"type,omitempty" json:"type,omitempty"`
+	RollingUpdate *RollingUpdate `yaml:"rollingUpdate,omitempty" json:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdate 表示滚动更新的参数, 可以是数量或百分比
+type RollingUpdate struct {
+	MaxUnavailable *IntOrString `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+	MaxSurge       *IntOrString `yaml:"maxSurge,omitempty" json:"maxSurge,omitempty"`
+}
+
+// DeploymentStatus 表示 Deployment 的状态
+type DeploymentStatus struct {
+	ObservedGeneration  int64                  `yaml:"observedGeneration,omitempty" json:"observedGeneration,omitempty"`
+	Replicas            int32                  `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	UpdatedReplicas     int32                  `yaml:"updatedReplicas,omitempty" json:"updatedReplicas,omitempty"`
+	ReadyReplicas       int32                  `yaml:"readyReplicas,omitempty" json:"readyReplicas,omitempty"`
+	AvailableReplicas   int32                  `yaml:"availableReplicas,omitempty" json:"availableReplicas,omitempty"`
+	UnavailableReplicas int32                  `yaml:"unavailableReplicas,omitempty" json:"unavailableReplicas,omitempty"`
+	Conditions          []Condition            `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	Extra               map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// Condition 表示状态中的一个条件
+type Condition struct {
+	Type               string `yaml:"type" json:"type"`
+	Status             string `yaml:"status" json:"status"`
+	LastUpdateTime     string `yaml:"lastUpdateTime,omitempty" json:"lastUpdateTime,omitempty"`
+	LastTransitionTime string `yaml:"lastTransitionTime,omitempty" json:"lastTransitionTime,omitempty"`
+	Reason             string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message            string `yaml:"message,omitempty" json:"message,omitempty"`
 }
 
 // Template 表示 Pod 模板
 type Template struct {
-	Metadata PodMetadata `yaml:"metadata" json:"metadata"`
+	Metadata PodMetadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
 	Spec     PodSpec     `yaml:"spec" json:"spec"`
 }
 
 // PodMetadata 表示 Pod 元数据
 type PodMetadata struct {
-	Labels      map[string]string `yaml:"labels" json:"labels"`
-	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Labels      map[string]string      `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string      `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline" json:"-"` // 如 creationTimestamp: null
 }
 
 // PodSpec 表示 Pod 规格
 type PodSpec struct {
-	HostAliases      []HostAlias  `yaml:"hostAliases,omitempty" json:"hostAliases,omitempty"`
-	Affinity         Affinity     `yaml:"affinity" json:"affinity"`
-	Containers       []Container  `yaml:"containers" json:"containers"`
-	ImagePullSecrets []PullSecret `yaml:"imagePullSecrets" json:"imagePullSecrets"`
-	SchedulerName    string       `yaml:"schedulerName" json:"schedulerName"`
-	Volumes          []Volume     `yaml:"volumes" json:"volumes"`
+	Volumes                       []Volume               `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	InitContainers                []Container            `yaml:"initContainers,omitempty" json:"initContainers,omitempty"`
+	Containers                    []Container            `yaml:"containers" json:"containers"`
+	RestartPolicy                 string                 `yaml:"restartPolicy,omitempty" json:"restartPolicy,omitempty"`
+	TerminationGracePeriodSeconds *int64                 `yaml:"terminationGracePeriodSeconds,omitempty" json:"terminationGracePeriodSeconds,omitempty"`
+	ActiveDeadlineSeconds         *int64                 `yaml:"activeDeadlineSeconds,omitempty" json:"activeDeadlineSeconds,omitempty"`
+	DNSPolicy                     string                 `yaml:"dnsPolicy,omitempty" json:"dnsPolicy,omitempty"`
+	NodeSelector                  map[string]string      `yaml:"nodeSelector,omitempty" json:"nodeSelector,omitempty"`
+	ServiceAccountName            string                 `yaml:"serviceAccountName,omitempty" json:"serviceAccountName,omitempty"`
+	ServiceAccount                string                 `yaml:"serviceAccount,omitempty" json:"serviceAccount,omitempty"`
+	AutomountServiceAccountToken  *bool                  `yaml:"automountServiceAccountToken,omitempty" json:"automountServiceAccountToken,omitempty"`
+	NodeName                      string                 `yaml:"nodeName,omitempty" json:"nodeName,omitempty"`
+	HostNetwork                   bool                   `yaml:"hostNetwork,omitempty" json:"hostNetwork,omitempty"`
+	HostPID                       bool                   `yaml:"hostPID,omitempty" json:"hostPID,omitempty"`
+	HostIPC                       bool                   `yaml:"hostIPC,omitempty" json:"hostIPC,omitempty"`
+	ShareProcessNamespace         *bool                  `yaml:"shareProcessNamespace,omitempty" json:"shareProcessNamespace,omitempty"`
+	SecurityContext               *PodSecurityContext    `yaml:"securityContext,omitempty" json:"securityContext,omitempty"`
+	ImagePullSecrets              []PullSecret           `yaml:"imagePullSecrets,omitempty" json:"imagePullSecrets,omitempty"`
+	Hostname                      string                 `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	Subdomain                     string                 `yaml:"subdomain,omitempty" json:"subdomain,omitempty"`
+	Affinity                      *Affinity              `yaml:"affinity,omitempty" json:"affinity,omitempty"`
+	SchedulerName                 string                 `yaml:"schedulerName,omitempty" json:"schedulerName,omitempty"`
+	Tolerations                   []Toleration           `yaml:"tolerations,omitempty" json:"tolerations,omitempty"`
+	HostAliases                   []HostAlias            `yaml:"hostAliases,omitempty" json:"hostAliases,omitempty"`
+	PriorityClassName             string                 `yaml:"priorityClassName,omitempty" json:"priorityClassName,omitempty"`
+	Priority                      *int32                 `yaml:"priority,omitempty" json:"priority,omitempty"`
+	DNSConfig                     *PodDNSConfig          `yaml:"dnsConfig,omitempty" json:"dnsConfig,omitempty"`
+	EnableServiceLinks            *bool                  `yaml:"enableServiceLinks,omitempty" json:"enableServiceLinks,omitempty"`
+	PreemptionPolicy              *string                `yaml:"preemptionPolicy,omitempty" json:"preemptionPolicy,omitempty"`
+	Extra                         map[string]interface{} `yaml:",inline" json:"-"` // topologySpreadConstraints等
 }
 
 type HostAlias struct {
-	IP        string   `yaml:"ip" json:"ip"`
-	Hostnames []string `yaml:"hostnames" json:"hostnames"`
+	IP        string   `yaml:"ip,omitempty" json:"ip,omitempty"`
+	Hostnames []string `yaml:"hostnames,omitempty" json:"hostnames,omitempty"`
 }
 
-// Affinity 表示节点亲和性
+// PodSecurityContext 表示 Pod 级别的安全配置
+type PodSecurityContext struct {
+	RunAsUser          *int64                 `yaml:"runAsUser,omitempty" json:"runAsUser,omitempty"`
+	RunAsGroup         *int64                 `yaml:"runAsGroup,omitempty" json:"runAsGroup,omitempty"`
+	RunAsNonRoot       *bool                  `yaml:"runAsNonRoot,omitempty" json:"runAsNonRoot,omitempty"`
+	FSGroup            *int64                 `yaml:"fsGroup,omitempty" json:"fsGroup,omitempty"`
+	SupplementalGroups []int64                `yaml:"supplementalGroups,omitempty" json:"supplementalGroups,omitempty"`
+	Extra              map[string]interface{} `yaml:",inline" json:"-"` // seccompProfile、sysctls等
+}
+
+// PodDNSConfig 表示 Pod 的DNS配置
+type PodDNSConfig struct {
+	Nameservers []string       `yaml:"nameservers,omitempty" json:"nameservers,omitempty"`
+	Searches    []string       `yaml:"searches,omitempty" json:"searches,omitempty"`
+	Options     []PodDNSOption `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+type PodDNSOption struct {
+	Name  string  `yaml:"name,omitempty" json:"name,omitempty"`
+	Value *string `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+// Toleration 表示对节点污点的容忍
+type Toleration struct {
+	Key               string `yaml:"key,omitempty" json:"key,omitempty"`
+	Operator          string `yaml:"operator,omitempty" json:"operator,omitempty"`
+	Value             string `yaml:"value,omitempty" json:"value,omitempty"`
+	Effect            string `yaml:"effect,omitempty" json:"effect,omitempty"`
+	TolerationSeconds *int64 `yaml:"tolerationSeconds,omitempty" json:"tolerationSeconds,omitempty"`
+}
+
+// Affinity 表示节点亲和性和Pod亲和性
 type Affinity struct {
-	NodeAffinity NodeAffinity `yaml:"nodeAffinity" json:"nodeAffinity"`
+	NodeAffinity    *NodeAffinity `yaml:"nodeAffinity,omitempty" json:"nodeAffinity,omitempty"`
+	PodAffinity     *PodAffinity  `yaml:"podAffinity,omitempty" json:"podAffinity,omitempty"`
+	PodAntiAffinity *PodAffinity  `yaml:"podAntiAffinity,omitempty" json:"podAntiAffinity,omitempty"`
 }
 
 // NodeAffinity 表示节点亲和性规则
 type NodeAffinity struct {
-	RequiredDuringSchedulingIgnoredDuringExecution NodeSelector `yaml:"requiredDuringSchedulingIgnoredDuringExecution" json:"requiredDuringSchedulingIgnoredDuringExecution"`
+	RequiredDuringSchedulingIgnoredDuringExecution  *NodeSelector             `yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty" json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty" json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
 }
 
 // NodeSelector 表示节点选择器
@@ -77,73 +195,262 @@ type NodeSelector struct {
 
 // NodeSelectorTerm 表示节点选择条件
 type NodeSelectorTerm struct {
-	MatchExpressions []MatchExpression `yaml:"matchExpressions" json:"matchExpressions"`
+	MatchExpressions []MatchExpression `yaml:"matchExpressions,omitempty" json:"matchExpressions,omitempty"`
+	MatchFields      []MatchExpression `yaml:"matchFields,omitempty" json:"matchFields,omitempty"`
+}
+
+// PreferredSchedulingTerm 表示带权重的节点选择条件
+type PreferredSchedulingTerm struct {
+	Weight     int32            `yaml:"weight" json:"weight"`
+	Preference NodeSelectorTerm `yaml:"preference" json:"preference"`
 }
 
 // MatchExpression 表示匹配表达式
 type MatchExpression struct {
 	Key      string   `yaml:"key" json:"key"`
 	Operator string   `yaml:"operator" json:"operator"`
-	Values   []string `yaml:"values" json:"values"`
+	Values   []string `yaml:"values,omitempty" json:"values,omitempty"`
+}
+
+// PodAffinity 表示Pod亲和性或反亲和性规则
+type PodAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  []PodAffinityTerm         `yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty" json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty" json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// PodAffinityTerm 表示Pod亲和性条件
+type PodAffinityTerm struct {
+	LabelSelector     *Selector `yaml:"labelSelector,omitempty" json:"labelSelector,omitempty"`
+	Namespaces        []string  `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+	TopologyKey       string    `yaml:"topologyKey" json:"topologyKey"`
+	NamespaceSelector *Selector `yaml:"namespaceSelector,omitempty" json:"namespaceSelector,omitempty"`
+}
+
+// WeightedPodAffinityTerm 表示带权重的Pod亲和性条件
+type WeightedPodAffinityTerm struct {
+	Weight          int32           `yaml:"weight" json:"weight"`
+	PodAffinityTerm PodAffinityTerm `yaml:"podAffinityTerm" json:"podAffinityTerm"`
 }
 
 // Container 表示容器配置
 type Container struct {
-	Name            string           `yaml:"name" json:"name"`
-	Image           string           `yaml:"image" json:"image"`
-	Args            []string         `yaml:"args,omitempty" json:"args,omitempty"`
-	Ports           []Port           `yaml:"ports,omitempty" json:"ports,omitempty"`
-	Env             []EnvVar         `yaml:"env,omitempty" json:"env,omitempty"`
-	ImagePullPolicy string           `yaml:"imagePullPolicy,omitempty" json:"imagePullPolicy,omitempty"`
-	VolumeMounts    []VolumeMount    `yaml:"volumeMounts,omitempty" json:"volumeMounts,omitempty"`
-	SecurityContext *SecurityContext `yaml:"securityContext,omitempty" json:"securityContext,omitempty"`
+	Name                     string                 `yaml:"name" json:"name"`
+	Image                    string                 `yaml:"image,omitempty" json:"image,omitempty"`
+	Command                  []string               `yaml:"command,omitempty" json:"command,omitempty"`
+	Args                     []string               `yaml:"args,omitempty" json:"args,omitempty"`
+	WorkingDir               string                 `yaml:"workingDir,omitempty" json:"workingDir,omitempty"`
+	Ports                    []Port                 `yaml:"ports,omitempty" json:"ports,omitempty"`
+	EnvFrom                  []EnvFromSource        `yaml:"envFrom,omitempty" json:"envFrom,omitempty"`
+	Env                      []EnvVar               `yaml:"env,omitempty" json:"env,omitempty"`
+	Resources                *ResourceRequirements  `yaml:"resources,omitempty" json:"resources,omitempty"`
+	VolumeMounts             []VolumeMount          `yaml:"volumeMounts,omitempty" json:"volumeMounts,omitempty"`
+	LivenessProbe            *Probe                 `yaml:"livenessProbe,omitempty" json:"livenessProbe,omitempty"`
+	ReadinessProbe           *Probe                 `yaml:"readinessProbe,omitempty" json:"readinessProbe,omitempty"`
+	StartupProbe             *Probe                 `yaml:"startupProbe,omitempty" json:"startupProbe,omitempty"`
+	Lifecycle                *Lifecycle             `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+	TerminationMessagePath   string                 `yaml:"terminationMessagePath,omitempty" json:"terminationMessagePath,omitempty"`
+	TerminationMessagePolicy string                 `yaml:"terminationMessagePolicy,omitempty" json:"terminationMessagePolicy,omitempty"`
+	ImagePullPolicy          string                 `yaml:"imagePullPolicy,omitempty" json:"imagePullPolicy,omitempty"`
+	SecurityContext          *SecurityContext       `yaml:"securityContext,omitempty" json:"securityContext,omitempty"`
+	Stdin                    bool                   `yaml:"stdin,omitempty" json:"stdin,omitempty"`
+	StdinOnce                bool                   `yaml:"stdinOnce,omitempty" json:"stdinOnce,omitempty"`
+	TTY                      bool                   `yaml:"tty,omitempty" json:"tty,omitempty"`
+	Extra                    map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// ResourceRequirements 表示容器的资源请求和限制, 如 cpu: 500m, memory: 1Gi
+type ResourceRequirements struct {
+	Limits   map[string]string `yaml:"limits,omitempty" json:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty" json:"requests,omitempty"`
+}
+
+// Probe 表示存活、就绪或启动检查
+type Probe struct {
+	Exec                          *ExecAction      `yaml:"exec,omitempty" json:"exec,omitempty"`
+	HTTPGet                       *HTTPGetAction   `yaml:"httpGet,omitempty" json:"httpGet,omitempty"`
+	TCPSocket                     *TCPSocketAction `yaml:"tcpSocket,omitempty" json:"tcpSocket,omitempty"`
+	InitialDelaySeconds           int32            `yaml:"initialDelaySeconds,omitempty" json:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds                int32            `yaml:"timeoutSeconds,omitempty" json:"timeoutSeconds,omitempty"`
+	PeriodSeconds                 int32            `yaml:"periodSeconds,omitempty" json:"periodSeconds,omitempty"`
+	SuccessThreshold              int32            `yaml:"successThreshold,omitempty" json:"successThreshold,omitempty"`
+	FailureThreshold              int32            `yaml:"failureThreshold,omitempty" json:"failureThreshold,omitempty"`
+	TerminationGracePeriodSeconds *int64           `yaml:"terminationGracePeriodSeconds,omitempty" json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// Lifecycle 表示容器启动后和停止前执行的操作
+type Lifecycle struct {
+	PostStart *LifecycleHandler `yaml:"postStart,omitempty" json:"postStart,omitempty"`
+	PreStop   *LifecycleHandler `yaml:"preStop,omitempty" json:"preStop,omitempty"`
+}
+
+type LifecycleHandler struct {
+	Exec      *ExecAction      `yaml:"exec,omitempty" json:"exec,omitempty"`
+	HTTPGet   *HTTPGetAction   `yaml:"httpGet,omitempty" json:"httpGet,omitempty"`
+	TCPSocket *TCPSocketAction `yaml:"tcpSocket,omitempty" json:"tcpSocket,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+}
+
+type HTTPGetAction struct {
+	Path        string       `yaml:"path,omitempty" json:"path,omitempty"`
+	Port        IntOrString  `yaml:"port" json:"port"`
+	Host        string       `yaml:"host,omitempty" json:"host,omitempty"`
+	Scheme      string       `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	HTTPHeaders []HTTPHeader `yaml:"httpHeaders,omitempty" json:"httpHeaders,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+type TCPSocketAction struct {
+	Port IntOrString `yaml:"port" json:"port"`
+	Host string      `yaml:"host,omitempty" json:"host,omitempty"`
 }
 
 type VolumeMount struct {
-	Name      string `yaml:"name" json:"name"`
-	MountPath string `yaml:"mountPath" json:"mountPath"`
-	SubPath   string `yaml:"subPath,omitempty" json:"subPath,omitempty"`
+	Name             string  `yaml:"name" json:"name"`
+	ReadOnly         bool    `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
+	MountPath        string  `yaml:"mountPath" json:"mountPath"`
+	SubPath          string  `yaml:"subPath,omitempty" json:"subPath,omitempty"`
+	MountPropagation *string `yaml:"mountPropagation,omitempty" json:"mountPropagation,omitempty"`
+	SubPathExpr      string  `yaml:"subPathExpr,omitempty" json:"subPathExpr,omitempty"`
 }
 
 type SecurityContext struct {
-	AllowPrivilegeEscalation *bool `yaml:"allowPrivilegeEscalation,omitempty" json:"allowPrivilegeEscalation,omitempty"`
-	Privileged               *bool `yaml:"privileged,omitempty" json:"privileged,omitempty"`
-	ReadOnlyRootFilesystem   *bool `yaml:"readOnlyRootFilesystem,omitempty" json:"readOnlyRootFilesystem,omitempty"`
-	RunAsNonRoot             *bool `yaml:"runAsNonRoot,omitempty" json:"runAsNonRoot,omitempty"`
+	Capabilities             *Capabilities          `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+	Privileged               *bool                  `yaml:"privileged,omitempty" json:"privileged,omitempty"`
+	RunAsUser                *int64                 `yaml:"runAsUser,omitempty" json:"runAsUser,omitempty"`
+	RunAsGroup               *int64                 `yaml:"runAsGroup,omitempty" json:"runAsGroup,omitempty"`
+	RunAsNonRoot             *bool                  `yaml:"runAsNonRoot,omitempty" json:"runAsNonRoot,omitempty"`
+	ReadOnlyRootFilesystem   *bool                  `yaml:"readOnlyRootFilesystem,omitempty" json:"readOnlyRootFilesystem,omitempty"`
+	AllowPrivilegeEscalation *bool                  `yaml:"allowPrivilegeEscalation,omitempty" json:"allowPrivilegeEscalation,omitempty"`
+	ProcMount                *string                `yaml:"procMount,omitempty" json:"procMount,omitempty"`
+	Extra                    map[string]interface{} `yaml:",inline" json:"-"` // seccompProfile等
+}
+
+// Capabilities 表示添加和去掉的Linux capabilities
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty" json:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty" json:"drop,omitempty"`
 }
 
 // Port 表示容器端口
 type Port struct {
+	Name          string `yaml:"name,omitempty" json:"name,omitempty"`
+	HostPort      int32  `yaml:"hostPort,omitempty" json:"hostPort,omitempty"`
 	ContainerPort int32  `yaml:"containerPort" json:"containerPort"`
 	Protocol      string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
-	Name          string `yaml:"name,omitempty" json:"name,omitempty"`
+	HostIP        string `yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
 }
 
-// EnvVar 表示环境变量
+// EnvVar 表示环境变量, 值直接给出或来自valueFrom
 type EnvVar struct {
-	Name  string `yaml:"name" json:"name"`
-	Value string `yaml:"value" json:"value"`
+	Name      string        `yaml:"name" json:"name"`
+	Value     string        `yaml:"value,omitempty" json:"value,omitempty"`
+	ValueFrom *EnvVarSource `yaml:"valueFrom,omitempty" json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource 表示环境变量值的来源
+type EnvVarSource struct {
+	FieldRef         *ObjectFieldSelector   `yaml:"fieldRef,omitempty" json:"fieldRef,omitempty"`
+	ResourceFieldRef *ResourceFieldSelector `yaml:"resourceFieldRef,omitempty" json:"resourceFieldRef,omitempty"`
+	ConfigMapKeyRef  *KeySelector           `yaml:"configMapKeyRef,omitempty" json:"configMapKeyRef,omitempty"`
+	SecretKeyRef     *KeySelector           `yaml:"secretKeyRef,omitempty" json:"secretKeyRef,omitempty"`
+}
+
+type ObjectFieldSelector struct {
+	ApiVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+	FieldPath  string `yaml:"fieldPath" json:"fieldPath"`
+}
+
+type ResourceFieldSelector struct {
+	ContainerName string `yaml:"containerName,omitempty" json:"containerName,omitempty"`
+	Resource      string `yaml:"resource" json:"resource"`
+	Divisor       string `yaml:"divisor,omitempty" json:"divisor,omitempty"`
+}
+
+// KeySelector 表示configMap或secret中的一个键
+type KeySelector struct {
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Key      string `yaml:"key" json:"key"`
+	Optional *bool  `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// EnvFromSource 表示从configMap或secret导入全部环境变量
+type EnvFromSource struct {
+	Prefix       string           `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	ConfigMapRef *EnvFromResource `yaml:"configMapRef,omitempty" json:"configMapRef,omitempty"`
+	SecretRef    *EnvFromResource `yaml:"secretRef,omitempty" json:"secretRef,omitempty"`
+}
+
+type EnvFromResource struct {
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Optional *bool  `yaml:"optional,omitempty" json:"optional,omitempty"`
 }
 
 // PullSecret 表示镜像拉取密钥
 type PullSecret struct {
-	Name string `yaml:"name" json:"name"`
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
 }
 
 // Volume 表示数据卷配置
 type Volume struct {
 	Name                  string                 `yaml:"name" json:"name"`
 	ConfigMap             *ConfigMap             `yaml:"configMap,omitempty" json:"configMap,omitempty"`
+	Secret                *Secret                `yaml:"secret,omitempty" json:"secret,omitempty"`
 	PersistentVolumeClaim *PersistentVolumeClaim `yaml:"persistentVolumeClaim,omitempty" json:"persistentVolumeClaim,omitempty"`
+	EmptyDir              *EmptyDir              `yaml:"emptyDir,omitempty" json:"emptyDir,omitempty"`
+	HostPath              *HostPath              `yaml:"hostPath,omitempty" json:"hostPath,omitempty"`
+	NFS                   *NFS                   `yaml:"nfs,omitempty" json:"nfs,omitempty"`
+	Extra                 map[string]interface{} `yaml:",inline" json:"-"` // projected、downwardAPI等
 }
 
 // ConfigMap 表示 ConfigMap 卷配置
 type ConfigMap struct {
-	DefaultMode *int32 `yaml:"defaultMode,omitempty" json:"defaultMode,omitempty"`
-	Name        string `yaml:"name" json:"name"`
-	Optional    *bool  `yaml:"optional,omitempty" json:"optional,omitempty"`
+	Name        string      `yaml:"name,omitempty" json:"name,omitempty"`
+	Items       []KeyToPath `yaml:"items,omitempty" json:"items,omitempty"`
+	DefaultMode *int32      `yaml:"defaultMode,omitempty" json:"defaultMode,omitempty"`
+	Optional    *bool       `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// Secret 表示 Secret 卷配置
+type Secret struct {
+	SecretName  string      `yaml:"secretName,omitempty" json:"secretName,omitempty"`
+	Items       []KeyToPath `yaml:"items,omitempty" json:"items,omitempty"`
+	DefaultMode *int32      `yaml:"defaultMode,omitempty" json:"defaultMode,omitempty"`
+	Optional    *bool       `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// KeyToPath 表示将configMap或secret中的一个键挂载为文件
+type KeyToPath struct {
+	Key  string `yaml:"key" json:"key"`
+	Path string `yaml:"path" json:"path"`
+	Mode *int32 `yaml:"mode,omitempty" json:"mode,omitempty"`
 }
 
 type PersistentVolumeClaim struct {
 	ClaimName string `yaml:"claimName" json:"claimName"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
+}
+
+// EmptyDir 表示临时目录, 配置为空时写作 emptyDir: {}
+type EmptyDir struct {
+	Medium    string `yaml:"medium,omitempty" json:"medium,omitempty"`
+	SizeLimit string `yaml:"sizeLimit,omitempty" json:"sizeLimit,omitempty"`
+}
+
+// HostPath 表示挂载节点上的目录或文件
+type HostPath struct {
+	Path string  `yaml:"path" json:"path"`
+	Type *string `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+type NFS struct {
+	Server   string `yaml:"server" json:"server"`
+	Path     string `yaml:"path" json:"path"`
+	ReadOnly bool   `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
 }
@@ -0,0 +1,116 @@
+package workload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// assertRoundTrip 将fixture解码为typed后重新编码, 与原始内容按解码后的对象树比较, 字段顺序和格式的差异不影响结果
+func assertRoundTrip(t *testing.T, file string, typed interface{}) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(content, typed); err != nil {
+		t.Fatalf("解码%s失败: %v", file, err)
+	}
+	output, err := yaml.Marshal(typed)
+	if err != nil {
+		t.Fatalf("编码%s失败: %v", file, err)
+	}
+	var want, got interface{}
+	if err := yaml.Unmarshal(content, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(output, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("%s解码再编码后内容不一致, 编码结果:\n%s", file, output)
+	}
+}
+
+func TestDeploymentRoundTrip(t *testing.T) {
+	for _, file := range []string{"deployment.yaml", "statefulset.yaml"} {
+		t.Run(file, func(t *testing.T) {
+			assertRoundTrip(t, file, &Deployment{})
+		})
+	}
+}
+
+func TestCronJobRoundTrip(t *testing.T) {
+	assertRoundTrip(t, "cronjob.yaml", &CronJob{})
+}
+
+func TestDeploymentFields(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deployment Deployment
+	if err := yaml.Unmarshal(content, &deployment); err != nil {
+		t.Fatal(err)
+	}
+	spec := deployment.Spec
+	if spec.Replicas == nil || *spec.Replicas != 2 {
+		t.Errorf("replicas = %v, 期望 2", spec.Replicas)
+	}
+	if got := spec.Strategy.RollingUpdate.MaxSurge; *got != FromString("25%") {
+		t.Errorf("maxSurge = %#v, 期望字符串 25%%", *got)
+	}
+	if got := spec.Strategy.RollingUpdate.MaxUnavailable; *got != FromInt(0) {
+		t.Errorf("maxUnavailable = %#v, 期望整数 0", *got)
+	}
+	podSpec := spec.Template.Spec
+	if len(podSpec.Containers) != 2 || len(podSpec.InitContainers) != 1 {
+		t.Fatalf("容器数量 %d, 初始化容器数量 %d", len(podSpec.Containers), len(podSpec.InitContainers))
+	}
+	main := podSpec.Containers[0]
+	if main.LivenessProbe.HTTPGet.Port != FromInt(8080) || main.ReadinessProbe.TCPSocket.Port != FromString("http") {
+		t.Errorf("探针端口 %v/%v", main.LivenessProbe.HTTPGet.Port, main.ReadinessProbe.TCPSocket.Port)
+	}
+	if main.Resources.Limits["memory"] != "2Gi" || main.Env[2].ValueFrom.SecretKeyRef.Name != "mysql" {
+		t.Errorf("resources或valueFrom未解码: %+v", main)
+	}
+	if _, ok := podSpec.Extra["topologySpreadConstraints"]; !ok {
+		t.Errorf("未覆盖的字段没有保存在Extra中")
+	}
+}
+
+func TestIntOrString(t *testing.T) {
+	cases := []struct {
+		yaml  string
+		json  string
+		value IntOrString
+	}{
+		{"8080", "8080", FromInt(8080)},
+		{"http", `"http"`, FromString("http")},
+		{"25%", `"25%"`, FromString("25%")},
+		{`"8080"`, `"8080"`, FromString("8080")},
+	}
+	for _, tc := range cases {
+		var fromYaml IntOrString
+		if err := yaml.Unmarshal([]byte(tc.yaml), &fromYaml); err != nil || fromYaml != tc.value {
+			t.Errorf("YAML %s 解码为 %#v, 错误 %v", tc.yaml, fromYaml, err)
+		}
+		var fromJSON IntOrString
+		if err := json.Unmarshal([]byte(tc.json), &fromJSON); err != nil || fromJSON != tc.value {
+			t.Errorf("JSON %s 解码为 %#v, 错误 %v", tc.json, fromJSON, err)
+		}
+		encoded, err := json.Marshal(tc.value)
+		if err != nil || string(encoded) != tc.json {
+			t.Errorf("%#v 编码为JSON %s, 期望 %s", tc.value, encoded, tc.json)
+		}
+		var back IntOrString
+		data, _ := yaml.Marshal(tc.value)
+		if err := yaml.Unmarshal(data, &back); err != nil || back != tc.value {
+			t.Errorf("%#v 的YAML %s 解码为 %#v", tc.value, data, back)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package workload
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IntOrString 表示可以是整数或字符串的字段, 如端口号/端口名、数量/百分比
+type IntOrString struct {
+	IsString bool
+	IntVal   int32
+	StrVal   string
+}
+
+func FromInt(value int32) IntOrString {
+	return IntOrString{IntVal: value}
+}
+
+func FromString(value string) IntOrString {
+	return IntOrString{IsString: true, StrVal: value}
+}
+
+func (v IntOrString) String() string {
+	if v.IsString {
+		return v.StrVal
+	}
+	return strconv.Itoa(int(v.IntVal))
+}
+
+func (v IntOrString) MarshalYAML() (interface{}, error) {
+	if v.IsString {
+		return v.StrVal, nil
+	}
+	return v.IntVal, nil
+}
+
+func (v *IntOrString) UnmarshalYAML(node *yaml.Node) error {
+	if node.Tag == "!!int" {
+		v.IsString = false
+		return node.Decode(&v.IntVal)
+	}
+	v.IsString = true
+	return node.Decode(&v.StrVal)
+}
+
+func (v IntOrString) MarshalJSON() ([]byte, error) {
+	if v.IsString {
+		return json.Marshal(v.StrVal)
+	}
+	return json.Marshal(v.IntVal)
+}
+
+func (v *IntOrString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		v.IsString = true
+		return json.Unmarshal(data, &v.StrVal)
+	}
+	v.IsString = false
+	return json.Unmarshal(data, &v.IntVal)
+}
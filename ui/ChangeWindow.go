@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"RancherMan/app"
+	"RancherMan/rancher"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// changeLimit 变更记录窗口最多显示的记录数
+const changeLimit = 500
+
+// allEnvironments 环境选择框中不限制环境的选项
+const allEnvironments = "全部环境"
+
+// ShowChangeWindow 打开变更记录窗口, 按环境、命名空间和工作负载过滤同步时记录的变更, 初始条件为当前选择的内容
+func ShowChangeWindow(application fyne.App, service *app.Service, filter rancher.ChangeEventFilter) {
+	window := application.NewWindow("变更记录")
+
+	environmentSelect := widget.NewSelect(append([]string{allEnvironments}, service.EnvironmentNames()...), nil)
+	environmentSelect.SetSelected(allEnvironments)
+	if filter.Environment != "" {
+		environmentSelect.SetSelected(filter.Environment)
+	}
+	namespaceEntry := widget.NewEntry()
+	namespaceEntry.SetPlaceHolder("命名空间")
+	namespaceEntry.SetText(filter.Namespace)
+	workloadEntry := widget.NewEntry()
+	workloadEntry.SetPlaceHolder("工作负载")
+	workloadEntry.SetText(filter.Workload)
+	status := widget.NewLabel("")
+
+	var events []rancher.ChangeEvent
+	eventList := widget.NewList(
+		func() int { return len(events) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("template")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			event := events[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s    %s/%s    %s    %s    %s",
+				event.CreatedAt.Local().Format("2006-01-02 15:04:05"), event.Environment, event.Namespace,
+				event.Workload, app.ChangeTypeLabel(event.Type), app.ChangeDetail(event)))
+		},
+	)
+
+	query := func() {
+		current := rancher.ChangeEventFilter{
+			Namespace: strings.TrimSpace(namespaceEntry.Text),
+			Workload:  strings.TrimSpace(workloadEntry.Text),
+			Limit:     changeLimit,
+		}
+		if environmentSelect.Selected != allEnvironments {
+			current.Environment = environmentSelect.Selected
+		}
+		list, err := service.ChangeEvents(current)
+		if err != nil {
+			status.SetText(fmt.Sprintf("查询变更记录失败: %v", err))
+			return
+		}
+		events = list
+		eventList.ScrollToTop()
+		eventList.Refresh()
+		if len(events) >= changeLimit {
+			status.SetText(fmt.Sprintf("只显示最近的 %d 条, 请缩小查询范围", changeLimit))
+		} else {
+			status.SetText(fmt.Sprintf("共 %d 条", len(events)))
+		}
+	}
+	environmentSelect.OnChanged = func(string) { query() }
+	namespaceEntry.OnSubmitted = func(string) { query() }
+	workloadEntry.OnSubmitted = func(string) { query() }
+
+	filterBar := container.NewBorder(nil, nil, environmentSelect, widget.NewButton("查询", query),
+		container.NewGridWithColumns(2, namespaceEntry, workloadEntry))
+	window.SetContent(container.NewBorder(filterBar, status, nil, nil, eventList))
+	window.Resize(fyne.NewSize(1000, 550))
+	window.Show()
+	query()
+}
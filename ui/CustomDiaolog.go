@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"RancherMan/app"
 	"RancherMan/rancher"
 	"strings"
 
@@ -11,10 +12,11 @@ import (
 )
 
 // 添加新的函数来创建和显示自定义对话框
-func ShowSelectNamespaceDialog(window fyne.Window, db *rancher.DatabaseManager, needNewTag bool, onSelect func(namespace rancher.Namespace, tag string)) {
-	// 创建搜索框
+// 输入不存在的命名空间时, 新命名空间属于projects中选择的集群/项目, 默认为current所在的项目
+func ShowSelectNamespaceDialog(window fyne.Window, db *rancher.DatabaseManager, projects []app.ProjectOption, current rancher.Namespace, needNewTag bool, onSelect func(namespace rancher.Namespace, tag string)) {
+	// 创建搜索框, 输入的名称不存在时作为新的命名空间
 	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("搜索命名空间...")
+	searchEntry.SetPlaceHolder("搜索或输入新的命名空间...")
 
 	namespaces, _ := db.GetAllNamespacesDetail()
 	// 添加一个变量来存储过滤后的命名空间
@@ -38,6 +40,34 @@ func ShowSelectNamespaceDialog(window fyne.Window, db *rancher.DatabaseManager,
 		selectedNamespace = filteredNamespaces[id]
 	}
 
+	// 新命名空间所属的集群/项目
+	var labels []string
+	for _, project := range projects {
+		labels = append(labels, project.Label)
+	}
+	projectSelect := widget.NewSelect(labels, nil)
+	projectSelect.PlaceHolder = "新命名空间所属的项目"
+	for _, project := range projects {
+		if project.Environment == current.Environment && project.Project == current.Project {
+			projectSelect.SetSelected(project.Label)
+		}
+	}
+	newNamespace := func(name string) rancher.Namespace {
+		namespace := rancher.Namespace{Name: name}
+		for _, project := range projects {
+			if project.Label == projectSelect.Selected {
+				namespace.Environment = project.Environment
+				namespace.Project = project.Project
+			}
+		}
+		return namespace
+	}
+	projectSelect.OnChanged = func(string) {
+		if selectedNamespace.Environment == "" || list.Length() == 0 {
+			selectedNamespace = newNamespace(selectedNamespace.Name)
+		}
+	}
+
 	// 添加搜索框事件
 	searchEntry.OnChanged = func(searchText string) {
 		filteredNamespaces = nil
@@ -51,7 +81,8 @@ func ShowSelectNamespaceDialog(window fyne.Window, db *rancher.DatabaseManager,
 			list.UnselectAll()
 			list.Select(0)
 		} else {
-			selectedNamespace = rancher.Namespace{}
+			// 没有匹配的命名空间时使用输入的名称, 由调用方确认是否创建
+			selectedNamespace = newNamespace(strings.TrimSpace(searchText))
 			list.UnselectAll()
 		}
 	}
@@ -64,16 +95,16 @@ func ShowSelectNamespaceDialog(window fyne.Window, db *rancher.DatabaseManager,
 		tagEntry.SetPlaceHolder("输入新的tag...")
 
 		content = container.NewBorder(
-			searchEntry, // 顶部放置搜索框
-			tagEntry,    // 底部放置tag输入框
+			container.NewVBox(searchEntry, projectSelect), // 顶部放置搜索框和新命名空间的项目
+			tagEntry, // 底部放置tag输入框
 			nil,
 			nil,
 			list, // 中间区域放置列表
 		)
 	} else {
 		content = container.NewBorder(
-			searchEntry, // 顶部放置搜索框
-			nil,         // 不需要tag输入框
+			container.NewVBox(searchEntry, projectSelect), // 顶部放置搜索框和新命名空间的项目
+			nil, // 不需要tag输入框
 			nil,
 			nil,
 			list, // 中间区域放置列表
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"RancherMan/rancher"
+	"RancherMan/ui/component"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowExecWindow 打开进入容器的终端窗口
+func ShowExecWindow(app fyne.App, client *rancher.Client, workload rancher.Workload) {
+	window := app.NewWindow(fmt.Sprintf("终端 - %s/%s", workload.Namespace, workload.Name))
+
+	var pods []rancher.PodResp
+	var session *rancher.ExecSession
+	var sessionMutex sync.Mutex
+	status := widget.NewLabel("")
+
+	terminal := component.NewTerminal(func(data []byte) {
+		sessionMutex.Lock()
+		current := session
+		sessionMutex.Unlock()
+		if current == nil {
+			status.SetText("未连接")
+			return
+		}
+		if _, err := current.Write(data); err != nil {
+			status.SetText(fmt.Sprintf("发送失败: %v", err))
+		}
+	})
+
+	containerSelect := widget.NewSelect(nil, nil)
+	containerSelect.PlaceHolder = "选择容器"
+	podSelect := widget.NewSelect(nil, func(name string) {
+		for _, pod := range pods {
+			if pod.Name == name {
+				containerSelect.Options = pod.ContainerNames()
+			}
+		}
+		if len(containerSelect.Options) > 0 {
+			containerSelect.SetSelectedIndex(0)
+		}
+		containerSelect.Refresh()
+	})
+	podSelect.PlaceHolder = "选择Pod"
+	shellSelect := widget.NewSelect([]string{"自动", "/bin/bash", "/bin/sh"}, nil)
+	shellSelect.SetSelected("自动")
+
+	disconnect := func() {
+		sessionMutex.Lock()
+		defer sessionMutex.Unlock()
+		if session != nil {
+			session.Close()
+			session = nil
+		}
+	}
+
+	connectButton := widget.NewButton("连接", func() {
+		if podSelect.Selected == "" {
+			status.SetText("请先选择Pod")
+			return
+		}
+		disconnect()
+		var command []string
+		if shellSelect.Selected != "自动" {
+			command = []string{shellSelect.Selected}
+		}
+		options := rancher.ExecOptions{
+			Namespace: workload.Namespace,
+			Pod:       podSelect.Selected,
+			Container: containerSelect.Selected,
+			Command:   command,
+			TTY:       true,
+		}
+		status.SetText(fmt.Sprintf("正在连接 %s ...", options.Pod))
+		go func() {
+			current, err := client.Exec(context.Background(), options)
+			if err != nil {
+				status.SetText(fmt.Sprintf("连接失败: %s", rancher.ErrorReason(err)))
+				return
+			}
+			sessionMutex.Lock()
+			session = current
+			sessionMutex.Unlock()
+			terminal.Clear()
+			status.SetText(fmt.Sprintf("已连接 %s/%s", options.Pod, options.Container))
+			terminal.FocusInput(window.Canvas())
+			err = current.Stream(terminal, terminal)
+			sessionMutex.Lock()
+			if session == current {
+				session = nil
+			}
+			sessionMutex.Unlock()
+			if err != nil {
+				status.SetText(fmt.Sprintf("会话结束: %v", err))
+			} else {
+				status.SetText("会话已结束")
+			}
+		}()
+	})
+	disconnectButton := widget.NewButton("断开", func() {
+		disconnect()
+	})
+	ctrlCButton := widget.NewButton("Ctrl+C", func() {
+		terminal.Send("\x03")
+	})
+	tabButton := widget.NewButton("Tab", func() {
+		terminal.Send("\t")
+	})
+	systemButton := widget.NewButton("系统终端", func() {
+		if podSelect.Selected == "" {
+			status.SetText("请先选择Pod")
+			return
+		}
+		if err := openSystemTerminal(workload.Namespace, podSelect.Selected, containerSelect.Selected); err != nil {
+			status.SetText(fmt.Sprintf("打开系统终端失败: %v", err))
+		}
+	})
+
+	go func() {
+		list, err := client.GetWorkloadPods(context.Background(), workload.Kind, workload.Namespace, workload.Name)
+		if err != nil {
+			status.SetText(fmt.Sprintf("获取Pod失败: %s", rancher.ErrorReason(err)))
+			return
+		}
+		pods = list
+		var names []string
+		for _, pod := range pods {
+			names = append(names, pod.Name)
+		}
+		podSelect.Options = names
+		podSelect.Refresh()
+		if len(names) > 0 {
+			podSelect.SetSelectedIndex(0)
+		} else {
+			status.SetText("该工作负载下没有Pod")
+		}
+	}()
+
+	toolbar := container.NewVBox(
+		container.NewGridWithColumns(3, podSelect, containerSelect, shellSelect),
+		container.NewHBox(connectButton, disconnectButton, ctrlCButton, tabButton, systemButton),
+	)
+	window.SetContent(container.NewBorder(toolbar, status, nil, nil, terminal))
+	window.SetOnClosed(disconnect)
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+}
+
+// openSystemTerminal 在系统终端中使用本机kubectl进入容器, 需要本机kubectl已配置对应集群
+func openSystemTerminal(namespace, pod, containerName string) error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("未找到kubectl")
+	}
+	args := []string{"kubectl", "exec", "-it", "-n", namespace, pod}
+	if containerName != "" {
+		args = append(args, "-c", containerName)
+	}
+	args = append(args, "--", "sh", "-c", "[ -x /bin/bash ] && exec /bin/bash || exec /bin/sh")
+	command := strings.Join(quoteArgs(args), " ")
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/C", "start", "cmd", "/K", command)
+	case "darwin":
+		script := fmt.Sprintf(`tell application "Terminal" to do script %q`, command)
+		cmd = exec.Command("osascript", "-e", script, "-e", `tell application "Terminal" to activate`)
+	default:
+		terminal := "x-terminal-emulator"
+		if _, err := exec.LookPath(terminal); err != nil {
+			terminal = "xterm"
+		}
+		cmd = exec.Command(terminal, "-e", "sh", "-c", command)
+	}
+	return cmd.Start()
+}
+
+// quoteArgs 为包含空格或特殊字符的参数加上引号
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " []&|;$") {
+			quoted[i] = fmt.Sprintf("%q", arg)
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return quoted
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"RancherMan/app"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowExportDialog 选择导出方式和保存位置。defaultName为默认的文件或目录名(不含扩展名),
+// chartName为默认的Helm chart名称; needNewTag为true时可以输入新的镜像标签
+func ShowExportDialog(window fyne.Window, defaultName string, chartName string, needNewTag bool, onConfirm func(target string, options app.ExportOptions, tag string)) {
+	workDir, _ := os.Getwd()
+	layout := app.LayoutSingleFile
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(filepath.Join(workDir, defaultName+layout.Extension()))
+
+	var labels []string
+	for _, item := range app.ExportLayouts {
+		labels = append(labels, item.Label())
+	}
+	layoutRadio := widget.NewRadioGroup(labels, nil)
+	layoutRadio.Required = true
+
+	chartEntry := widget.NewEntry()
+	chartEntry.SetText(chartName)
+	chartEntry.Disable()
+	helmCheck := widget.NewCheck("生成Helm chart, 命名空间、镜像标签和副本数写入values.yaml", nil)
+
+	layoutRadio.OnChanged = func(label string) {
+		previous := layout
+		for _, item := range app.ExportLayouts {
+			if item.Label() == label {
+				layout = item
+			}
+		}
+		// 切换导出方式时同步修改保存位置的扩展名
+		path := strings.TrimSuffix(pathEntry.Text, previous.Extension())
+		pathEntry.SetText(path + layout.Extension())
+		// Helm chart由多个文件组成, 不能导出为单个文件
+		if layout == app.LayoutSingleFile && helmCheck.Checked {
+			helmCheck.SetChecked(false)
+		}
+	}
+	layoutRadio.SetSelected(layout.Label())
+	helmCheck.OnChanged = func(checked bool) {
+		if checked {
+			chartEntry.Enable()
+			if layout == app.LayoutSingleFile {
+				layoutRadio.SetSelected(app.LayoutPerResource.Label())
+			}
+		} else {
+			chartEntry.Disable()
+		}
+	}
+
+	browseButton := newBrowseButton(window, pathEntry, func() bool { return layout.Extension() == "" })
+
+	form := widget.NewForm(
+		widget.NewFormItem("导出方式", layoutRadio),
+		widget.NewFormItem("保存位置", container.NewBorder(nil, nil, nil, browseButton, pathEntry)),
+		widget.NewFormItem("", helmCheck),
+		widget.NewFormItem("chart名称", chartEntry),
+	)
+	var tagEntry *widget.Entry
+	if needNewTag {
+		tagEntry = widget.NewEntry()
+		tagEntry.SetPlaceHolder("输入新的tag...")
+		form.Append("镜像标签", tagEntry)
+	}
+
+	confirm := dialog.NewCustomConfirm("导出", "导出", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		options := app.ExportOptions{Layout: layout, Helm: helmCheck.Checked, ChartName: strings.TrimSpace(chartEntry.Text)}
+		var tag string
+		if tagEntry != nil {
+			tag = tagEntry.Text
+		}
+		onConfirm(strings.TrimSpace(pathEntry.Text), options, tag)
+	}, window)
+	confirm.Resize(fyne.NewSize(600, 300))
+	confirm.Show()
+}
+
+// newBrowseButton 创建选择保存位置的浏览按钮, isDir返回true时选择目录, 否则选择保存的文件
+func newBrowseButton(window fyne.Window, pathEntry *widget.Entry, isDir func() bool) *widget.Button {
+	return widget.NewButton("浏览...", func() {
+		location, _ := storage.ListerForURI(storage.NewFileURI(filepath.Dir(pathEntry.Text)))
+		if isDir() {
+			folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+				if err == nil && uri != nil {
+					pathEntry.SetText(uri.Path())
+				}
+			}, window)
+			if location != nil {
+				folderDialog.SetLocation(location)
+			}
+			folderDialog.Show()
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			// 只需要路径, 文件在导出时写入
+			writer.Close()
+			pathEntry.SetText(writer.URI().Path())
+		}, window)
+		saveDialog.SetFileName(filepath.Base(pathEntry.Text))
+		if location != nil {
+			saveDialog.SetLocation(location)
+		}
+		saveDialog.Show()
+	})
+}
+
+// ShowKustomizeDialog 选择kustomize导出的目标环境和保存位置。environments为可选的目标环境,
+// 目标命名空间为空时与源命名空间同名; 导出到目录或tar.gz压缩包
+func ShowKustomizeDialog(window fyne.Window, environments []string, defaultName string, onConfirm func(target string, environments []string, destNamespace string, archive bool)) {
+	workDir, _ := os.Getwd()
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(filepath.Join(workDir, defaultName))
+
+	environmentGroup := widget.NewCheckGroup(environments, nil)
+	environmentGroup.Horizontal = true
+	namespaceEntry := widget.NewEntry()
+	namespaceEntry.SetPlaceHolder("默认与源命名空间同名")
+
+	archiveCheck := widget.NewCheck("打包为tar.gz", func(checked bool) {
+		path := strings.TrimSuffix(pathEntry.Text, app.LayoutArchive.Extension())
+		if checked {
+			path += app.LayoutArchive.Extension()
+		}
+		pathEntry.SetText(path)
+	})
+	browseButton := newBrowseButton(window, pathEntry, func() bool { return !archiveCheck.Checked })
+
+	form := widget.NewForm(
+		widget.NewFormItem("目标环境", environmentGroup),
+		widget.NewFormItem("目标命名空间", namespaceEntry),
+		widget.NewFormItem("保存位置", container.NewBorder(nil, nil, nil, browseButton, pathEntry)),
+		widget.NewFormItem("", archiveCheck),
+	)
+	confirm := dialog.NewCustomConfirm("导出kustomize", "导出", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		onConfirm(strings.TrimSpace(pathEntry.Text), environmentGroup.Selected, strings.TrimSpace(namespaceEntry.Text), archiveCheck.Checked)
+	}, window)
+	confirm.Resize(fyne.NewSize(600, 300))
+	confirm.Show()
+}
+
+// ShowSecretOptionsDialog 选择如何处理Secret。askInclude为true时先选择是否包含Secret, 否则Secret总是包含;
+// 脱敏时只保留键名, 值替换为占位符, 克隆时不覆盖目标命名空间中已有的Secret
+func ShowSecretOptionsDialog(window fyne.Window, askInclude bool, onConfirm func(includeSecrets bool, redactSecrets bool)) {
+	redactCheck := widget.NewCheck("值脱敏, 只保留键名", nil)
+	content := container.NewVBox()
+	includeCheck := widget.NewCheck("包含Secret", func(checked bool) {
+		if checked {
+			redactCheck.Enable()
+		} else {
+			redactCheck.SetChecked(false)
+			redactCheck.Disable()
+		}
+	})
+	if askInclude {
+		redactCheck.Disable()
+		content.Add(includeCheck)
+	} else {
+		includeCheck.SetChecked(true)
+	}
+	content.Add(redactCheck)
+	confirm := dialog.NewCustomConfirm("Secret", "确定", "取消", content, func(ok bool) {
+		if ok {
+			onConfirm(includeCheck.Checked, redactCheck.Checked)
+		}
+	}, window)
+	confirm.Show()
+}
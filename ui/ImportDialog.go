@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowImportSourceDialog 选择要导入的本地YAML文件、目录或tar.gz压缩包, 可以添加多个。确认时以选择的路径调用onConfirm
+func ShowImportSourceDialog(window fyne.Window, onConfirm func(paths []string)) {
+	var paths []string
+	list := widget.NewList(
+		func() int { return len(paths) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("template")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(paths[id])
+		},
+	)
+	addPath := func(path string) {
+		for _, existing := range paths {
+			if existing == path {
+				return
+			}
+		}
+		paths = append(paths, path)
+		list.Refresh()
+	}
+
+	addFile := widget.NewButton("添加文件", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			// 只需要路径, 文件在导入时读取
+			reader.Close()
+			addPath(reader.URI().Path())
+		}, window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".yaml", ".yml", ".gz", ".tgz"}))
+		fileDialog.Show()
+	})
+	addFolder := widget.NewButton("添加目录", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				addPath(uri.Path())
+			}
+		}, window)
+	})
+	clearButton := widget.NewButton("清空", func() {
+		paths = nil
+		list.Refresh()
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("支持多文档YAML、导出的目录和tar.gz压缩包"),
+		container.NewHBox(addFile, addFolder, clearButton),
+		nil,
+		nil,
+		list,
+	)
+	confirm := dialog.NewCustomConfirm("导入YAML", "下一步", "取消", content, func(ok bool) {
+		if ok && len(paths) > 0 {
+			onConfirm(paths)
+		}
+	}, window)
+	confirm.Resize(fyne.NewSize(600, 400))
+	confirm.Show()
+}
@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"RancherMan/rancher"
+	"context"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 日志窗口最多保留的行数, 超出后丢弃最早的日志
+const maxLogLines = 5000
+
+// logBuffer 按行缓存日志, 实现io.Writer供日志流写入
+type logBuffer struct {
+	mutex   sync.Mutex
+	lines   []string
+	partial string
+	dirty   bool
+}
+
+func (b *logBuffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	text := b.partial + string(data)
+	parts := strings.Split(text, "\n")
+	b.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		b.lines = append(b.lines, strings.TrimSuffix(line, "\r"))
+	}
+	if len(b.lines) > maxLogLines {
+		b.lines = b.lines[len(b.lines)-maxLogLines:]
+	}
+	b.dirty = true
+	return len(data), nil
+}
+
+// snapshot 返回当前所有行(包含未换行的部分)及是否有新内容
+func (b *logBuffer) snapshot() ([]string, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	lines := append([]string{}, b.lines...)
+	if b.partial != "" {
+		lines = append(lines, b.partial)
+	}
+	dirty := b.dirty
+	b.dirty = false
+	return lines, dirty
+}
+
+func (b *logBuffer) reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.lines = nil
+	b.partial = ""
+	b.dirty = true
+}
+
+// parseSince 解析起始时间, 支持相对时长(如 10m、2h)和绝对时间(2006-01-02 15:04:05)
+func parseSince(text string) (time.Time, int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, 0, nil
+	}
+	if duration, err := time.ParseDuration(text); err == nil {
+		return time.Time{}, int(duration.Seconds()), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", text, time.Local); err == nil {
+		return t, 0, nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, 0, nil
+	}
+	return time.Time{}, 0, fmt.Errorf("无法识别的起始时间: %s", text)
+}
+
+// ShowLogWindow 打开工作负载的容器日志窗口
+func ShowLogWindow(app fyne.App, client *rancher.Client, workload rancher.Workload) {
+	window := app.NewWindow(fmt.Sprintf("日志 - %s/%s", workload.Namespace, workload.Name))
+
+	var pods []rancher.PodResp
+	var selectedPod rancher.PodResp
+	var cancelStream context.CancelFunc
+	var streamMutex sync.Mutex
+	buffer := &logBuffer{}
+
+	podInfo := widget.NewLabel("")
+	containerSelect := widget.NewSelect(nil, nil)
+	podSelect := widget.NewSelect(nil, func(name string) {
+		for _, pod := range pods {
+			if pod.Name == name {
+				selectedPod = pod
+			}
+		}
+		podInfo.SetText(fmt.Sprintf("节点: %s    状态: %s    重启次数: %d", selectedPod.NodeId, selectedPod.State, selectedPod.RestartCount()))
+		containerSelect.Options = selectedPod.ContainerNames()
+		if len(containerSelect.Options) > 0 {
+			containerSelect.SetSelectedIndex(0)
+		} else {
+			containerSelect.ClearSelected()
+		}
+		containerSelect.Refresh()
+	})
+	podSelect.PlaceHolder = "选择Pod"
+	containerSelect.PlaceHolder = "选择容器"
+
+	followCheck := widget.NewCheck("跟踪", nil)
+	followCheck.SetChecked(true)
+	previousCheck := widget.NewCheck("上一个容器", nil)
+	timestampsCheck := widget.NewCheck("时间戳", nil)
+	tailEntry := widget.NewEntry()
+	tailEntry.SetText("200")
+	tailEntry.SetPlaceHolder("行数")
+	sinceEntry := widget.NewEntry()
+	sinceEntry.SetPlaceHolder("起始时间, 如 10m 或 2006-01-02 15:04:05")
+
+	grid := widget.NewTextGrid()
+	scroll := container.NewScroll(grid)
+	status := widget.NewLabel("")
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("搜索日志...")
+	highlight := &widget.CustomTextGridStyle{BGColor: theme.Color(theme.ColorNamePrimary), FGColor: color.White}
+
+	// render 将缓存的日志刷新到界面并高亮搜索结果
+	render := func(force bool) {
+		lines, dirty := buffer.snapshot()
+		if !dirty && !force {
+			return
+		}
+		grid.SetText(strings.Join(lines, "\n"))
+		keyword := searchEntry.Text
+		matches := 0
+		firstMatch := -1
+		if keyword != "" {
+			lowerKeyword := strings.ToLower(keyword)
+			for row, line := range lines {
+				lowerLine := []rune(strings.ToLower(line))
+				keywordRunes := []rune(lowerKeyword)
+				for col := 0; col+len(keywordRunes) <= len(lowerLine); {
+					if string(lowerLine[col:col+len(keywordRunes)]) == lowerKeyword {
+						grid.SetStyleRange(row, col, row, col+len(keywordRunes)-1, highlight)
+						matches++
+						if firstMatch < 0 {
+							firstMatch = row
+						}
+						col += len(keywordRunes)
+					} else {
+						col++
+					}
+				}
+			}
+			status.SetText(fmt.Sprintf("%d 行, 匹配 %d 处", len(lines), matches))
+		} else {
+			status.SetText(fmt.Sprintf("%d 行", len(lines)))
+		}
+		if keyword != "" && firstMatch >= 0 && force {
+			lineHeight := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true}).Height
+			scroll.Offset = fyne.NewPos(0, float32(firstMatch)*lineHeight)
+			scroll.Refresh()
+		} else if keyword == "" && followCheck.Checked {
+			scroll.ScrollToBottom()
+		}
+	}
+	searchEntry.OnChanged = func(string) {
+		render(true)
+	}
+
+	var startButton *widget.Button
+	// stop 停止当前日志流, 返回是否有正在读取的日志
+	stop := func() bool {
+		streamMutex.Lock()
+		defer streamMutex.Unlock()
+		if cancelStream == nil {
+			return false
+		}
+		cancelStream()
+		cancelStream = nil
+		startButton.SetText("开始")
+		return true
+	}
+	startButton = widget.NewButton("开始", func() {
+		if stop() {
+			return
+		}
+		if selectedPod.Name == "" {
+			status.SetText("请先选择Pod")
+			return
+		}
+		tailLines := 0
+		if tailEntry.Text != "" {
+			lines, err := strconv.Atoi(tailEntry.Text)
+			if err != nil {
+				status.SetText("行数必须是数字")
+				return
+			}
+			tailLines = lines
+		}
+		sinceTime, sinceSeconds, err := parseSince(sinceEntry.Text)
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		options := rancher.LogOptions{
+			Namespace:    workload.Namespace,
+			Pod:          selectedPod.Name,
+			Container:    containerSelect.Selected,
+			Follow:       followCheck.Checked,
+			TailLines:    tailLines,
+			SinceTime:    sinceTime,
+			SinceSeconds: sinceSeconds,
+			Previous:     previousCheck.Checked,
+			Timestamps:   timestampsCheck.Checked,
+		}
+		buffer.reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		streamMutex.Lock()
+		cancelStream = cancel
+		streamMutex.Unlock()
+		startButton.SetText("停止")
+		go func() {
+			// 定时刷新界面, 避免每收到一帧就重绘
+			ticker := time.NewTicker(300 * time.Millisecond)
+			defer ticker.Stop()
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						render(false)
+					}
+				}
+			}()
+			err := client.StreamLogs(ctx, options, buffer)
+			render(true)
+			if err != nil && !rancher.IsCanceled(err) {
+				status.SetText(fmt.Sprintf("读取日志失败: %s", rancher.ErrorReason(err)))
+			}
+			if ctx.Err() == nil {
+				// 日志流自然结束
+				stop()
+			}
+		}()
+	})
+
+	refreshPods := func() {
+		go func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			list, err := client.GetWorkloadPods(ctx, workload.Kind, workload.Namespace, workload.Name)
+			if err != nil {
+				status.SetText(fmt.Sprintf("获取Pod失败: %s", rancher.ErrorReason(err)))
+				return
+			}
+			pods = list
+			var names []string
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			podSelect.Options = names
+			podSelect.Refresh()
+			if len(names) > 0 {
+				podSelect.SetSelectedIndex(0)
+			} else {
+				status.SetText("该工作负载下没有Pod")
+			}
+		}()
+	}
+
+	saveButton := widget.NewButton("保存到文件", func() {
+		lines, _ := buffer.snapshot()
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			status.SetText(fmt.Sprintf("已保存到 %s", writer.URI().Path()))
+		}, window)
+	})
+	clearButton := widget.NewButton("清空", func() {
+		buffer.reset()
+		render(true)
+	})
+
+	toolbar := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Pod"), widget.NewButton("刷新", refreshPods), podSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("容器"), nil, containerSelect),
+		podInfo,
+		container.NewGridWithColumns(2, tailEntry, sinceEntry),
+		container.NewHBox(followCheck, previousCheck, timestampsCheck, startButton, clearButton, saveButton),
+		searchEntry,
+	)
+	window.SetContent(container.NewBorder(toolbar, status, nil, nil, scroll))
+	window.SetOnClosed(func() {
+		stop()
+	})
+	window.Resize(fyne.NewSize(900, 600))
+	window.Show()
+	refreshPods()
+}
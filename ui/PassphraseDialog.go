@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"RancherMan/rancher/config"
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowPassphraseDialog 输入加密配置中密钥使用的主密码。existing为true时需要输入之前使用的主密码;
+// 否则可以设置新的主密码(需要输入两次), 留空表示在keyFile生成密钥文件。确认时以输入的主密码调用onConfirm,
+// 取消时调用onCancel
+func ShowPassphraseDialog(window fyne.Window, keyFile string, existing bool, onConfirm func(passphrase string), onCancel func()) {
+	passphraseEntry := widget.NewPasswordEntry()
+	items := []*widget.FormItem{widget.NewFormItem("主密码", passphraseEntry)}
+	title := "设置主密码"
+	message := "配置中的API密钥和跳板机密码需要加密保存。\n设置主密码, 或留空在以下位置生成密钥文件:\n" + keyFile
+	if existing {
+		title = "输入主密码"
+		message = "配置中的密钥已使用主密码加密, 请输入之前使用的主密码"
+		passphraseEntry.Validator = func(text string) error {
+			if text == "" {
+				return errors.New("请输入主密码")
+			}
+			return nil
+		}
+	} else {
+		confirmEntry := widget.NewPasswordEntry()
+		confirmEntry.Validator = func(text string) error {
+			if text != passphraseEntry.Text {
+				return errors.New("两次输入的主密码不一致")
+			}
+			return nil
+		}
+		passphraseEntry.OnChanged = func(string) { confirmEntry.Validate() }
+		items = append(items, widget.NewFormItem("确认主密码", confirmEntry))
+	}
+	message += "\n也可以通过环境变量 " + config.PassphraseEnv + " 设置主密码"
+	items = append([]*widget.FormItem{widget.NewFormItem("", widget.NewLabel(message))}, items...)
+
+	form := dialog.NewForm(title, "确定", "取消", items, func(ok bool) {
+		if !ok {
+			if onCancel != nil {
+				onCancel()
+			}
+			return
+		}
+		onConfirm(passphraseEntry.Text)
+	}, window)
+	form.Resize(fyne.NewSize(520, 0))
+	form.Show()
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"RancherMan/app"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 并排对比时左边一栏的最大宽度(字符), 超出部分截断
+const maxPreviewColumn = 80
+
+var (
+	deletedStyle  = &widget.CustomTextGridStyle{BGColor: color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0x50}}
+	insertedStyle = &widget.CustomTextGridStyle{BGColor: color.NRGBA{R: 0x43, G: 0xa0, B: 0x47, A: 0x50}}
+)
+
+// ShowClonePreviewDialog 显示克隆前的差异预览。左侧列出每个资源及其状态, 勾选的资源会被导入;
+// 右侧并排显示目标命名空间中的现有内容和将要导入的内容。确认时以勾选结果调用onConfirm, 取消时调用onCancel
+func ShowClonePreviewDialog(window fyne.Window, previews []app.ClonePreview, onConfirm func(previews []app.ClonePreview), onCancel func()) {
+	grid := widget.NewTextGrid()
+	header := widget.NewLabel("")
+
+	showDiff := func(preview app.ClonePreview) {
+		header.SetText(fmt.Sprintf("%s %s: %s    左: 目标命名空间现有内容    右: 将要导入的内容", preview.Kind, preview.Name, preview.Status))
+		renderSideBySide(grid, app.SideBySide(preview.Diff()))
+	}
+
+	list := widget.NewList(
+		func() int { return len(previews) },
+		func() fyne.CanvasObject {
+			return widget.NewCheck("template", nil)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			check := item.(*widget.Check)
+			preview := previews[id]
+			check.Text = fmt.Sprintf("%s [%s]", preview.Name, preview.Status)
+			// 先清除回调, 避免设置状态时修改其他行的选择
+			check.OnChanged = nil
+			check.SetChecked(preview.Accept)
+			check.OnChanged = func(checked bool) {
+				previews[id].Accept = checked
+			}
+			check.Refresh()
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		showDiff(previews[id])
+	}
+
+	selectAll := widget.NewButton("全选", func() {
+		for i := range previews {
+			previews[i].Accept = true
+		}
+		list.Refresh()
+	})
+	selectNone := widget.NewButton("全不选", func() {
+		for i := range previews {
+			previews[i].Accept = false
+		}
+		list.Refresh()
+	})
+
+	left := container.NewBorder(nil, container.NewHBox(selectAll, selectNone), nil, nil, list)
+	right := container.NewBorder(header, nil, nil, nil, container.NewScroll(grid))
+	split := container.NewHSplit(left, right)
+	split.Offset = 0.25
+
+	confirm := dialog.NewCustomConfirm("克隆预览", "导入选中的资源", "取消", split, func(ok bool) {
+		if ok {
+			onConfirm(previews)
+		} else if onCancel != nil {
+			onCancel()
+		}
+	}, window)
+	confirm.Resize(fyne.NewSize(1000, 650))
+	confirm.Show()
+	if len(previews) > 0 {
+		list.Select(0)
+	}
+}
+
+// renderSideBySide 在一个TextGrid中并排显示差异, 保证左右两边滚动同步
+func renderSideBySide(grid *widget.TextGrid, rows []app.DiffRow) {
+	width := 0
+	for _, row := range rows {
+		if row.Left != nil {
+			width = max(width, len([]rune(*row.Left)))
+		}
+	}
+	width = min(width, maxPreviewColumn)
+
+	var text strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(padColumn(row.Left, width))
+		text.WriteString(" │ ")
+		if row.Right != nil {
+			text.WriteString(*row.Right)
+		}
+	}
+	grid.SetText(text.String())
+	for i, row := range rows {
+		if !row.Changed() {
+			continue
+		}
+		if row.Left != nil && width > 0 {
+			grid.SetStyleRange(i, 0, i, width-1, deletedStyle)
+		}
+		if row.Right != nil && *row.Right != "" {
+			start := width + len([]rune(" │ "))
+			grid.SetStyleRange(i, start, i, start+len([]rune(*row.Right))-1, insertedStyle)
+		}
+	}
+	grid.Refresh()
+}
+
+// padColumn 将文本截断或补齐到指定宽度
+func padColumn(text *string, width int) string {
+	var runes []rune
+	if text != nil {
+		runes = []rune(*text)
+	}
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
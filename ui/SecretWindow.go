@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"RancherMan/app"
+	"RancherMan/rancher"
+	"RancherMan/rancher/types/secrets"
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// secretMask 未显示的值在界面上的占位符
+const secretMask = "******"
+
+// ShowSecretWindow 打开命名空间的Secret管理窗口。值默认隐藏, 点击显示后才解码; 可以复制单个值、编辑和新建Secret
+func ShowSecretWindow(application fyne.App, service *app.Service, namespace rancher.Namespace) {
+	window := application.NewWindow(fmt.Sprintf("Secret - %s", namespace.Name))
+
+	var secretList []secrets.Secret
+	var selected *secrets.Secret
+	// 当前Secret中已显示的键, 切换Secret时清空
+	revealed := map[string]bool{}
+	status := widget.NewLabel("")
+	header := widget.NewLabel("")
+
+	var keys []string
+	var keyList *widget.List
+	keyList = widget.NewList(
+		func() int { return len(keys) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewLabel("key"),
+				container.NewHBox(widget.NewButton("显示", nil), widget.NewButton("复制", nil)),
+				widget.NewLabel("value"))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			key := keys[id]
+			row := item.(*fyne.Container)
+			valueLabel := row.Objects[0].(*widget.Label)
+			keyLabel := row.Objects[1].(*widget.Label)
+			buttons := row.Objects[2].(*fyne.Container)
+			revealButton := buttons.Objects[0].(*widget.Button)
+			copyButton := buttons.Objects[1].(*widget.Button)
+
+			keyLabel.SetText(key)
+			valueLabel.SetText(secretMask)
+			revealButton.SetText("显示")
+			if revealed[key] {
+				if value, err := selected.Value(key); err != nil {
+					valueLabel.SetText(err.Error())
+				} else {
+					// 多行的值只显示第一行, 完整内容可以复制或在编辑中查看
+					firstLine, _, multiLine := strings.Cut(value, "\n")
+					if multiLine {
+						firstLine += " ..."
+					}
+					valueLabel.SetText(firstLine)
+				}
+				revealButton.SetText("隐藏")
+			}
+			revealButton.OnTapped = func() {
+				revealed[key] = !revealed[key]
+				keyList.RefreshItem(id)
+			}
+			copyButton.OnTapped = func() {
+				value, err := selected.Value(key)
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				window.Clipboard().SetContent(value)
+				status.SetText(fmt.Sprintf("已复制 %s", key))
+			}
+		},
+	)
+
+	showSecret := func(secret *secrets.Secret) {
+		selected = secret
+		revealed = map[string]bool{}
+		keys = nil
+		header.SetText("")
+		if secret != nil {
+			keys = secret.Keys()
+			header.SetText(fmt.Sprintf("%s    类型: %s    %d 个键", secret.Metadata.Name, secret.Type, len(keys)))
+		}
+		keyList.Refresh()
+	}
+
+	secretNames := widget.NewList(
+		func() int { return len(secretList) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("template")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(secretList[id].Metadata.Name)
+		},
+	)
+	secretNames.OnSelected = func(id widget.ListItemID) {
+		showSecret(&secretList[id])
+	}
+
+	refresh := func(selectName string) {
+		status.SetText("正在读取...")
+		go func() {
+			list, err := service.Secrets(context.Background(), namespace)
+			if err != nil {
+				status.SetText(rancher.ErrorReason(err))
+				return
+			}
+			secretList = list
+			secretNames.UnselectAll()
+			showSecret(nil)
+			secretNames.Refresh()
+			status.SetText(fmt.Sprintf("共 %d 个Secret", len(secretList)))
+			for i := range secretList {
+				if secretList[i].Metadata.Name == selectName {
+					secretNames.Select(i)
+				}
+			}
+		}()
+	}
+
+	save := func(secret secrets.Secret, create bool) {
+		status.SetText(fmt.Sprintf("正在保存 %s ...", secret.Metadata.Name))
+		go func() {
+			if err := service.SaveSecret(context.Background(), namespace, secret, create); err != nil {
+				status.SetText(rancher.ErrorReason(err))
+				dialog.ShowError(err, window)
+				return
+			}
+			refresh(secret.Metadata.Name)
+		}()
+	}
+
+	refreshButton := widget.NewButton("刷新", func() {
+		name := ""
+		if selected != nil {
+			name = selected.Metadata.Name
+		}
+		refresh(name)
+	})
+	createButton := widget.NewButton("新建", func() {
+		showSecretEditor(window, secrets.Secret{Type: "Opaque"}, true, func(secret secrets.Secret) {
+			save(secret, true)
+		})
+	})
+	editButton := widget.NewButton("编辑", func() {
+		if selected == nil {
+			status.SetText("请先选择Secret")
+			return
+		}
+		showSecretEditor(window, *selected, false, func(secret secrets.Secret) {
+			save(secret, false)
+		})
+	})
+
+	left := container.NewBorder(container.NewHBox(refreshButton, createButton), nil, nil, nil, secretNames)
+	right := container.NewBorder(container.NewBorder(nil, nil, nil, editButton, header), nil, nil, nil, keyList)
+	split := container.NewHSplit(left, right)
+	split.Offset = 0.3
+
+	window.SetContent(container.NewBorder(nil, status, nil, nil, split))
+	window.Resize(fyne.NewSize(900, 500))
+	window.Show()
+	refresh("")
+}
+
+// secretEntryRow 编辑Secret时的一个键值对
+type secretEntryRow struct {
+	key   *widget.Entry
+	value *widget.Entry
+}
+
+// showSecretEditor 编辑或新建Secret, 编辑时解码所有值以便修改。确认后以修改后的Secret调用onSave
+func showSecretEditor(window fyne.Window, secret secrets.Secret, create bool, onSave func(secret secrets.Secret)) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(secret.Metadata.Name)
+	typeEntry := widget.NewEntry()
+	typeEntry.SetText(secret.Type)
+	if !create {
+		// 名称和类型创建后不能修改
+		nameEntry.Disable()
+		typeEntry.Disable()
+	}
+
+	var rows []*secretEntryRow
+	rowsBox := container.NewVBox()
+	var render func()
+	addRow := func(key, value string) {
+		row := &secretEntryRow{key: widget.NewEntry(), value: widget.NewMultiLineEntry()}
+		row.key.SetText(key)
+		row.key.SetPlaceHolder("键")
+		row.value.SetText(value)
+		row.value.SetPlaceHolder("值")
+		row.value.SetMinRowsVisible(2)
+		rows = append(rows, row)
+	}
+	render = func() {
+		rowsBox.RemoveAll()
+		for i, row := range rows {
+			index := i
+			removeButton := widget.NewButton("删除", func() {
+				rows = append(rows[:index], rows[index+1:]...)
+				render()
+			})
+			rowsBox.Add(container.NewBorder(nil, nil, container.NewGridWrap(fyne.NewSize(180, 36), row.key), removeButton, row.value))
+		}
+		rowsBox.Refresh()
+	}
+	for _, key := range secret.Keys() {
+		value, err := secret.Value(key)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		addRow(key, value)
+	}
+	render()
+
+	addButton := widget.NewButton("添加键", func() {
+		addRow("", "")
+		render()
+	})
+	form := widget.NewForm(
+		widget.NewFormItem("名称", nameEntry),
+		widget.NewFormItem("类型", typeEntry),
+	)
+	content := container.NewBorder(form, addButton, nil, nil, container.NewVScroll(rowsBox))
+
+	title := "编辑Secret"
+	if create {
+		title = "新建Secret"
+	}
+	editor := dialog.NewCustomConfirm(title, "保存", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		secret.Metadata.Name = strings.TrimSpace(nameEntry.Text)
+		secret.Type = strings.TrimSpace(typeEntry.Text)
+		secret.Data = nil
+		for _, row := range rows {
+			key := strings.TrimSpace(row.key.Text)
+			if key == "" {
+				continue
+			}
+			if _, exists := secret.Data[key]; exists {
+				dialog.ShowError(fmt.Errorf("键%s重复", key), window)
+				return
+			}
+			secret.SetValue(key, row.value.Text)
+		}
+		onSave(secret)
+	}, window)
+	editor.Resize(fyne.NewSize(700, 500))
+	editor.Show()
+}
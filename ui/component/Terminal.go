@@ -0,0 +1,106 @@
+package component
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 终端最多保留的行数
+const maxTerminalLines = 3000
+
+// ansiPattern 匹配终端控制序列(颜色、光标移动、窗口标题等)
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[=>]`)
+
+// Terminal 一个简单的行式终端组件, 显示输出并把输入的每一行发送出去
+type Terminal struct {
+	widget.BaseWidget
+	grid    *widget.TextGrid
+	scroll  *container.Scroll
+	input   *widget.Entry
+	mutex   sync.Mutex
+	lines   []string
+	current []rune
+	onInput func(data []byte)
+}
+
+// NewTerminal 创建终端组件, onInput 在用户输入时被调用
+func NewTerminal(onInput func(data []byte)) *Terminal {
+	t := &Terminal{
+		grid:    widget.NewTextGrid(),
+		input:   widget.NewEntry(),
+		onInput: onInput,
+	}
+	t.scroll = container.NewScroll(t.grid)
+	t.input.SetPlaceHolder("输入命令后回车发送")
+	t.input.OnSubmitted = func(text string) {
+		t.input.SetText("")
+		t.Send(text + "\n")
+	}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// Send 向终端发送原始输入, 如控制字符 "\x03"
+func (t *Terminal) Send(text string) {
+	if t.onInput != nil {
+		t.onInput([]byte(text))
+	}
+}
+
+// Write 写入终端输出, 会去除控制序列并处理回车、退格
+func (t *Terminal) Write(data []byte) (int, error) {
+	t.mutex.Lock()
+	text := []rune(ansiPattern.ReplaceAllString(string(data), ""))
+	for i, r := range text {
+		switch r {
+		case '\n':
+			t.lines = append(t.lines, string(t.current))
+			t.current = nil
+		case '\r':
+			// 回车后通常紧跟换行, 单独出现时表示回到行首
+			if i+1 < len(text) && text[i+1] == '\n' {
+				continue
+			}
+			t.current = t.current[:0]
+		case '\b':
+			if len(t.current) > 0 {
+				t.current = t.current[:len(t.current)-1]
+			}
+		case '\a', 0:
+		default:
+			t.current = append(t.current, r)
+		}
+	}
+	if len(t.lines) > maxTerminalLines {
+		t.lines = t.lines[len(t.lines)-maxTerminalLines:]
+	}
+	content := strings.Join(append(append([]string{}, t.lines...), string(t.current)), "\n")
+	t.mutex.Unlock()
+
+	t.grid.SetText(content)
+	t.scroll.ScrollToBottom()
+	return len(data), nil
+}
+
+// Clear 清空终端输出
+func (t *Terminal) Clear() {
+	t.mutex.Lock()
+	t.lines = nil
+	t.current = nil
+	t.mutex.Unlock()
+	t.grid.SetText("")
+}
+
+// FocusInput 让输入框获得焦点
+func (t *Terminal) FocusInput(canvas fyne.Canvas) {
+	canvas.Focus(t.input)
+}
+
+func (t *Terminal) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, t.input, nil, nil, t.scroll))
+}